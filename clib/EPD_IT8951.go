@@ -15,6 +15,7 @@ import (
 const INIT_Mode = 0
 const A2_Mode = 6
 const GC16_Mode = 2
+const GL16_Mode = 3 // grayscale update without the flash, leaves more ghosting than GC16
 
 const EPD_RST_PIN = 17
 const EPD_CS_PIN = 8
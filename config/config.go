@@ -32,6 +32,8 @@ type homeAssistantSettings struct {
 	InternalHumiditySensor    string `json:"internal_humidity_sensor"`
 	ExternalHumiditySensor    string `json:"external_humidity_sensor"`
 	PressureSensor            string `json:"pressure_sensor"`
+	WindDirectionSensor       string `json:"wind_direction_sensor"` // degrees, optional, improves the local forecast
+	WindSpeedSensor           string `json:"wind_speed_sensor"`     // m/s, optional, enables the wind chill
 }
 
 type openWeatherMapSettings struct {
@@ -45,26 +47,246 @@ type daylightSettings struct {
 	Longitude float64 `json:"longitude"`
 }
 
+type unitsSettings struct {
+	Temperature   string `json:"temperature"`   // celsius or fahrenheit
+	Pressure      string `json:"pressure"`      // hpa, mmhg or inhg
+	WindSpeed     string `json:"wind_speed"`    // ms, kmh or mph
+	Precipitation string `json:"precipitation"` // mm or in
+	TimeFormat    string `json:"time_format"`   // 24h or 12h
+}
+
+type localeSettings struct {
+	Language       string `json:"language"`          // en or ru
+	FirstDayOfWeek string `json:"first_day_of_week"` // monday or sunday
+}
+
+type agendaSettings struct {
+	MaxItems  int `json:"max_items"`  // 10 if not set, fewer are shown if they don't fit
+	DaysAhead int `json:"days_ahead"` // 60 if not set
+}
+
+type clockSettings struct {
+	Font     string `json:"font"`      // TTF or OTF file the digits are drawn with, fonts/VerilySerifMono.otf if not set
+	ShowDate bool   `json:"show_date"` // weekday and date line under the time
+}
+
+type nightModeSettings struct {
+	Schedule       string                 `json:"schedule"`        // off (default), daylight (from sunset to sunrise) or fixed
+	From           string                 `json:"from"`            // start of the quiet hours as HH:MM, fixed schedule only
+	To             string                 `json:"to"`              // end of the quiet hours as HH:MM, fixed schedule only
+	Widgets        []string               `json:"widgets"`         // layout names of the widgets shown at night, all if empty
+	Layout         map[string]*WidgetRect `json:"layout"`          // night positions and modes of the widgets, the day ones if missing
+	Invert         bool                   `json:"invert"`          // white on black at night
+	RefreshSeconds int                    `json:"refresh_seconds"` // minimal interval between screen updates at night, 60 if not set
+}
+
+type airQualitySettings struct {
+	Source         string `json:"source"`          // ha (default), open_meteo or openweathermap at the daylight coordinates, PM2.5 and PM10 are read from it
+	Co2Sensor      string `json:"co2_sensor"`      // HA sensor in ppm, optional, read with any source
+	Pm25Sensor     string `json:"pm25_sensor"`     // HA sensor in µg/m³, ha source only
+	Pm10Sensor     string `json:"pm10_sensor"`     // HA sensor in µg/m³, ha source only, optional
+	RefreshMinutes int    `json:"refresh_minutes"` // how often the web sources are queried, 30 if not set
+	WarningAqi     int    `json:"warning_aqi"`     // the warning sign is shown above this US AQI, 100 if not set
+	WarningCo2     int    `json:"warning_co2"`     // or above this CO2 level in ppm, 1200 if not set
+}
+
+// AlertFeed is a file or URL of severe weather alerts
+type AlertFeed struct {
+	Id             string `json:"id"`
+	Source         string `json:"source"`          // local file path, file:// or http(s):// URL
+	Format         string `json:"format"`          // atom (default) for CAP entries of an Atom feed, cap for a CAP 1.2 document or owm for the One Call API
+	RefreshMinutes int    `json:"refresh_minutes"` // 0 for every 15 minutes
+}
+
+type alertsSettings struct {
+	Feeds       []*AlertFeed `json:"feeds"`
+	Slot        string       `json:"slot"`         // layout name of the widget the banner takes over while an alert is active, forecast if not set
+	MinSeverity string       `json:"min_severity"` // minor, moderate (default), severe or extreme
+}
+
+// EnergySettings lists the HA sensors of the energy widget, only the solar one is required
+type EnergySettings struct {
+	SolarSensor       string `json:"solar_sensor"`
+	GridImportSensor  string `json:"grid_import_sensor"`
+	GridExportSensor  string `json:"grid_export_sensor"`
+	ConsumptionSensor string `json:"consumption_sensor"` // solar + import - export if not set
+	BatterySensor     string `json:"battery_sensor"`     // state of charge in %
+	SensorType        string `json:"sensor_type"`        // power (default) for sensors in W or energy for meters in kWh
+	RefreshMinutes    int    `json:"refresh_minutes"`    // how often the history is downloaded, 15 if not set
+}
+
+// TransitStop is a stop of the departures widget
+type TransitStop struct {
+	Id     string   `json:"id"`     // stop_id of the GTFS feed
+	Label  string   `json:"label"`  // the stop name from the feed if not set
+	Routes []string `json:"routes"` // short names of the routes shown, all if empty
+}
+
+// TransitSettings configures the public transport departures widget
+type TransitSettings struct {
+	Gtfs          string         `json:"gtfs"`     // GTFS static zip, local file path, file:// or http(s):// URL
+	Realtime      string         `json:"realtime"` // GTFS-Realtime trip updates URL, optional
+	Stops         []*TransitStop `json:"stops"`
+	MaxDepartures int            `json:"max_departures"` // per stop, 5 if not set
+	WalkMinutes   int            `json:"walk_minutes"`   // departures sooner than this are not shown
+}
+
+// TideConstituent is a harmonic constituent of the tide at the station, as listed by the tide tables
+type TideConstituent struct {
+	Name      string  `json:"name"`      // M2, S2, N2, K1, O1, ...
+	Amplitude float64 `json:"amplitude"` // in the height unit
+	Phase     float64 `json:"phase"`     // Greenwich phase lag in degrees, i.e. relative to UTC
+}
+
+// TideSettings configures the tide widget, the tides are predicted from the constituents or read from the table
+type TideSettings struct {
+	Label          string             `json:"label"`
+	Datum          float64            `json:"datum"` // mean sea level above the chart datum, added to the predicted heights
+	Constituents   []*TideConstituent `json:"constituents"`
+	Table          string             `json:"table"`           // CSV or JSON of the high and low tides, local file path, file:// or http(s):// URL
+	Unit           string             `json:"unit"`            // of the heights, shown as is, m if not set
+	MaxTides       int                `json:"max_tides"`       // 4 if not set
+	CurveHours     int                `json:"curve_hours"`     // 24 if not set
+	RefreshMinutes int                `json:"refresh_minutes"` // how often the table is read again, 1440 if not set
+}
+
+// HAEntity is a Home Assistant entity shown on the entity grid
+type HAEntity struct {
+	Id       string `json:"id"`       // entity id, e.g. binary_sensor.front_door
+	Label    string `json:"label"`    // the friendly name from HA if not set
+	Icon     string `json:"icon"`     // door, window, light, switch, cover, person, power or sensor, picked by the domain if not set
+	Template string `json:"template"` // Go template of the shown state with .State, .Unit, .Attributes and the t function, picked by the domain if not set
+}
+
+type entityGridSettings struct {
+	Entities       []*HAEntity `json:"entities"`
+	Columns        int         `json:"columns"`         // picked to fit the widget if not set
+	RefreshSeconds int         `json:"refresh_seconds"` // 30 if not set
+}
+
+// RadarSettings configures the radar or satellite image widget
+type RadarSettings struct {
+	Source         string `json:"source"`    // image file or URL, or a tile URL template with {z}, {x} and {y}
+	Zoom           int    `json:"zoom"`      // zoom level of the tiles around the daylight coordinates, 7 if not set
+	TileSize       int    `json:"tile_size"` // 256 if not set
+	HomeX          int    `json:"home_x"`    // home on a single image in its pixels, no marker if not set
+	HomeY          int    `json:"home_y"`
+	RefreshMinutes int    `json:"refresh_minutes"` // 10 if not set
+}
+
+// WidgetRect places a widget on the screen, the default position is kept if width or height is zero
+type WidgetRect struct {
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Mode     string `json:"mode"` // widget specific, e.g. the calendar view
+	Disabled bool   `json:"disabled"`
+	Dither   string `json:"dither"` // none (default, floyd_steinberg for the radar and the photo frame), ordered, floyd_steinberg or atkinson
+}
+
+type photoFrameSettings struct {
+	Directory       string `json:"directory"`        // relative paths are resolved against the root dir
+	IntervalMinutes int    `json:"interval_minutes"` // 15 if not set
+	Shuffle         bool   `json:"shuffle"`          // random order instead of the file names order
+	FullScreenFrom  string `json:"full_screen_from"` // HH:MM, the photos take the whole screen from this time,
+	FullScreenTo    string `json:"full_screen_to"`   // until this one, never if not set
+}
+
+type messageBoardSettings struct {
+	File     string `json:"file"`      // where the messages are kept, messages.json in the root dir if not set
+	Token    string `json:"token"`     // bearer token of the messages API and the message forms, only local requests are served if not set
+	HaEntity string `json:"ha_entity"` // input_text or sensor shown as a message while it is not empty, optional
+}
+
+type panelSettings struct {
+	Gamma  float64   `json:"gamma"`  // applied to the grey values before they are reduced to the 16 panel levels, above 1 darkens the mid greys, 1 if not set
+	Levels []float64 `json:"levels"` // measured brightness of the 16 panel levels from 0 to 255, evenly spread if not set
+}
+
+// IcsFeed is an iCalendar file or URL whose events are shown on the calendar as special days
+type IcsFeed struct {
+	Id                  string `json:"id"`
+	Source              string `json:"source"`          // local file path, file:// or http(s):// URL
+	RefreshMinutes      int    `json:"refresh_minutes"` // 0 for hourly
+	IsPublicHoliday     bool   `json:"is_public_holiday"`
+	IsSchoolHoliday     bool   `json:"is_school_holiday"`
+	DisplayTextTemplate string `json:"display_text_template"` // text/template, {{.Summary}} if empty
+}
+
+// SparklineSettings configures the history graph under a sensor value
+type SparklineSettings struct {
+	Window string  `json:"window"` // 24h or 7d
+	Scale  string  `json:"scale"`  // auto (default) to fit the values or fixed to use min and max
+	Min    float64 `json:"min"`    // in the display units, fixed scale only
+	Max    float64 `json:"max"`
+}
+
+// WorldClockZone is a time zone shown by the world clock widget
+type WorldClockZone struct {
+	Label     string  `json:"label"`
+	TimeZone  string  `json:"time_zone"` // IANA name, e.g. Europe/Moscow
+	Latitude  float64 `json:"latitude"`  // day and night follow the sunrise and sunset if the coordinates are set,
+	Longitude float64 `json:"longitude"` // it is night from 19:00 to 7:00 otherwise
+}
+
 type configData struct {
-	HomeAssistant    homeAssistantSettings   `json:"home_assistant"`
-	OpenWeatherMap   openWeatherMapSettings  `json:"open_weather_map"`
-	SpecialDays      []*SpecialDayOrInterval `json:"special_days"`
-	DaylightSettings daylightSettings        `json:"daylight_settings"`
+	HomeAssistant    homeAssistantSettings         `json:"home_assistant"`
+	OpenWeatherMap   openWeatherMapSettings        `json:"open_weather_map"`
+	SpecialDays      []*SpecialDayOrInterval       `json:"special_days"`
+	DaylightSettings daylightSettings              `json:"daylight_settings"`
+	Units            unitsSettings                 `json:"units"`
+	Locale           localeSettings                `json:"locale"`
+	IcsFeeds         []*IcsFeed                    `json:"ics_feeds"`
+	HolidayPacks     []string                      `json:"holiday_packs"`   // codes of the built-in public holiday packs
+	HiddenHolidays   []string                      `json:"hidden_holidays"` // ids of the pack holidays not to show
+	Agenda           agendaSettings                `json:"agenda"`
+	Layout           map[string]*WidgetRect        `json:"layout"` // widget name to its position, default layout if missing
+	NightMode        nightModeSettings             `json:"night_mode"`
+	Clock            clockSettings                 `json:"clock"`
+	WorldClocks      []*WorldClockZone             `json:"world_clocks"`
+	Sparklines       map[string]*SparklineSettings `json:"sparklines"` // temperature, humidity or pressure to its graph, none if missing
+	AirQuality       airQualitySettings            `json:"air_quality"`
+	Alerts           alertsSettings                `json:"alerts"`
+	Radar            RadarSettings                 `json:"radar"`
+	Panel            panelSettings                 `json:"panel"`
+	PhotoFrame       photoFrameSettings            `json:"photo_frame"`
+	EntityGrid       entityGridSettings            `json:"entity_grid"`
+	Energy           EnergySettings                `json:"energy"`
+	Transit          TransitSettings               `json:"transit"`
+	MessageBoard     messageBoardSettings          `json:"message_board"`
+	Tides            TideSettings                  `json:"tides"`
 }
 
 type SpecialDayOrInterval struct {
-	Index           int    `json:"index"`
-	Id              string `json:"id"`
-	DisplayText     string `json:"display_text"`
-	Type            string `json:"type"`
-	StartDateDay    int    `json:"start_date_day"`
-	StartDateMonth  int    `json:"start_date_month"`
-	StartDateYear   int    `json:"start_date_year"`
-	EndDateDay      int    `json:"end_date_day"`
-	EndDateMonth    int    `json:"end_date_month"`
-	EndDateYear     int    `json:"end_date_year"`
-	IsPublicHoliday bool   `json:"is_public_holiday"`
-	IsSchoolHoliday bool   `json:"is_school_holiday"`
+	Index           int         `json:"index"`
+	Id              string      `json:"id"`
+	DisplayText     string      `json:"display_text"`
+	Type            string      `json:"type"` // once_off, annual, interval or recurring
+	StartDateDay    int         `json:"start_date_day"`
+	StartDateMonth  int         `json:"start_date_month"`
+	StartDateYear   int         `json:"start_date_year"`
+	EndDateDay      int         `json:"end_date_day"`
+	EndDateMonth    int         `json:"end_date_month"`
+	EndDateYear     int         `json:"end_date_year"`
+	IsPublicHoliday bool        `json:"is_public_holiday"`
+	IsSchoolHoliday bool        `json:"is_school_holiday"`
+	Recurrence      *Recurrence `json:"recurrence,omitempty"` // only used by the recurring type
+}
+
+// Recurrence describes a repeating special day, the start date of the special day is the first occurrence.
+type Recurrence struct {
+	Frequency        string   `json:"frequency"`          // daily, weekly, monthly, yearly or easter
+	Interval         int      `json:"interval"`           // every N days/weeks/months/years, 0 is the same as 1
+	Weekdays         []int    `json:"weekdays"`           // 0 (Sunday) to 6 (Saturday), the start date weekday if empty
+	WeekOfMonth      int      `json:"week_of_month"`      // 1 to 5 or -1 for the last one, 0 to use the start date day
+	EasterOffsetDays int      `json:"easter_offset_days"` // days relative to the Easter Sunday, easter frequency only
+	DurationDays     int      `json:"duration_days"`      // length of every occurrence, 0 is the same as 1
+	Count            int      `json:"count"`              // total number of occurrences, 0 for no limit
+	UntilDay         int      `json:"until_day"`
+	UntilMonth       int      `json:"until_month"`
+	UntilYear        int      `json:"until_year"`             // 0 for no end date
+	ExceptDates      []string `json:"except_dates,omitempty"` // YYYY-MM-DD start dates left out, still counted by the count
 }
 
 func readConfig() (*configData, error) {
@@ -100,13 +322,22 @@ type ConfigApi interface {
 	SetExternalTemperatureSensorName(sensorName string)
 	SetExternalHumiditySensorName(sensorName string)
 	SetPressureSensorName(sensorName string)
+	SetWindDirectionSensorName(sensorName string)
+	SetWindSpeedSensorName(sensorName string)
 	GetInternalTemperatureSensorName() string
 	GetInternalHumiditySensorName() string
 	GetExternalTemperatureSensorName() string
 	GetExternalHumiditySensorName() string
 	GetPressureSensorName() string
+	GetWindDirectionSensorName() string
+	GetWindSpeedSensorName() string
 	SetSpecialDays(specialDays []*SpecialDayOrInterval)
 	GetSpecialDays() []*SpecialDayOrInterval
+	GetIcsFeeds() []*IcsFeed
+	SetHolidayPacks(codes []string)
+	GetHolidayPacks() []string
+	SetHiddenHolidays(ids []string)
+	GetHiddenHolidays() []string
 	GetDaylightCoordinates() (float64, float64)
 	GetSimpleRefresh() bool
 	ResetSimpleRefresh()
@@ -118,6 +349,49 @@ type ConfigApi interface {
 	GetOpenWeatherMapAPIKey() string
 	GetOpenWeatherMapPostCode() string
 	GetOpenWeatherMapCountryCode() string
+	GetTemperatureUnit() string
+	GetPressureUnit() string
+	GetWindSpeedUnit() string
+	GetPrecipitationUnit() string
+	GetTimeFormat() string
+	GetLanguage() string
+	GetAgendaMaxItems() int
+	GetAgendaDaysAhead() int
+	GetWidgetRect(widget string) *WidgetRect
+	GetClockFont() string
+	GetWorldClocks() []*WorldClockZone
+	GetSparkline(value string) *SparklineSettings
+	GetClockShowDate() bool
+	GetAirQualitySource() string
+	GetAirQualitySensors() (string, string, string)
+	GetAirQualityRefreshMinutes() int
+	GetAirQualityWarnings() (int, int)
+	GetAlertFeeds() []*AlertFeed
+	GetAlertSlot() string
+	GetAlertMinSeverity() string
+	GetRadar() *RadarSettings
+	GetPanelGamma() float64
+	GetPanelLevels() []float64
+	GetPhotoDirectory() string
+	GetPhotoIntervalMinutes() int
+	GetPhotoShuffle() bool
+	GetPhotoFullScreenHours() (string, string)
+	GetHAEntities() []*HAEntity
+	GetEntityGridColumns() int
+	GetEntityGridRefreshSeconds() int
+	GetEnergy() *EnergySettings
+	GetTransit() *TransitSettings
+	GetMessagesFile() string
+	GetMessagesToken() string
+	GetMessagesHaEntity() string
+	GetTides() *TideSettings
+	GetNightSchedule() string
+	GetNightQuietHours() (string, string)
+	GetNightWidgets() []string
+	GetNightWidgetRect(widget string) *WidgetRect
+	GetNightInvert() bool
+	GetNightRefreshSeconds() int
+	GetFirstDayOfWeek() string
 	GetCalendarRedraw() bool
 	ResetCalendarRedraw()
 	SetCalendarRedraw()
@@ -206,6 +480,298 @@ func (c *configApi) GetOpenWeatherMapCountryCode() string {
 	return c.config.OpenWeatherMap.CountryCode
 }
 
+func (c *configApi) GetTemperatureUnit() string {
+	if c.config.Units.Temperature == "" {
+		return "celsius"
+	}
+	return c.config.Units.Temperature
+}
+
+func (c *configApi) GetPressureUnit() string {
+	if c.config.Units.Pressure == "" {
+		return "mmhg"
+	}
+	return c.config.Units.Pressure
+}
+
+func (c *configApi) GetWindSpeedUnit() string {
+	if c.config.Units.WindSpeed == "" {
+		return "kmh"
+	}
+	return c.config.Units.WindSpeed
+}
+
+func (c *configApi) GetPrecipitationUnit() string {
+	if c.config.Units.Precipitation == "" {
+		return "mm"
+	}
+	return c.config.Units.Precipitation
+}
+
+func (c *configApi) GetTimeFormat() string {
+	if c.config.Units.TimeFormat == "" {
+		return "24h"
+	}
+	return c.config.Units.TimeFormat
+}
+
+func (c *configApi) GetAgendaMaxItems() int {
+	if c.config.Agenda.MaxItems <= 0 {
+		return 10
+	}
+	return c.config.Agenda.MaxItems
+}
+
+func (c *configApi) GetAgendaDaysAhead() int {
+	if c.config.Agenda.DaysAhead <= 0 {
+		return 60
+	}
+	return c.config.Agenda.DaysAhead
+}
+
+// GetWidgetRect returns the configured position of the widget or nil if the default one should be used
+func (c *configApi) GetWidgetRect(widget string) *WidgetRect {
+	return c.config.Layout[widget]
+}
+
+// GetClockFont returns the path of the clock font, relative paths are resolved against the root dir
+func (c *configApi) GetClockFont() string {
+	if c.config.Clock.Font == "" {
+		return "fonts/VerilySerifMono.otf"
+	}
+	return c.config.Clock.Font
+}
+
+func (c *configApi) GetClockShowDate() bool {
+	return c.config.Clock.ShowDate
+}
+
+func (c *configApi) GetWorldClocks() []*WorldClockZone {
+	return c.config.WorldClocks
+}
+
+// GetSparkline returns the history graph settings of temperature, humidity or pressure, nil if there is no graph
+func (c *configApi) GetSparkline(value string) *SparklineSettings {
+	return c.config.Sparklines[value]
+}
+
+func (c *configApi) GetAirQualitySource() string {
+	if c.config.AirQuality.Source == "" {
+		return "ha"
+	}
+	return c.config.AirQuality.Source
+}
+
+// GetAirQualitySensors returns the HA sensor names of CO2, PM2.5 and PM10, empty if not configured
+func (c *configApi) GetAirQualitySensors() (string, string, string) {
+	return c.config.AirQuality.Co2Sensor, c.config.AirQuality.Pm25Sensor, c.config.AirQuality.Pm10Sensor
+}
+
+func (c *configApi) GetAirQualityRefreshMinutes() int {
+	if c.config.AirQuality.RefreshMinutes <= 0 {
+		return 30
+	}
+	return c.config.AirQuality.RefreshMinutes
+}
+
+// GetAirQualityWarnings returns the US AQI and the CO2 ppm above which the warning sign is shown
+func (c *configApi) GetAirQualityWarnings() (int, int) {
+	aqi, co2 := c.config.AirQuality.WarningAqi, c.config.AirQuality.WarningCo2
+	if aqi <= 0 {
+		aqi = 100
+	}
+	if co2 <= 0 {
+		co2 = 1200
+	}
+	return aqi, co2
+}
+
+func (c *configApi) GetAlertFeeds() []*AlertFeed {
+	return c.config.Alerts.Feeds
+}
+
+func (c *configApi) GetAlertSlot() string {
+	if c.config.Alerts.Slot == "" {
+		return "forecast"
+	}
+	return c.config.Alerts.Slot
+}
+
+func (c *configApi) GetAlertMinSeverity() string {
+	if c.config.Alerts.MinSeverity == "" {
+		return "moderate"
+	}
+	return c.config.Alerts.MinSeverity
+}
+
+// GetRadar returns the radar settings with the defaults filled in
+func (c *configApi) GetRadar() *RadarSettings {
+	res := c.config.Radar
+	if res.Zoom <= 0 {
+		res.Zoom = 7
+	}
+	if res.TileSize <= 0 {
+		res.TileSize = 256
+	}
+	if res.RefreshMinutes <= 0 {
+		res.RefreshMinutes = 10
+	}
+	return &res
+}
+
+func (c *configApi) GetPanelGamma() float64 {
+	if c.config.Panel.Gamma == 0 {
+		return 1
+	}
+	return c.config.Panel.Gamma
+}
+
+func (c *configApi) GetPanelLevels() []float64 {
+	return c.config.Panel.Levels
+}
+
+// GetPhotoDirectory returns the absolute path of the photos, empty if not configured
+func (c *configApi) GetPhotoDirectory() string {
+	dir := c.config.PhotoFrame.Directory
+	if dir == "" || path.IsAbs(dir) {
+		return dir
+	}
+	return path.Join(GetRootDir(), dir)
+}
+
+func (c *configApi) GetPhotoIntervalMinutes() int {
+	if c.config.PhotoFrame.IntervalMinutes <= 0 {
+		return 15
+	}
+	return c.config.PhotoFrame.IntervalMinutes
+}
+
+func (c *configApi) GetPhotoShuffle() bool {
+	return c.config.PhotoFrame.Shuffle
+}
+
+// GetPhotoFullScreenHours returns the HH:MM start and end of the full screen photos, empty if they are never shown
+func (c *configApi) GetPhotoFullScreenHours() (string, string) {
+	return c.config.PhotoFrame.FullScreenFrom, c.config.PhotoFrame.FullScreenTo
+}
+
+func (c *configApi) GetHAEntities() []*HAEntity {
+	return c.config.EntityGrid.Entities
+}
+
+func (c *configApi) GetEntityGridColumns() int {
+	return c.config.EntityGrid.Columns
+}
+
+func (c *configApi) GetEntityGridRefreshSeconds() int {
+	if c.config.EntityGrid.RefreshSeconds <= 0 {
+		return 30
+	}
+	return c.config.EntityGrid.RefreshSeconds
+}
+
+func (c *configApi) GetEnergy() *EnergySettings {
+	res := c.config.Energy
+	if res.SensorType == "" {
+		res.SensorType = "power"
+	}
+	if res.RefreshMinutes <= 0 {
+		res.RefreshMinutes = 15
+	}
+	return &res
+}
+
+func (c *configApi) GetTransit() *TransitSettings {
+	res := c.config.Transit
+	if res.MaxDepartures <= 0 {
+		res.MaxDepartures = 5
+	}
+	return &res
+}
+
+// GetMessagesFile returns the absolute path of the message store
+func (c *configApi) GetMessagesFile() string {
+	file := c.config.MessageBoard.File
+	if file == "" {
+		file = "messages.json"
+	}
+	if path.IsAbs(file) {
+		return file
+	}
+	return path.Join(GetRootDir(), file)
+}
+
+func (c *configApi) GetMessagesToken() string {
+	return c.config.MessageBoard.Token
+}
+
+func (c *configApi) GetMessagesHaEntity() string {
+	return c.config.MessageBoard.HaEntity
+}
+
+func (c *configApi) GetTides() *TideSettings {
+	res := c.config.Tides
+	if res.Unit == "" {
+		res.Unit = "m"
+	}
+	if res.MaxTides <= 0 {
+		res.MaxTides = 4
+	}
+	if res.CurveHours <= 0 {
+		res.CurveHours = 24
+	}
+	if res.RefreshMinutes <= 0 {
+		res.RefreshMinutes = 1440
+	}
+	return &res
+}
+
+func (c *configApi) GetNightSchedule() string {
+	if c.config.NightMode.Schedule == "" {
+		return "off"
+	}
+	return c.config.NightMode.Schedule
+}
+
+// GetNightQuietHours returns the start and the end of the fixed night schedule as HH:MM
+func (c *configApi) GetNightQuietHours() (string, string) {
+	return c.config.NightMode.From, c.config.NightMode.To
+}
+
+func (c *configApi) GetNightWidgets() []string {
+	return c.config.NightMode.Widgets
+}
+
+// GetNightWidgetRect returns the night position of the widget or nil if the day one is kept at night
+func (c *configApi) GetNightWidgetRect(widget string) *WidgetRect {
+	return c.config.NightMode.Layout[widget]
+}
+
+func (c *configApi) GetNightInvert() bool {
+	return c.config.NightMode.Invert
+}
+
+func (c *configApi) GetNightRefreshSeconds() int {
+	if c.config.NightMode.RefreshSeconds <= 0 {
+		return 60
+	}
+	return c.config.NightMode.RefreshSeconds
+}
+
+func (c *configApi) GetLanguage() string {
+	if c.config.Locale.Language == "" {
+		return "en"
+	}
+	return c.config.Locale.Language
+}
+
+func (c *configApi) GetFirstDayOfWeek() string {
+	if c.config.Locale.FirstDayOfWeek == "" {
+		return "monday"
+	}
+	return c.config.Locale.FirstDayOfWeek
+}
+
 func (c *configApi) SetSpecialDays(specialDays []*SpecialDayOrInterval) {
 	c.config.SpecialDays = specialDays
 	c.calendarRedraw = true
@@ -219,6 +785,39 @@ func (c *configApi) GetSpecialDays() []*SpecialDayOrInterval {
 	return c.config.SpecialDays
 }
 
+func (c *configApi) GetIcsFeeds() []*IcsFeed {
+	if c.config.IcsFeeds == nil {
+		return []*IcsFeed{}
+	}
+	return c.config.IcsFeeds
+}
+
+func (c *configApi) SetHolidayPacks(codes []string) {
+	c.config.HolidayPacks = codes
+	c.calendarRedraw = true
+	c.saveConfig()
+}
+
+func (c *configApi) GetHolidayPacks() []string {
+	if c.config.HolidayPacks == nil {
+		return []string{}
+	}
+	return c.config.HolidayPacks
+}
+
+func (c *configApi) SetHiddenHolidays(ids []string) {
+	c.config.HiddenHolidays = ids
+	c.calendarRedraw = true
+	c.saveConfig()
+}
+
+func (c *configApi) GetHiddenHolidays() []string {
+	if c.config.HiddenHolidays == nil {
+		return []string{}
+	}
+	return c.config.HiddenHolidays
+}
+
 func (c *configApi) GetInternalTemperatureSensorName() string {
 	return c.config.HomeAssistant.InternalTemperatureSensor
 }
@@ -239,6 +838,14 @@ func (c *configApi) GetPressureSensorName() string {
 	return c.config.HomeAssistant.PressureSensor
 }
 
+func (c *configApi) GetWindDirectionSensorName() string {
+	return c.config.HomeAssistant.WindDirectionSensor
+}
+
+func (c *configApi) GetWindSpeedSensorName() string {
+	return c.config.HomeAssistant.WindSpeedSensor
+}
+
 func (c *configApi) RedrawAll() {
 	c.redrawAll = true
 }
@@ -268,6 +875,16 @@ func (c *configApi) SetPressureSensorName(sensorName string) {
 	c.saveConfig()
 }
 
+func (c *configApi) SetWindDirectionSensorName(sensorName string) {
+	c.config.HomeAssistant.WindDirectionSensor = sensorName
+	c.saveConfig()
+}
+
+func (c *configApi) SetWindSpeedSensorName(sensorName string) {
+	c.config.HomeAssistant.WindSpeedSensor = sensorName
+	c.saveConfig()
+}
+
 func NewConfigApi() (ConfigApi, error) {
 	config, err := readConfig()
 	if err != nil {
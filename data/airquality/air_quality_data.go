@@ -0,0 +1,249 @@
+package airquality
+
+import (
+	"encoding/json"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/fetch"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"fmt"
+	"github.com/rotisserie/eris"
+	"math"
+	"strconv"
+	"time"
+)
+
+// trends compare the latest values with the oldest ones of the last hour
+const (
+	TrendFalling = -1
+	TrendSteady  = 0
+	TrendRising  = 1
+)
+
+const trendPeriod = time.Hour
+
+// changes over the trend period below these are steady
+const (
+	co2TrendPpm  = 50.0
+	pm25TrendUgm = 2.0
+	aqiTrend     = 5.0
+)
+
+type AirQualityData struct {
+	HasCo2    bool    // false if there is no CO2 sensor
+	Co2       float64 // ppm
+	Co2Band   int     // Co2Fresh to Co2VeryStuffy
+	Co2Trend  int     // TrendFalling, TrendSteady or TrendRising
+	Pm25      float64 // µg/m³
+	Pm25Trend int
+	HasPm10   bool
+	Pm10      float64 // µg/m³
+	Aqi       int     // US EPA AQI from the current PM values
+	AqiBand   int     // AqiGood to AqiHazardous
+	AqiTrend  int
+}
+
+type AirQualityDataProvider interface {
+	GetAirQuality() (*AirQualityData, error)
+}
+
+type particles struct {
+	pm25, pm10 float64 // pm10 is NaN if unknown
+}
+
+type sample struct {
+	at             time.Time
+	co2, pm25, aqi float64
+}
+
+type airQualityDataProvider struct {
+	config    config.ConfigApi
+	haApi     ha.HomeAssistantApi
+	source    string
+	cached    *particles // the last answer of a web source
+	fetchedAt time.Time
+	samples   []*sample // the last hour of values for the trends
+}
+
+func NewAirQualityDataProvider(cfg config.ConfigApi, haApi ha.HomeAssistantApi) (AirQualityDataProvider, error) {
+	source := cfg.GetAirQualitySource()
+	switch source {
+	case "ha", "open_meteo", "openweathermap":
+	default:
+		return nil, eris.Errorf("unknown air quality source '%s', expected 'ha', 'open_meteo' or 'openweathermap'", source)
+	}
+	return &airQualityDataProvider{
+		config: cfg,
+		haApi:  haApi,
+		source: source,
+	}, nil
+}
+
+func (a *airQualityDataProvider) GetAirQuality() (*AirQualityData, error) {
+	now := time.Now()
+	co2SensorName, _, _ := a.config.GetAirQualitySensors()
+	res := &AirQualityData{}
+	if co2SensorName != "" {
+		co2, err := a.readSensor(co2SensorName)
+		if err != nil {
+			return nil, err
+		}
+		res.HasCo2 = true
+		res.Co2 = co2
+		res.Co2Band = co2Band(co2)
+	}
+	p, err := a.getParticles(now)
+	if err != nil {
+		return nil, err
+	}
+	res.Pm25 = p.pm25
+	res.HasPm10 = !math.IsNaN(p.pm10)
+	if res.HasPm10 {
+		res.Pm10 = p.pm10
+	}
+	res.Aqi = usAqi(p.pm25, p.pm10)
+	res.AqiBand = aqiBand(res.Aqi)
+
+	current := &sample{at: now, co2: res.Co2, pm25: res.Pm25, aqi: float64(res.Aqi)}
+	samples := make([]*sample, 0, len(a.samples)+1)
+	for _, s := range a.samples {
+		if now.Sub(s.at) <= trendPeriod {
+			samples = append(samples, s)
+		}
+	}
+	a.samples = append(samples, current)
+	oldest := a.samples[0]
+	res.Co2Trend = trend(current.co2-oldest.co2, co2TrendPpm)
+	res.Pm25Trend = trend(current.pm25-oldest.pm25, pm25TrendUgm)
+	res.AqiTrend = trend(current.aqi-oldest.aqi, aqiTrend)
+	return res, nil
+}
+
+func trend(change, threshold float64) int {
+	switch {
+	case change >= threshold:
+		return TrendRising
+	case change <= -threshold:
+		return TrendFalling
+	}
+	return TrendSteady
+}
+
+// getParticles reads the HA sensors every time, the web sources are only queried once per refresh interval
+func (a *airQualityDataProvider) getParticles(now time.Time) (*particles, error) {
+	if a.source == "ha" {
+		return a.readHaParticles()
+	}
+	refresh := time.Duration(a.config.GetAirQualityRefreshMinutes()) * time.Minute
+	if a.cached != nil && now.Sub(a.fetchedAt) < refresh {
+		return a.cached, nil
+	}
+	var p *particles
+	var err error
+	if a.source == "open_meteo" {
+		p, err = a.readOpenMeteo()
+	} else {
+		p, err = a.readOpenWeatherMap()
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.cached = p
+	a.fetchedAt = now
+	return p, nil
+}
+
+func (a *airQualityDataProvider) readSensor(sensorName string) (float64, error) {
+	value, err := a.haApi.DownloadSensorValueFromHA(sensorName)
+	if err != nil {
+		return 0, err
+	}
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, eris.Wrapf(err, "sensor %s is not available", sensorName)
+	}
+	return res, nil
+}
+
+func (a *airQualityDataProvider) readHaParticles() (*particles, error) {
+	_, pm25SensorName, pm10SensorName := a.config.GetAirQualitySensors()
+	if pm25SensorName == "" {
+		return nil, eris.New("air quality source 'ha' needs pm25_sensor")
+	}
+	pm25, err := a.readSensor(pm25SensorName)
+	if err != nil {
+		return nil, err
+	}
+	res := &particles{pm25: pm25, pm10: math.NaN()}
+	if pm10SensorName != "" {
+		res.pm10, err = a.readSensor(pm10SensorName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// See https://open-meteo.com/en/docs/air-quality-api, no key is needed
+type openMeteoResponse struct {
+	Current struct {
+		Pm25 *float64 `json:"pm2_5"`
+		Pm10 *float64 `json:"pm10"`
+	} `json:"current"`
+}
+
+func (a *airQualityDataProvider) readOpenMeteo() (*particles, error) {
+	latitude, longitude := a.config.GetDaylightCoordinates()
+	url := fmt.Sprintf("https://air-quality-api.open-meteo.com/v1/air-quality?latitude=%v&longitude=%v&current=pm10,pm2_5",
+		latitude, longitude)
+	content, err := fetch.ReadSource(url)
+	if err != nil {
+		return nil, err
+	}
+	response := openMeteoResponse{}
+	err = json.Unmarshal(content, &response)
+	if err != nil {
+		return nil, eris.Wrap(err, "couldn't parse the Open-Meteo air quality")
+	}
+	if response.Current.Pm25 == nil {
+		return nil, eris.New("no PM2.5 in the Open-Meteo air quality")
+	}
+	res := &particles{pm25: *response.Current.Pm25, pm10: math.NaN()}
+	if response.Current.Pm10 != nil {
+		res.pm10 = *response.Current.Pm10
+	}
+	return res, nil
+}
+
+// See https://openweathermap.org/api/air-pollution
+type openWeatherMapResponse struct {
+	List []struct {
+		Components struct {
+			Pm25 float64 `json:"pm2_5"`
+			Pm10 float64 `json:"pm10"`
+		} `json:"components"`
+	} `json:"list"`
+}
+
+func (a *airQualityDataProvider) readOpenWeatherMap() (*particles, error) {
+	apiKey := a.config.GetOpenWeatherMapAPIKey()
+	if apiKey == "" {
+		return nil, eris.New("air quality source 'openweathermap' needs the OpenWeatherMap API key")
+	}
+	latitude, longitude := a.config.GetDaylightCoordinates()
+	url := fmt.Sprintf("https://api.openweathermap.org/data/2.5/air_pollution?lat=%v&lon=%v&appid=%s",
+		latitude, longitude, apiKey)
+	content, err := fetch.ReadSource(url)
+	if err != nil {
+		return nil, err
+	}
+	response := openWeatherMapResponse{}
+	err = json.Unmarshal(content, &response)
+	if err != nil {
+		return nil, eris.Wrap(err, "couldn't parse the OpenWeatherMap air pollution")
+	}
+	if len(response.List) == 0 {
+		return nil, eris.New("no data in the OpenWeatherMap air pollution")
+	}
+	components := response.List[0].Components
+	return &particles{pm25: components.Pm25, pm10: components.Pm10}, nil
+}
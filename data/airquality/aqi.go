@@ -0,0 +1,93 @@
+package airquality
+
+import "math"
+
+// US EPA AQI bands
+const (
+	AqiGood = iota
+	AqiModerate
+	AqiUnhealthyForSensitive
+	AqiUnhealthy
+	AqiVeryUnhealthy
+	AqiHazardous
+)
+
+// CO2 bands of the indoor air
+const (
+	Co2Fresh = iota // below 800 ppm
+	Co2Moderate
+	Co2Stuffy
+	Co2VeryStuffy // 2000 ppm and above
+)
+
+var co2BandTops = []float64{800, 1200, 2000}
+
+type aqiBreakpoint struct {
+	low, high       float64 // concentration in µg/m³
+	aqiLow, aqiHigh float64
+}
+
+// breakpoints of the 2024 revision of the EPA AQI
+var pm25Breakpoints = []aqiBreakpoint{
+	{0, 9.0, 0, 50},
+	{9.1, 35.4, 51, 100},
+	{35.5, 55.4, 101, 150},
+	{55.5, 125.4, 151, 200},
+	{125.5, 225.4, 201, 300},
+	{225.5, 325.4, 301, 500},
+}
+
+var pm10Breakpoints = []aqiBreakpoint{
+	{0, 54, 0, 50},
+	{55, 154, 51, 100},
+	{155, 254, 101, 150},
+	{255, 354, 151, 200},
+	{355, 424, 201, 300},
+	{425, 604, 301, 500},
+}
+
+// subIndex interpolates the AQI of the concentration truncated to the precision of the breakpoints,
+// concentrations above the scale are 500
+func subIndex(concentration float64, breakpoints []aqiBreakpoint) int {
+	for _, b := range breakpoints {
+		if concentration <= b.high {
+			return int(math.Round(b.aqiLow + (b.aqiHigh-b.aqiLow)/(b.high-b.low)*(concentration-b.low)))
+		}
+	}
+	return 500
+}
+
+// usAqi returns the US AQI, the higher of the PM2.5 and PM10 sub-indices, PM10 is NaN if unknown
+func usAqi(pm25, pm10 float64) int {
+	// the epsilon keeps e.g. 35.5 from becoming 35.4 through the binary representation
+	res := subIndex(math.Trunc(math.Max(pm25, 0)*10+1e-9)/10, pm25Breakpoints)
+	if !math.IsNaN(pm10) {
+		res = max(res, subIndex(math.Trunc(math.Max(pm10, 0)), pm10Breakpoints))
+	}
+	return res
+}
+
+func aqiBand(aqi int) int {
+	switch {
+	case aqi <= 50:
+		return AqiGood
+	case aqi <= 100:
+		return AqiModerate
+	case aqi <= 150:
+		return AqiUnhealthyForSensitive
+	case aqi <= 200:
+		return AqiUnhealthy
+	case aqi <= 300:
+		return AqiVeryUnhealthy
+	}
+	return AqiHazardous
+}
+
+func co2Band(ppm float64) int {
+	for i, top := range co2BandTops {
+		if ppm < top {
+			return i
+		}
+	}
+	return Co2VeryStuffy
+}
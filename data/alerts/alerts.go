@@ -0,0 +1,149 @@
+package alerts
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/fetch"
+	"github.com/rotisserie/eris"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRefreshMinutes = 15
+
+// CAP severities, unknown is used for sources without one and is never filtered out
+const (
+	SeverityUnknown = iota
+	SeverityMinor
+	SeverityModerate
+	SeveritySevere
+	SeverityExtreme
+)
+
+var severityNames = []string{"unknown", "minor", "moderate", "severe", "extreme"}
+
+func parseSeverity(value string) int {
+	index := slices.Index(severityNames, strings.ToLower(strings.TrimSpace(value)))
+	return max(index, SeverityUnknown)
+}
+
+type Alert struct {
+	Id       string
+	Event    string // short name of the hazard, e.g. Wind
+	Headline string
+	Area     string
+	Severity int       // SeverityUnknown to SeverityExtreme
+	Onset    time.Time // zero if unknown
+	Expires  time.Time // zero if the alert has no expiry, it lasts while it is in the feed then
+}
+
+// AlertsProvider keeps the alerts of the configured feeds, re-reading every feed once its refresh interval has passed.
+// Feeds which fail to load keep their last known alerts.
+type AlertsProvider interface {
+	// GetActiveAlerts returns the alerts which haven't expired, the most severe and then the earliest first
+	GetActiveAlerts() []*Alert
+}
+
+// TimeProvider is the part of utils.TimeProvider used here, the renderable utils are not imported so that
+// the data packages don't depend on the display library
+type TimeProvider interface {
+	UtcNow() time.Time
+}
+
+type feedState struct {
+	source      string
+	alerts      []*Alert
+	nextRefresh time.Time
+}
+
+type alertsProvider struct {
+	cfg          config.ConfigApi
+	timeProvider TimeProvider
+	minSeverity  int
+	lock         sync.Mutex
+	feeds        map[string]*feedState
+}
+
+func NewAlertsProvider(cfg config.ConfigApi, timeProvider TimeProvider) (AlertsProvider, error) {
+	minSeverity := slices.Index(severityNames, cfg.GetAlertMinSeverity())
+	if minSeverity <= SeverityUnknown {
+		return nil, eris.Errorf("unknown alert severity '%s', expected minor, moderate, severe or extreme", cfg.GetAlertMinSeverity())
+	}
+	for _, feed := range cfg.GetAlertFeeds() {
+		switch feed.Format {
+		case "", "atom", "cap", "owm":
+		default:
+			return nil, eris.Errorf("unknown format '%s' of the alert feed '%s', expected atom, cap or owm", feed.Format, feed.Id)
+		}
+	}
+	return &alertsProvider{
+		cfg:          cfg,
+		timeProvider: timeProvider,
+		minSeverity:  minSeverity,
+		feeds:        make(map[string]*feedState),
+	}, nil
+}
+
+func (p *alertsProvider) GetActiveAlerts() []*Alert {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := p.timeProvider.UtcNow()
+	res := make([]*Alert, 0)
+	seen := make(map[string]bool)
+	for _, feed := range p.cfg.GetAlertFeeds() {
+		state, exists := p.feeds[feed.Id]
+		if !exists || state.source != feed.Source {
+			state = &feedState{source: feed.Source}
+			p.feeds[feed.Id] = state
+		}
+		if !now.Before(state.nextRefresh) {
+			refreshMinutes := feed.RefreshMinutes
+			if refreshMinutes <= 0 {
+				refreshMinutes = defaultRefreshMinutes
+			}
+			state.nextRefresh = now.Add(time.Duration(refreshMinutes) * time.Minute)
+			alerts, err := p.loadFeed(feed)
+			if err != nil {
+				println(eris.ToString(eris.Wrapf(err, "Error loading alert feed '%s'", feed.Id), true))
+			} else {
+				state.alerts = alerts
+			}
+		}
+		for _, alert := range state.alerts {
+			if !alert.Expires.IsZero() && !now.Before(alert.Expires) {
+				continue
+			}
+			if alert.Severity != SeverityUnknown && alert.Severity < p.minSeverity {
+				continue
+			}
+			// the same alert may come from several feeds
+			if alert.Id != "" && seen[alert.Id] {
+				continue
+			}
+			seen[alert.Id] = true
+			res = append(res, alert)
+		}
+	}
+	slices.SortStableFunc(res, func(a, b *Alert) int {
+		if a.Severity != b.Severity {
+			return b.Severity - a.Severity
+		}
+		return a.Onset.Compare(b.Onset)
+	})
+	return res
+}
+
+func (p *alertsProvider) loadFeed(feed *config.AlertFeed) ([]*Alert, error) {
+	content, err := fetch.ReadSource(feed.Source)
+	if err != nil {
+		return nil, err
+	}
+	switch feed.Format {
+	case "cap":
+		return parseCap(content, p.cfg.GetLanguage())
+	case "owm":
+		return parseOwm(content)
+	}
+	return parseAtom(content)
+}
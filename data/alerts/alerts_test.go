@@ -0,0 +1,157 @@
+package alerts
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type alertsConfig struct {
+	config.ConfigApi
+	feeds       []*config.AlertFeed
+	minSeverity string
+}
+
+func (c *alertsConfig) GetAlertFeeds() []*config.AlertFeed {
+	return c.feeds
+}
+
+func (c *alertsConfig) GetAlertMinSeverity() string {
+	return c.minSeverity
+}
+
+func (c *alertsConfig) GetLanguage() string {
+	return "en"
+}
+
+type fixedTime struct {
+	now time.Time
+}
+
+func (f *fixedTime) UtcNow() time.Time {
+	return f.now
+}
+
+func fixtureSource(t *testing.T, name string) string {
+	t.Helper()
+	fileName, err := filepath.Abs("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + fileName
+}
+
+func TestActiveAlerts(t *testing.T) {
+	feeds := []*config.AlertFeed{
+		{Id: "nws", Source: fixtureSource(t, "atom.xml")},
+		// the CAP document repeats the flood warning of the Atom feed
+		{Id: "flood", Source: fixtureSource(t, "cap.xml"), Format: "cap"},
+		{Id: "owm", Source: fixtureSource(t, "owm.json"), Format: "owm"},
+	}
+	const (
+		flood     = "urn:oid:2.49.0.1.840.0.flood"
+		wind      = "urn:oid:2.49.0.1.840.0.wind"
+		frost     = "urn:oid:2.49.0.1.840.0.frost"
+		statement = "urn:oid:2.49.0.1.840.0.statement"
+		owmWind   = "Met Office/Yellow wind warning/1705309200"
+		owmIce    = "Met Office/Yellow ice warning/1705356000"
+	)
+	tests := []struct {
+		name        string
+		now         time.Time
+		minSeverity string
+		expected    []string
+	}{
+		{
+			name:        "most severe first, the alerts without a severity by onset at the end",
+			now:         time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			minSeverity: "moderate",
+			expected:    []string{flood, wind, statement, owmWind, owmIce},
+		},
+		{
+			name:        "minor alerts on request",
+			now:         time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			minSeverity: "minor",
+			expected:    []string{flood, wind, frost, statement, owmWind, owmIce},
+		},
+		{
+			name:        "alerts without a severity are kept above the threshold",
+			now:         time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			minSeverity: "extreme",
+			expected:    []string{statement, owmWind, owmIce},
+		},
+		{
+			name:        "expiry is exclusive",
+			now:         time.Date(2024, 1, 15, 20, 0, 0, 0, time.UTC),
+			minSeverity: "moderate",
+			expected:    []string{flood, statement, owmWind, owmIce},
+		},
+		{
+			name:        "alerts without an expiry last while they are in the feed",
+			now:         time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC),
+			minSeverity: "moderate",
+			expected:    []string{statement},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &alertsConfig{feeds: feeds, minSeverity: test.minSeverity}
+			p, err := NewAlertsProvider(cfg, &fixedTime{now: test.now})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0)
+			for _, alert := range p.GetActiveAlerts() {
+				got = append(got, alert.Id)
+			}
+			if len(got) != len(test.expected) {
+				t.Fatalf("got %v, expected %v", got, test.expected)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Fatalf("got %v, expected %v", got, test.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestFailedFeedKeepsAlerts(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "atom.xml")
+	err := os.WriteFile(fileName, readFixture(t, "atom.xml"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := &config.AlertFeed{Id: "nws", Source: fileName, RefreshMinutes: 10}
+	cfg := &alertsConfig{feeds: []*config.AlertFeed{feed}, minSeverity: "moderate"}
+	clock := &fixedTime{now: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)}
+	p, err := NewAlertsProvider(cfg, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(p.GetActiveAlerts()); got != 3 {
+		t.Fatalf("got %d alerts, expected 3", got)
+	}
+	err = os.Remove(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	if got := len(p.GetActiveAlerts()); got != 3 {
+		t.Errorf("got %d alerts after a failed refresh, expected 3", got)
+	}
+	// a new source starts from scratch
+	feed.Source = fileName + ".missing"
+	if got := len(p.GetActiveAlerts()); got != 0 {
+		t.Errorf("got %d alerts of the previous source, expected none", got)
+	}
+}
+
+func TestUnknownMinSeverity(t *testing.T) {
+	_, err := NewAlertsProvider(&alertsConfig{minSeverity: "unknown"}, &fixedTime{})
+	if err == nil {
+		t.Error("expected an error for the unknown severity")
+	}
+}
@@ -0,0 +1,177 @@
+package alerts
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/rotisserie/eris"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CAP 1.2 elements, see https://docs.oasis-open.org/emergency/cap/v1.2/CAP-v1.2.html
+// the local names are matched in any namespace, Atom feeds carry them with the cap: prefix
+
+type capArea struct {
+	AreaDesc string `xml:"areaDesc"`
+}
+
+type capInfo struct {
+	Language    string    `xml:"language"`
+	Event       string    `xml:"event"`
+	Severity    string    `xml:"severity"`
+	Effective   string    `xml:"effective"`
+	Onset       string    `xml:"onset"`
+	Expires     string    `xml:"expires"`
+	Headline    string    `xml:"headline"`
+	Description string    `xml:"description"`
+	Areas       []capArea `xml:"area"`
+}
+
+type capAlert struct {
+	Identifier string    `xml:"identifier"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	Infos      []capInfo `xml:"info"`
+}
+
+type atomEntry struct {
+	Id        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Event     string `xml:"event"`
+	Status    string `xml:"status"`
+	MsgType   string `xml:"msgType"`
+	Severity  string `xml:"severity"`
+	Effective string `xml:"effective"`
+	Onset     string `xml:"onset"`
+	Expires   string `xml:"expires"`
+	AreaDesc  string `xml:"areaDesc"`
+}
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+// See https://openweathermap.org/api/one-call-3#hist_parameter, the alerts carry no severity
+type owmAlert struct {
+	SenderName  string `json:"sender_name"`
+	Event       string `json:"event"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	Description string `json:"description"`
+}
+
+type owmOneCall struct {
+	Alerts []owmAlert `json:"alerts"`
+}
+
+// relevant tells whether the alert is a real one, tests, exercises and cancellations are left out
+func relevant(status, msgType string) bool {
+	return (status == "" || strings.EqualFold(status, "Actual")) && !strings.EqualFold(msgType, "Cancel")
+}
+
+// parseTime parses a CAP date and time, zero if it is missing or malformed
+func parseTime(value string) time.Time {
+	res, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return res
+}
+
+// onset is the start of the event, the time the alert becomes effective if not given
+func onset(onset, effective string) time.Time {
+	if res := parseTime(onset); !res.IsZero() {
+		return res
+	}
+	return parseTime(effective)
+}
+
+// parseCap reads a CAP 1.2 document, the info block in the given language is preferred, the first one is used otherwise
+func parseCap(content []byte, language string) ([]*Alert, error) {
+	alert := capAlert{}
+	err := xml.Unmarshal(content, &alert)
+	if err != nil {
+		return nil, eris.Wrap(err, "couldn't parse the CAP document")
+	}
+	if !relevant(alert.Status, alert.MsgType) || len(alert.Infos) == 0 {
+		return []*Alert{}, nil
+	}
+	info := alert.Infos[0]
+	for _, i := range alert.Infos {
+		if strings.HasPrefix(strings.ToLower(i.Language), language) {
+			info = i
+			break
+		}
+	}
+	areas := make([]string, 0, len(info.Areas))
+	for _, area := range info.Areas {
+		areas = append(areas, area.AreaDesc)
+	}
+	headline := info.Headline
+	if headline == "" {
+		headline = info.Description
+	}
+	return []*Alert{{
+		Id:       alert.Identifier,
+		Event:    info.Event,
+		Headline: strings.TrimSpace(headline),
+		Area:     strings.Join(areas, ", "),
+		Severity: parseSeverity(info.Severity),
+		Onset:    onset(info.Onset, info.Effective),
+		Expires:  parseTime(info.Expires),
+	}}, nil
+}
+
+// parseAtom reads an Atom feed of CAP alerts as published by the NWS or Meteoalarm
+func parseAtom(content []byte) ([]*Alert, error) {
+	feed := atomFeed{}
+	err := xml.Unmarshal(content, &feed)
+	if err != nil {
+		return nil, eris.Wrap(err, "couldn't parse the Atom feed")
+	}
+	res := make([]*Alert, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		if !relevant(entry.Status, entry.MsgType) {
+			continue
+		}
+		event := entry.Event
+		if event == "" {
+			event = entry.Title
+		}
+		res = append(res, &Alert{
+			Id:       entry.Id,
+			Event:    strings.TrimSpace(event),
+			Headline: strings.TrimSpace(entry.Title),
+			Area:     strings.TrimSpace(entry.AreaDesc),
+			Severity: parseSeverity(entry.Severity),
+			Onset:    onset(entry.Onset, entry.Effective),
+			Expires:  parseTime(entry.Expires),
+		})
+	}
+	return res, nil
+}
+
+// parseOwm reads the alerts of the OpenWeatherMap One Call API response
+func parseOwm(content []byte) ([]*Alert, error) {
+	oneCall := owmOneCall{}
+	err := json.Unmarshal(content, &oneCall)
+	if err != nil {
+		return nil, eris.Wrap(err, "couldn't parse the One Call response")
+	}
+	res := make([]*Alert, 0, len(oneCall.Alerts))
+	for _, alert := range oneCall.Alerts {
+		headline, _, _ := strings.Cut(strings.TrimSpace(alert.Description), "\n")
+		res = append(res, &Alert{
+			Id:       alert.SenderName + "/" + alert.Event + "/" + strconv.FormatInt(alert.Start, 10),
+			Event:    alert.Event,
+			Headline: headline,
+			Area:     alert.SenderName,
+			Severity: SeverityUnknown,
+			Onset:    time.Unix(alert.Start, 0),
+			Expires:  time.Unix(alert.End, 0),
+		})
+	}
+	return res, nil
+}
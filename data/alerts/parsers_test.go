@@ -0,0 +1,186 @@
+package alerts
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	content, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"Minor", SeverityMinor},
+		{"moderate", SeverityModerate},
+		{"Severe", SeveritySevere},
+		{" EXTREME ", SeverityExtreme},
+		{"Unknown", SeverityUnknown},
+		{"", SeverityUnknown},
+		{"catastrophic", SeverityUnknown},
+	}
+	for _, test := range tests {
+		if got := parseSeverity(test.value); got != test.expected {
+			t.Errorf("%q: got %d, expected %d", test.value, got, test.expected)
+		}
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	alerts, err := parseAtom(readFixture(t, "atom.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Alert{
+		{
+			Id:       "urn:oid:2.49.0.1.840.0.flood",
+			Event:    "Flood Warning",
+			Headline: "Flood Warning issued January 15 at 8:00AM until January 16 at 8:00AM",
+			Area:     "Riverside County",
+			Severity: SeveritySevere,
+			Onset:    time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC),
+			Expires:  time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			Id:       "urn:oid:2.49.0.1.840.0.wind",
+			Event:    "Wind Advisory",
+			Headline: "Wind Advisory issued January 15 at 9:00AM until January 15 at 8:00PM",
+			Area:     "Coastal Hills",
+			Severity: SeverityModerate,
+			// no onset, the alert starts when it becomes effective
+			Onset:   time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+			Expires: time.Date(2024, 1, 15, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			Id:       "urn:oid:2.49.0.1.840.0.frost",
+			Event:    "Frost Advisory",
+			Headline: "Frost Advisory issued January 15 at 9:00AM until January 16 at 9:00AM",
+			Area:     "Inland Valleys",
+			Severity: SeverityMinor,
+			Onset:    time.Date(2024, 1, 15, 22, 0, 0, 0, time.UTC),
+			Expires:  time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Id:       "urn:oid:2.49.0.1.840.0.fog",
+			Event:    "Dense Fog Advisory",
+			Headline: "Dense Fog Advisory issued January 15 at 3:00AM until January 15 at 9:00AM",
+			Area:     "Coastal Hills",
+			Severity: SeverityModerate,
+			Onset:    time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC),
+			Expires:  time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		},
+		// the test message and the cancellation are left out, the event falls back to the title
+		{
+			Id:       "urn:oid:2.49.0.1.840.0.statement",
+			Event:    "Special Weather Statement",
+			Headline: "Special Weather Statement",
+			Area:     "Riverside County; Coastal Hills",
+			Severity: SeverityUnknown,
+			Onset:    time.Date(2024, 1, 15, 6, 0, 0, 0, time.UTC),
+		},
+	}
+	checkAlerts(t, alerts, expected)
+}
+
+func TestParseCap(t *testing.T) {
+	content := readFixture(t, "cap.xml")
+	english := &Alert{
+		Id:       "urn:oid:2.49.0.1.840.0.flood",
+		Event:    "Flood Warning",
+		Headline: "Minor flooding is occurring along the river.",
+		Area:     "Riverside County, Lake County",
+		Severity: SeveritySevere,
+		Onset:    time.Date(2024, 1, 15, 7, 45, 0, 0, time.UTC),
+		Expires:  time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC),
+	}
+	russian := &Alert{
+		Id:       "urn:oid:2.49.0.1.840.0.flood",
+		Event:    "Предупреждение о наводнении",
+		Headline: "Наводнение до 16 января",
+		Area:     "Округ Риверсайд",
+		Severity: SeveritySevere,
+		Onset:    time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC),
+		Expires:  time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		language string
+		expected *Alert
+	}{
+		{"en", english},
+		{"ru", russian},
+		// the first info block without one in the language
+		{"de", russian},
+	}
+	for _, test := range tests {
+		t.Run(test.language, func(t *testing.T) {
+			alerts, err := parseCap(content, test.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAlerts(t, alerts, []*Alert{test.expected})
+		})
+	}
+}
+
+func TestParseCapCancellation(t *testing.T) {
+	content := `<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2"><identifier>a</identifier><status>Actual</status>` +
+		`<msgType>Cancel</msgType><info><event>Flood Warning</event><severity>Severe</severity></info></alert>`
+	alerts, err := parseCap([]byte(content), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("got %d alerts for a cancellation", len(alerts))
+	}
+}
+
+func TestParseOwm(t *testing.T) {
+	alerts, err := parseOwm(readFixture(t, "owm.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Alert{
+		{
+			Id:       "Met Office/Yellow wind warning/1705309200",
+			Event:    "Yellow wind warning",
+			Headline: "Strong winds are expected.",
+			Area:     "Met Office",
+			Severity: SeverityUnknown,
+			Onset:    time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+			Expires:  time.Date(2024, 1, 15, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			Id:       "Met Office/Yellow ice warning/1705356000",
+			Event:    "Yellow ice warning",
+			Headline: "Icy patches on untreated roads.",
+			Area:     "Met Office",
+			Severity: SeverityUnknown,
+			Onset:    time.Date(2024, 1, 15, 22, 0, 0, 0, time.UTC),
+			Expires:  time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC),
+		},
+	}
+	checkAlerts(t, alerts, expected)
+}
+
+func checkAlerts(t *testing.T, got []*Alert, expected []*Alert) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("got %d alerts, expected %d", len(got), len(expected))
+	}
+	for i := range got {
+		g, e := got[i], expected[i]
+		if g.Id != e.Id || g.Event != e.Event || g.Headline != e.Headline || g.Area != e.Area || g.Severity != e.Severity ||
+			!g.Onset.Equal(e.Onset) || !g.Expires.Equal(e.Expires) {
+			t.Errorf("alert %d: got %+v, expected %+v", i, g, e)
+		}
+	}
+}
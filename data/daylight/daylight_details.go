@@ -0,0 +1,165 @@
+package daylight
+
+import (
+	"math"
+	"time"
+)
+
+type DayType int
+
+const (
+	NormalDay  DayType = iota
+	PolarDay           // the Sun doesn't set
+	PolarNight         // the Sun doesn't rise
+)
+
+// SunriseSunset holds sunrise and sunset of a calendar day, both are zero for polar days and nights
+type SunriseSunset struct {
+	Sunrise time.Time
+	Sunset  time.Time
+	Type    DayType
+}
+
+func (s *SunriseSunset) HasSunrise() bool {
+	return s.Type == NormalDay
+}
+
+func (s *SunriseSunset) HasSunset() bool {
+	return s.Type == NormalDay
+}
+
+// DayLength is the time the Sun is above the horizon, a full day for polar days and zero for polar nights
+func (s *SunriseSunset) DayLength() time.Duration {
+	switch s.Type {
+	case PolarDay:
+		return 24 * time.Hour
+	case PolarNight:
+		return 0
+	}
+	return s.Sunset.Sub(s.Sunrise)
+}
+
+// Twilight is the period between the start of the dawn and sunrise or between sunset and the end of the dusk,
+// it is not valid on days the Sun doesn't get as low below the horizon, e.g. during white nights
+type Twilight struct {
+	Dawn  time.Time
+	Dusk  time.Time
+	Valid bool
+}
+
+// DaylightDetails describes the Sun and the Moon for a calendar day
+type DaylightDetails struct {
+	SunriseSunset
+	SolarNoon            time.Time
+	DayLength            time.Duration
+	DayLengthChange      time.Duration // compared to the previous day
+	CivilTwilight        Twilight
+	NauticalTwilight     Twilight
+	AstronomicalTwilight Twilight
+	MoonPhase            float64 // fraction of the synodic month at noon, 0 new moon, 0.5 full moon
+	MoonIllumination     float64 // illuminated fraction of the disc at noon, 0 to 1
+	Moonrise             time.Time
+	HasMoonrise          bool // the Moon doesn't rise every day
+	Moonset              time.Time
+	HasMoonset           bool
+}
+
+// moonPhaseKeys are message keys of the 8 phases, each phase is centered at a multiple of 1/8 of the synodic month
+var moonPhaseKeys = []string{
+	"moon_new",
+	"moon_waxing_crescent",
+	"moon_first_quarter",
+	"moon_waxing_gibbous",
+	"moon_full",
+	"moon_waning_gibbous",
+	"moon_last_quarter",
+	"moon_waning_crescent",
+}
+
+// MoonPhaseKey returns the message key of the phase name, e.g. "moon_first_quarter"
+func MoonPhaseKey(phase float64) string {
+	return moonPhaseKeys[int(math.Floor(phase*8+0.5))%8]
+}
+
+// utcTime converts minutes past the UTC midnight of the date, as returned by the NOAA calculations, to a time in the location
+func utcTime(date time.Time, minutes float64, location *time.Location) time.Time {
+	utcMidnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return utcMidnight.Add(time.Duration(minutes * float64(time.Minute))).Round(time.Second).In(location)
+}
+
+func julianDate(t time.Time) float64 {
+	return float64(t.Unix())/86400.0 + 2440587.5
+}
+
+// sunriseSunset returns the events of the Sun crossing the horizon, the date has to be set beforehand
+func (s *SunSet) sunriseSunset(date time.Time, location *time.Location) *SunriseSunset {
+	sunrise := s.calcAbsSunrise(SunsetOfficial)
+	sunset := s.calcAbsSunset(SunsetOfficial)
+	if math.IsNaN(sunrise) || math.IsNaN(sunset) {
+		if s.sunIsUpAtNoon(SunsetOfficial) {
+			return &SunriseSunset{Type: PolarDay}
+		}
+		return &SunriseSunset{Type: PolarNight}
+	}
+	return &SunriseSunset{
+		Sunrise: utcTime(date, sunrise, location),
+		Sunset:  utcTime(date, sunset, location),
+		Type:    NormalDay,
+	}
+}
+
+// sunIsUpAtNoon tells whether the Sun is higher than the given zenith angle at noon,
+// used to tell polar days from polar nights when the hour angle can't be calculated
+func (s *SunSet) sunIsUpAtNoon(angle float64) bool {
+	t := calcTimeJulianCent(s.julianDate + 0.5 - s.longitude/360.0)
+	return math.Abs(s.latitude-calcSunDeclination(t)) < angle
+}
+
+func (s *SunSet) calcTwilight(date time.Time, angle float64, location *time.Location) Twilight {
+	dawn := s.calcAbsSunrise(angle)
+	dusk := s.calcAbsSunset(angle)
+	if math.IsNaN(dawn) || math.IsNaN(dusk) {
+		return Twilight{}
+	}
+	return Twilight{Dawn: utcTime(date, dawn, location), Dusk: utcTime(date, dusk, location), Valid: true}
+}
+
+// calcSolarNoonUTC returns solar noon in minutes past the UTC midnight, the time the Sun crosses the meridian
+func (s *SunSet) calcSolarNoonUTC() float64 {
+	t := calcTimeJulianCent(s.julianDate - s.longitude/360.0)
+	noonUTC := 720 - 4*s.longitude - calcEquationOfTime(t)
+	// second pass with the equation of time at the approximate noon
+	t = calcTimeJulianCent(s.julianDate + noonUTC/1440.0)
+	return 720 - 4*s.longitude - calcEquationOfTime(t)
+}
+
+func calcDaylightDetails(latitude, longitude float64, date time.Time, location *time.Location) *DaylightDetails {
+	sun := newSunSet(latitude, longitude, 0)
+	yesterday := date.AddDate(0, 0, -1)
+	sun.setCurrentDate(yesterday.Year(), int(yesterday.Month()), yesterday.Day())
+	yesterdayLength := sun.sunriseSunset(yesterday, location).DayLength()
+	sun.setCurrentDate(date.Year(), int(date.Month()), date.Day())
+	sunriseSunset := sun.sunriseSunset(date, location)
+	res := &DaylightDetails{
+		SunriseSunset:        *sunriseSunset,
+		SolarNoon:            utcTime(date, sun.calcSolarNoonUTC(), location),
+		DayLength:            sunriseSunset.DayLength(),
+		DayLengthChange:      sunriseSunset.DayLength() - yesterdayLength,
+		CivilTwilight:        sun.calcTwilight(date, SunsetCivil, location),
+		NauticalTwilight:     sun.calcTwilight(date, SunsetNautical, location),
+		AstronomicalTwilight: sun.calcTwilight(date, SunsetAstronomical, location),
+	}
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+	// the day is 23 or 25 hours long when the clocks change
+	nextMidnight := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, location)
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, location)
+	res.MoonPhase, res.MoonIllumination = calcMoonPhase(julianDate(noon))
+	moonrise, hasMoonrise, moonset, hasMoonset := calcMoonRiseSet(julianDate(midnight), nextMidnight.Sub(midnight), latitude, longitude)
+	if hasMoonrise {
+		res.Moonrise, res.HasMoonrise = midnight.Add(time.Duration(moonrise*float64(time.Minute))).Round(time.Second), true
+	}
+	if hasMoonset {
+		res.Moonset, res.HasMoonset = midnight.Add(time.Duration(moonset*float64(time.Minute))).Round(time.Second), true
+	}
+	return res
+}
@@ -0,0 +1,256 @@
+package daylight
+
+import (
+	"testing"
+	"time"
+)
+
+// the expected times are the almanac ones of timeanddate.com rounded to the minute
+const tolerance = 2 * time.Minute
+
+type place struct {
+	latitude, longitude float64
+	zone                string
+}
+
+var (
+	sydney = place{-33.8688, 151.2093, "Australia/Sydney"}
+	london = place{51.5074, -0.1278, "Europe/London"}
+	tromso = place{69.6492, 18.9553, "Europe/Oslo"}
+)
+
+// clock parses the local HH:MM of the day, empty for the events which don't happen
+func clock(t *testing.T, day time.Time, text string) time.Time {
+	if text == "" {
+		return time.Time{}
+	}
+	hm, err := time.Parse("15:04", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hm.Hour(), hm.Minute(), 0, 0, day.Location())
+}
+
+func checkTime(t *testing.T, name string, got time.Time, expected time.Time) {
+	t.Helper()
+	if expected.IsZero() {
+		if !got.IsZero() {
+			t.Errorf("%s: got %s, expected none", name, got.Format(time.DateTime))
+		}
+		return
+	}
+	if diff := got.Sub(expected).Abs(); diff > tolerance {
+		t.Errorf("%s: got %s, expected %s", name, got.Format(time.DateTime), expected.Format(time.DateTime))
+	}
+}
+
+func TestDaylightDetails(t *testing.T) {
+	tests := []struct {
+		name                  string
+		place                 place
+		date                  string
+		dayType               DayType
+		sunrise, sunset, noon string
+		civilDawn, civilDusk  string
+		nauticalDawn          string
+		nauticalDusk          string
+		astronomicalDawn      string
+		astronomicalDusk      string
+		dayLength             [2]time.Duration
+	}{
+		{
+			name: "Sydney winter solstice", place: sydney, date: "2024-06-21", dayType: NormalDay,
+			sunrise: "07:00", sunset: "16:54", noon: "11:57",
+			civilDawn: "06:32", civilDusk: "17:22", nauticalDawn: "06:01", nauticalDusk: "17:53",
+			astronomicalDawn: "05:31", astronomicalDusk: "18:23",
+			dayLength: [2]time.Duration{9*time.Hour + 53*time.Minute, 9*time.Hour + 55*time.Minute},
+		},
+		{
+			name: "Sydney summer solstice in daylight saving time", place: sydney, date: "2024-12-21", dayType: NormalDay,
+			sunrise: "05:41", sunset: "20:05", noon: "12:53",
+			civilDawn: "05:12", civilDusk: "20:35", nauticalDawn: "04:36", nauticalDusk: "21:11",
+			astronomicalDawn: "03:57", astronomicalDusk: "21:50",
+			dayLength: [2]time.Duration{14*time.Hour + 23*time.Minute, 14*time.Hour + 26*time.Minute},
+		},
+		{
+			name: "London summer solstice without astronomical twilight", place: london, date: "2024-06-21", dayType: NormalDay,
+			sunrise: "04:43", sunset: "21:21", noon: "13:02",
+			civilDawn: "03:56", civilDusk: "22:09", nauticalDawn: "02:41", nauticalDusk: "23:24",
+			dayLength: [2]time.Duration{16*time.Hour + 37*time.Minute, 16*time.Hour + 40*time.Minute},
+		},
+		{
+			name: "London winter solstice", place: london, date: "2024-12-21", dayType: NormalDay,
+			sunrise: "08:04", sunset: "15:54", noon: "11:59",
+			civilDawn: "07:24", civilDusk: "16:34", nauticalDawn: "06:40", nauticalDusk: "17:17",
+			astronomicalDawn: "06:00", astronomicalDusk: "17:58",
+			dayLength: [2]time.Duration{7*time.Hour + 48*time.Minute, 7*time.Hour + 51*time.Minute},
+		},
+		{
+			name: "Sydney daylight saving time start", place: sydney, date: "2024-10-06", dayType: NormalDay,
+			sunrise: "06:25", sunset: "19:02", noon: "12:43",
+			civilDawn: "06:00", civilDusk: "19:27", nauticalDawn: "05:31", nauticalDusk: "19:57",
+			astronomicalDawn: "05:01", astronomicalDusk: "20:27",
+			dayLength: [2]time.Duration{12*time.Hour + 35*time.Minute, 12*time.Hour + 38*time.Minute},
+		},
+		{
+			name: "Sydney daylight saving time end", place: sydney, date: "2024-04-07", dayType: NormalDay,
+			sunrise: "06:12", sunset: "17:42", noon: "11:57",
+			civilDawn: "05:47", civilDusk: "18:07", nauticalDawn: "05:18", nauticalDusk: "18:36",
+			astronomicalDawn: "04:49", astronomicalDusk: "19:05",
+			dayLength: [2]time.Duration{11*time.Hour + 29*time.Minute, 11*time.Hour + 32*time.Minute},
+		},
+		{
+			name: "London summer time start", place: london, date: "2024-03-31", dayType: NormalDay,
+			sunrise: "06:37", sunset: "19:33", noon: "13:04",
+			civilDawn: "06:03", civilDusk: "20:07", nauticalDawn: "05:23", nauticalDusk: "20:48",
+			astronomicalDawn: "04:39", astronomicalDusk: "21:32",
+			dayLength: [2]time.Duration{12*time.Hour + 54*time.Minute, 12*time.Hour + 57*time.Minute},
+		},
+		{
+			name: "London summer time end", place: london, date: "2024-10-27", dayType: NormalDay,
+			sunrise: "06:46", sunset: "16:42", noon: "11:44",
+			civilDawn: "06:11", civilDusk: "17:17", nauticalDawn: "05:32", nauticalDusk: "17:56",
+			astronomicalDawn: "04:53", astronomicalDusk: "18:34",
+			dayLength: [2]time.Duration{9*time.Hour + 54*time.Minute, 9*time.Hour + 57*time.Minute},
+		},
+		{
+			name: "Tromsø polar day", place: tromso, date: "2024-06-21", dayType: PolarDay, noon: "12:46",
+			dayLength: [2]time.Duration{24 * time.Hour, 24 * time.Hour},
+		},
+		{
+			name: "Tromsø polar night with civil twilight at noon", place: tromso, date: "2024-12-21", dayType: PolarNight, noon: "11:42",
+			civilDawn: "09:32", civilDusk: "13:53", nauticalDawn: "07:47", nauticalDusk: "15:38",
+			astronomicalDawn: "06:29", astronomicalDusk: "16:56",
+		},
+		{
+			name: "Tromsø after the polar night", place: tromso, date: "2024-01-25", dayType: NormalDay,
+			sunrise: "10:06", sunset: "13:48", noon: "11:56",
+			civilDawn: "08:28", civilDusk: "15:26", nauticalDawn: "07:06", nauticalDusk: "16:48",
+			astronomicalDawn: "05:55", astronomicalDusk: "17:59",
+			dayLength: [2]time.Duration{3*time.Hour + 40*time.Minute, 3*time.Hour + 43*time.Minute},
+		},
+	}
+	provider := NewSunriseSunsetProvider()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location, err := time.LoadLocation(test.place.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			day, err := time.ParseInLocation(time.DateOnly, test.date, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			details := provider.GetDaylightDetails(test.place.latitude, test.place.longitude, day, location)
+			if details.Type != test.dayType {
+				t.Fatalf("got day type %d, expected %d", details.Type, test.dayType)
+			}
+			checkTime(t, "sunrise", details.Sunrise, clock(t, day, test.sunrise))
+			checkTime(t, "sunset", details.Sunset, clock(t, day, test.sunset))
+			checkTime(t, "solar noon", details.SolarNoon, clock(t, day, test.noon))
+			for _, twilight := range []struct {
+				name       string
+				twilight   Twilight
+				dawn, dusk string
+			}{
+				{"civil", details.CivilTwilight, test.civilDawn, test.civilDusk},
+				{"nautical", details.NauticalTwilight, test.nauticalDawn, test.nauticalDusk},
+				{"astronomical", details.AstronomicalTwilight, test.astronomicalDawn, test.astronomicalDusk},
+			} {
+				if twilight.twilight.Valid != (twilight.dawn != "") {
+					t.Errorf("%s twilight: got valid %v", twilight.name, twilight.twilight.Valid)
+					continue
+				}
+				checkTime(t, twilight.name+" dawn", twilight.twilight.Dawn, clock(t, day, twilight.dawn))
+				checkTime(t, twilight.name+" dusk", twilight.twilight.Dusk, clock(t, day, twilight.dusk))
+			}
+			if details.DayLength < test.dayLength[0] || details.DayLength > test.dayLength[1] {
+				t.Errorf("got day length %s, expected %s to %s", details.DayLength, test.dayLength[0], test.dayLength[1])
+			}
+			// the short version of the provider agrees with the details
+			sunriseSunset := provider.GetSunriseSunset(test.place.latitude, test.place.longitude, day, location)
+			if *sunriseSunset != details.SunriseSunset {
+				t.Errorf("got %+v from GetSunriseSunset and %+v from GetDaylightDetails", sunriseSunset, details.SunriseSunset)
+			}
+		})
+	}
+}
+
+func TestTomorrowAcrossTransition(t *testing.T) {
+	// tomorrow is looked up late in the evening before the clocks change, the way the night schedule does it
+	tests := []struct {
+		name     string
+		place    place
+		evening  string
+		sunrise  string
+		tomorrow string
+	}{
+		{"Sydney daylight saving time start", sydney, "2024-10-05 23:30", "06:25", "2024-10-06"},
+		{"Sydney daylight saving time end", sydney, "2024-04-06 23:30", "06:12", "2024-04-07"},
+		{"London summer time start", london, "2024-03-30 23:30", "06:37", "2024-03-31"},
+		{"London summer time end", london, "2024-10-26 23:30", "06:46", "2024-10-27"},
+	}
+	provider := NewSunriseSunsetProvider()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location, err := time.LoadLocation(test.place.zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			evening, err := time.ParseInLocation("2006-01-02 15:04", test.evening, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nextMidnight := time.Date(evening.Year(), evening.Month(), evening.Day()+1, 0, 0, 0, 0, location)
+			tomorrow, err := time.ParseInLocation(time.DateOnly, test.tomorrow, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			details := provider.GetDaylightDetails(test.place.latitude, test.place.longitude, nextMidnight, location)
+			checkTime(t, "sunrise", details.Sunrise, clock(t, tomorrow, test.sunrise))
+			// the day length changes by minutes, the hour of the clock change doesn't leak into it
+			if details.DayLengthChange.Abs() > 5*time.Minute {
+				t.Errorf("got day length change %s", details.DayLengthChange)
+			}
+			sunriseSunset := provider.GetSunriseSunset(test.place.latitude, test.place.longitude, nextMidnight, location)
+			if *sunriseSunset != details.SunriseSunset {
+				t.Errorf("got %+v from GetSunriseSunset and %+v from GetDaylightDetails", sunriseSunset, details.SunriseSunset)
+			}
+		})
+	}
+}
+
+func TestMoonPhase(t *testing.T) {
+	// the principal phases of January 2024 in UTC
+	tests := []struct {
+		name         string
+		at           time.Time
+		phase        float64
+		illumination float64
+		key          string
+	}{
+		{"new moon", time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC), 0, 0, "moon_new"},
+		{"first quarter", time.Date(2024, 1, 18, 3, 52, 0, 0, time.UTC), 0.25, 0.5, "moon_first_quarter"},
+		{"full moon", time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC), 0.5, 1, "moon_full"},
+		{"last quarter", time.Date(2024, 2, 2, 23, 18, 0, 0, time.UTC), 0.75, 0.5, "moon_last_quarter"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			phase, illumination := calcMoonPhase(julianDate(test.at))
+			// the phase wraps around at the new moon
+			diff := phase - test.phase
+			if diff > 0.5 {
+				diff -= 1
+			}
+			if diff < -0.01 || diff > 0.01 {
+				t.Errorf("got phase %f, expected %f", phase, test.phase)
+			}
+			if illumination < test.illumination-0.02 || illumination > test.illumination+0.02 {
+				t.Errorf("got illumination %f, expected %f", illumination, test.illumination)
+			}
+			if key := MoonPhaseKey(phase); key != test.key {
+				t.Errorf("got %s, expected %s", key, test.key)
+			}
+		})
+	}
+}
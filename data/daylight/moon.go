@@ -0,0 +1,105 @@
+package daylight
+
+import (
+	"math"
+	"time"
+)
+
+// Low precision lunar position from the Astronomical Almanac, accurate to about 0.3 degrees,
+// which gives moonrise and moonset within a couple of minutes and the phase within a few hours.
+// https://celestialprogramming.com/lowprecisionmoonposition.html
+
+type moonPosition struct {
+	longitude      float64 // ecliptic, degrees
+	rightAscension float64 // degrees
+	declination    float64 // degrees
+	parallax       float64 // horizontal parallax, degrees
+}
+
+func sinDeg(angleDeg float64) float64 {
+	return math.Sin(degToRad(angleDeg))
+}
+
+func cosDeg(angleDeg float64) float64 {
+	return math.Cos(degToRad(angleDeg))
+}
+
+func normalizeDegrees(angleDeg float64) float64 {
+	res := math.Mod(angleDeg, 360.0)
+	if res < 0 {
+		res += 360.0
+	}
+	return res
+}
+
+func calcMoonPosition(jd float64) *moonPosition {
+	t := calcTimeJulianCent(jd)
+	lambda := 218.32 + 481267.881*t +
+		6.29*sinDeg(135.0+477198.87*t) -
+		1.27*sinDeg(259.3-413335.36*t) +
+		0.66*sinDeg(235.7+890534.22*t) +
+		0.21*sinDeg(269.9+954397.74*t) -
+		0.19*sinDeg(357.5+35999.05*t) -
+		0.11*sinDeg(186.5+966404.03*t)
+	beta := 5.13*sinDeg(93.3+483202.02*t) +
+		0.28*sinDeg(228.2+960400.89*t) -
+		0.28*sinDeg(318.3+6003.15*t) -
+		0.17*sinDeg(217.6-407332.21*t)
+	parallax := 0.9508 +
+		0.0518*cosDeg(135.0+477198.87*t) +
+		0.0095*cosDeg(259.3-413335.36*t) +
+		0.0078*cosDeg(235.7+890534.22*t) +
+		0.0028*cosDeg(269.9+954397.74*t)
+	epsilon := calcObliquityCorrection(t)
+	// ecliptic to equatorial coordinates
+	l := cosDeg(beta) * cosDeg(lambda)
+	m := cosDeg(epsilon)*cosDeg(beta)*sinDeg(lambda) - sinDeg(epsilon)*sinDeg(beta)
+	n := sinDeg(epsilon)*cosDeg(beta)*sinDeg(lambda) + cosDeg(epsilon)*sinDeg(beta)
+	return &moonPosition{
+		longitude:      normalizeDegrees(lambda),
+		rightAscension: normalizeDegrees(radToDeg(math.Atan2(m, l))),
+		declination:    radToDeg(math.Asin(n)),
+		parallax:       parallax,
+	}
+}
+
+// calcMoonAltitude returns the altitude of the Moon above the horizon corrected for the parallax,
+// longitude is positive to the east
+func calcMoonAltitude(jd, latitude, longitude float64) float64 {
+	position := calcMoonPosition(jd)
+	siderealTime := 280.46061837 + 360.98564736629*(jd-2451545.0)
+	hourAngle := siderealTime + longitude - position.rightAscension
+	sinAltitude := sinDeg(latitude)*sinDeg(position.declination) + cosDeg(latitude)*cosDeg(position.declination)*cosDeg(hourAngle)
+	// the rise and set happen when the upper limb touches the horizon, with refraction and the parallax
+	horizon := 0.7275*position.parallax - 0.5667
+	return radToDeg(math.Asin(sinAltitude)) - horizon
+}
+
+// calcMoonPhase returns the phase as the fraction of the synodic month (0 new moon, 0.5 full moon)
+// and the illuminated fraction of the disc
+func calcMoonPhase(jd float64) (float64, float64) {
+	elongation := normalizeDegrees(calcMoonPosition(jd).longitude - calcSunApparentLong(calcTimeJulianCent(jd)))
+	return elongation / 360.0, (1 - cosDeg(elongation)) / 2
+}
+
+// moon altitude is sampled with this step in minutes and the crossings are interpolated
+const moonSearchStepMinutes = 10
+
+// calcMoonRiseSet finds moonrise and moonset in minutes after midnight of the day starting at the given julian date,
+// the Moon doesn't rise or set on some days, the flags are false then
+func calcMoonRiseSet(jdMidnight float64, dayLength time.Duration, latitude, longitude float64) (float64, bool, float64, bool) {
+	var rise, set float64
+	hasRise, hasSet := false, false
+	previous := calcMoonAltitude(jdMidnight, latitude, longitude)
+	for minutes := moonSearchStepMinutes; minutes <= int(dayLength.Minutes()); minutes += moonSearchStepMinutes {
+		current := calcMoonAltitude(jdMidnight+float64(minutes)/1440.0, latitude, longitude)
+		crossing := float64(minutes-moonSearchStepMinutes) + moonSearchStepMinutes*previous/(previous-current)
+		if previous < 0 && current >= 0 && !hasRise {
+			rise, hasRise = crossing, true
+		} else if previous >= 0 && current < 0 && !hasSet {
+			set, hasSet = crossing, true
+		}
+		previous = current
+	}
+	return rise, hasRise, set, hasSet
+}
@@ -5,25 +5,23 @@ import (
 	"time"
 )
 
+// SunriseSunsetProvider calculates the Sun and the Moon events of a calendar day in the given location,
+// the time zone offset is taken for the requested date, so DST changes are handled
 type SunriseSunsetProvider interface {
-	GetSunriseSunset(latitude, longitude float64, date time.Time) (time.Duration, time.Duration)
-	GetSunriseSunsetAtOffset(latitude, longitude float64, date time.Time, offsetSeconds int) (time.Duration, time.Duration)
+	GetSunriseSunset(latitude, longitude float64, date time.Time, location *time.Location) *SunriseSunset
+	GetDaylightDetails(latitude, longitude float64, date time.Time, location *time.Location) *DaylightDetails
 }
 
 type sunriseSunsetProvider struct{}
 
-func (s sunriseSunsetProvider) GetSunriseSunset(latitude, longitude float64, date time.Time) (time.Duration, time.Duration) {
-	now := time.Now()
-	_, tzOffsetSeconds := now.Zone()
-	return s.GetSunriseSunsetAtOffset(latitude, longitude, date, tzOffsetSeconds)
+func (s sunriseSunsetProvider) GetSunriseSunset(latitude, longitude float64, date time.Time, location *time.Location) *SunriseSunset {
+	sun := newSunSet(latitude, longitude, 0)
+	sun.setCurrentDate(date.Year(), int(date.Month()), date.Day())
+	return sun.sunriseSunset(date, location)
 }
 
-func (s sunriseSunsetProvider) GetSunriseSunsetAtOffset(latitude, longitude float64, date time.Time, offsetSeconds int) (time.Duration, time.Duration) {
-	res := newSunSet(latitude, longitude, float64(offsetSeconds)/3600.0)
-	res.setCurrentDate(date.Year(), int(date.Month()), date.Day())
-	sunrise := res.calcSunrise()
-	sunset := res.calcSunset()
-	return time.Duration(int(60.0*sunrise)) * time.Second, time.Duration(int(60.0*sunset)) * time.Second
+func (s sunriseSunsetProvider) GetDaylightDetails(latitude, longitude float64, date time.Time, location *time.Location) *DaylightDetails {
+	return calcDaylightDetails(latitude, longitude, date, location)
 }
 
 func NewSunriseSunsetProvider() SunriseSunsetProvider {
@@ -0,0 +1,207 @@
+package energy
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"github.com/rotisserie/eris"
+	"math"
+	"strconv"
+	"time"
+)
+
+// EnergyData sums up the energy since the midnight in kWh
+type EnergyData struct {
+	Solar            float64
+	HasGrid          bool
+	GridImport       float64
+	GridExport       float64
+	HasConsumption   bool // false without the consumption sensor and the grid sensors
+	Consumption      float64
+	HasBattery       bool
+	Battery          float64     // state of charge in %
+	SolarHours       [24]float64 // kWh in every hour since the midnight
+	ConsumptionHours [24]float64 // zero if the consumption is unknown
+	Hour             int         // the current hour, the later ones are empty
+}
+
+type EnergyDataProvider interface {
+	GetEnergy() (*EnergyData, error)
+}
+
+type energyDataProvider struct {
+	config    config.ConfigApi
+	haApi     ha.HomeAssistantApi
+	cached    *EnergyData
+	fetchedAt time.Time
+}
+
+func NewEnergyDataProvider(cfg config.ConfigApi, haApi ha.HomeAssistantApi) (EnergyDataProvider, error) {
+	switch cfg.GetEnergy().SensorType {
+	case "power", "energy":
+	default:
+		return nil, eris.Errorf("unknown energy sensor type '%s', expected 'power' or 'energy'", cfg.GetEnergy().SensorType)
+	}
+	return &energyDataProvider{config: cfg, haApi: haApi}, nil
+}
+
+func midnight(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// GetEnergy downloads the history of today once per refresh interval and right after the midnight
+func (e *energyDataProvider) GetEnergy() (*EnergyData, error) {
+	settings := e.config.GetEnergy()
+	now := time.Now()
+	refresh := time.Duration(settings.RefreshMinutes) * time.Minute
+	if e.cached != nil && now.Sub(e.fetchedAt) < refresh && midnight(now).Equal(midnight(e.fetchedAt)) {
+		return e.cached, nil
+	}
+	from := midnight(now)
+	res := &EnergyData{Hour: now.Hour()}
+	var err error
+	res.SolarHours, err = e.hourlyEnergy(settings.SolarSensor, settings.SensorType, from, now)
+	if err != nil {
+		return nil, err
+	}
+	res.Solar = sum(res.SolarHours)
+	var importHours, exportHours [24]float64
+	if settings.GridImportSensor != "" && settings.GridExportSensor != "" {
+		res.HasGrid = true
+		importHours, err = e.hourlyEnergy(settings.GridImportSensor, settings.SensorType, from, now)
+		if err != nil {
+			return nil, err
+		}
+		exportHours, err = e.hourlyEnergy(settings.GridExportSensor, settings.SensorType, from, now)
+		if err != nil {
+			return nil, err
+		}
+		res.GridImport = sum(importHours)
+		res.GridExport = sum(exportHours)
+	}
+	switch {
+	case settings.ConsumptionSensor != "":
+		res.HasConsumption = true
+		res.ConsumptionHours, err = e.hourlyEnergy(settings.ConsumptionSensor, settings.SensorType, from, now)
+		if err != nil {
+			return nil, err
+		}
+	case res.HasGrid:
+		// the battery is left out, it only shifts the consumption between the hours
+		res.HasConsumption = true
+		for i := range res.ConsumptionHours {
+			res.ConsumptionHours[i] = max(0, res.SolarHours[i]+importHours[i]-exportHours[i])
+		}
+	}
+	res.Consumption = sum(res.ConsumptionHours)
+	if settings.BatterySensor != "" {
+		value, err := e.haApi.DownloadSensorValueFromHA(settings.BatterySensor)
+		if err != nil {
+			return nil, err
+		}
+		res.Battery, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, eris.Wrapf(err, "battery state '%s' is not a number", value)
+		}
+		res.HasBattery = true
+	}
+	e.cached = res
+	e.fetchedAt = now
+	return res, nil
+}
+
+func (e *energyDataProvider) hourlyEnergy(sensorName, sensorType string, from, to time.Time) ([24]float64, error) {
+	history, err := e.haApi.DownloadSensorHistoryFromHA(sensorName, from, to, false)
+	if err != nil {
+		return [24]float64{}, eris.Wrapf(err, "Error loading history of %s", sensorName)
+	}
+	series := toNumericSeries(history)
+	if sensorType == "energy" {
+		return meterBuckets(series, from), nil
+	}
+	return powerBuckets(series, from, to), nil
+}
+
+// toNumericSeries keeps the unavailable and the broken values as NaN, they are gaps in the data
+func toNumericSeries(history []*ha.HomeAssistantHistoryItem) []*ha.NumericHistoryValue {
+	res := make([]*ha.NumericHistoryValue, 0, len(history))
+	for _, item := range history {
+		value, err := strconv.ParseFloat(item.Value, 64)
+		if err != nil {
+			value = math.NaN()
+		}
+		res = append(res, &ha.NumericHistoryValue{Timestamp: item.Timestamp, Value: value})
+	}
+	return res
+}
+
+// hourIndex is the wall-clock hour in the location, the repeated hour of the day the clock goes back counts twice
+// in its bucket and the hour skipped when it goes forward stays empty
+func hourIndex(t time.Time, location *time.Location) int {
+	return t.In(location).Hour()
+}
+
+// nextHour is the start of the next wall-clock hour in the location of the time, it is counted from the minutes
+// of the local time since time.Date can pick either of the repeated hours when the clock goes back
+func nextHour(t time.Time) time.Time {
+	return t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+}
+
+// powerBuckets integrates the power in W into kWh per hour, the sensors only report changes so every value
+// holds until the next one
+func powerBuckets(series []*ha.NumericHistoryValue, from, to time.Time) [24]float64 {
+	var res [24]float64
+	for i, v := range series {
+		if math.IsNaN(v.Value) {
+			continue
+		}
+		end := to
+		if i+1 < len(series) {
+			end = series[i+1].Timestamp
+		}
+		// the first value is the state at the midnight, it may have been reported earlier
+		start := v.Timestamp
+		if start.Before(from) {
+			start = from
+		}
+		kw := max(0, v.Value) / 1000
+		for start.Before(end) {
+			local := start.In(from.Location())
+			hourEnd := nextHour(local)
+			if end.Before(hourEnd) {
+				hourEnd = end
+			}
+			res[local.Hour()] += kw * hourEnd.Sub(start).Hours()
+			start = hourEnd
+		}
+	}
+	return res
+}
+
+// meterBuckets adds the increases of the meter in kWh to the hours they are reported in, the meter is assumed
+// to have been reset if it goes down
+func meterBuckets(series []*ha.NumericHistoryValue, from time.Time) [24]float64 {
+	var res [24]float64
+	previous := math.NaN()
+	for _, v := range series {
+		if math.IsNaN(v.Value) {
+			continue
+		}
+		if !math.IsNaN(previous) {
+			increase := v.Value - previous
+			if increase < 0 {
+				increase = v.Value
+			}
+			res[hourIndex(v.Timestamp, from.Location())] += increase
+		}
+		previous = v.Value
+	}
+	return res
+}
+
+func sum(hours [24]float64) float64 {
+	res := 0.0
+	for _, v := range hours {
+		res += v
+	}
+	return res
+}
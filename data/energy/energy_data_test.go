@@ -0,0 +1,153 @@
+package energy
+
+import (
+	"fkirill.org/eink-meteo-station/data/ha"
+	"math"
+	"testing"
+	"time"
+)
+
+// value is reported at the local time of the day as parsed by at
+type value struct {
+	at    string
+	value float64
+}
+
+func series(t *testing.T, day time.Time, values []value) []*ha.NumericHistoryValue {
+	res := make([]*ha.NumericHistoryValue, 0, len(values))
+	for _, v := range values {
+		res = append(res, &ha.NumericHistoryValue{Timestamp: at(t, day, v.at).UTC(), Value: v.value})
+	}
+	return res
+}
+
+// at parses HH:MM of the day, "-HH:MM" is on the day before and "+HH:MM" is the second pass of the hour
+// repeated when the clock goes back
+func at(t *testing.T, day time.Time, text string) time.Time {
+	t.Helper()
+	date := day
+	if text[0] == '-' {
+		date = day.AddDate(0, 0, -1)
+		text = text[1:]
+	}
+	second := false
+	if text[0] == '+' {
+		second = true
+		text = text[1:]
+	}
+	hm, err := time.Parse("15:04", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := time.Date(date.Year(), date.Month(), date.Day(), hm.Hour(), hm.Minute(), 0, 0, day.Location())
+	if second {
+		_, offset := res.Zone()
+		_, later := res.Add(time.Hour).Zone()
+		res = res.Add(time.Duration(offset-later) * time.Second)
+	}
+	return res
+}
+
+func checkHours(t *testing.T, got [24]float64, expected map[int]float64) {
+	t.Helper()
+	for hour, v := range got {
+		if math.Abs(v-expected[hour]) > 1e-9 {
+			t.Errorf("hour %d: got %f kWh, expected %f", hour, v, expected[hour])
+		}
+	}
+}
+
+func TestPowerBuckets(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		day      time.Time
+		values   []value
+		to       string
+		expected map[int]float64
+	}{
+		{
+			name: "across the hours", day: time.Date(2024, 6, 12, 0, 0, 0, 0, london),
+			values:   []value{{"00:00", 1000}, {"01:30", 2000}},
+			to:       "03:00",
+			expected: map[int]float64{0: 1, 1: 1.5, 2: 2},
+		},
+		{
+			name: "state reported before the midnight", day: time.Date(2024, 6, 12, 0, 0, 0, 0, london),
+			values:   []value{{"-22:15", 500}, {"00:45", 0}, {"01:15", 4000}},
+			to:       "01:30",
+			expected: map[int]float64{0: 0.375, 1: 1},
+		},
+		{
+			name: "unavailable and negative values", day: time.Date(2024, 6, 12, 0, 0, 0, 0, london),
+			values:   []value{{"10:00", 1000}, {"10:30", math.NaN()}, {"11:00", -50}, {"11:30", 3000}},
+			to:       "12:00",
+			expected: map[int]float64{10: 0.5, 11: 1.5},
+		},
+		{
+			name: "23 hours when the clock goes forward", day: time.Date(2024, 3, 31, 0, 0, 0, 0, london),
+			values:   []value{{"00:00", 1000}},
+			to:       "03:00",
+			expected: map[int]float64{0: 1, 2: 1},
+		},
+		{
+			name: "25 hours when the clock goes back", day: time.Date(2024, 10, 27, 0, 0, 0, 0, london),
+			values:   []value{{"00:00", 1000}, {"+01:30", 2000}},
+			to:       "03:00",
+			expected: map[int]float64{0: 1, 1: 2.5, 2: 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := powerBuckets(series(t, test.day, test.values), test.day, at(t, test.day, test.to))
+			checkHours(t, got, test.expected)
+		})
+	}
+}
+
+func TestMeterBuckets(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		day      time.Time
+		values   []value
+		expected map[int]float64
+	}{
+		{
+			name: "increases in the hours they are reported in", day: time.Date(2024, 6, 12, 0, 0, 0, 0, london),
+			values:   []value{{"-23:50", 10}, {"00:30", 10.5}, {"01:10", 11.2}, {"01:50", 11.5}},
+			expected: map[int]float64{0: 0.5, 1: 1},
+		},
+		{
+			name: "reset of the meter", day: time.Date(2024, 6, 12, 0, 0, 0, 0, london),
+			values:   []value{{"-23:50", 10}, {"00:30", 10.5}, {"02:20", 0.3}, {"02:50", 0.9}},
+			expected: map[int]float64{0: 0.5, 2: 0.9},
+		},
+		{
+			name: "unavailable meter", day: time.Date(2024, 6, 12, 0, 0, 0, 0, london),
+			values:   []value{{"-23:50", 10}, {"00:30", math.NaN()}, {"01:10", 11}},
+			expected: map[int]float64{1: 1},
+		},
+		{
+			name: "23 hours when the clock goes forward", day: time.Date(2024, 3, 31, 0, 0, 0, 0, london),
+			values:   []value{{"-23:50", 10}, {"00:50", 11}, {"02:10", 12}, {"02:50", 13}},
+			expected: map[int]float64{0: 1, 2: 2},
+		},
+		{
+			name: "25 hours when the clock goes back", day: time.Date(2024, 10, 27, 0, 0, 0, 0, london),
+			values:   []value{{"-23:50", 10}, {"00:50", 11}, {"01:10", 12}, {"+01:10", 13}, {"02:10", 14}},
+			expected: map[int]float64{0: 1, 1: 2, 2: 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkHours(t, meterBuckets(series(t, test.day, test.values), test.day), test.expected)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package environment
+
+import "math"
+
+// Magnus formula coefficients for water above -45°C
+const (
+	magnusA = 17.62
+	magnusB = 243.12
+)
+
+// dewPoint returns the dew point in °C for the temperature in °C and the relative humidity in percent
+func dewPoint(celsius, humidity float64) float64 {
+	gamma := math.Log(math.Max(humidity, 1)/100) + magnusA*celsius/(magnusB+celsius)
+	return magnusB * gamma / (magnusA - gamma)
+}
+
+// absoluteHumidity returns the water vapour content of the air in g/m³
+func absoluteHumidity(celsius, humidity float64) float64 {
+	saturation := 6.112 * math.Exp(magnusA*celsius/(magnusB+celsius)) // hPa
+	return saturation * humidity * 2.1674 / (273.15 + celsius)
+}
+
+// heat index and humidex are only meaningful in warm weather
+const heatIndexFromCelsius = 26.7
+
+// heatIndex returns the NOAA heat index (Rothfusz regression with the usual adjustments) in °C
+func heatIndex(celsius, humidity float64) float64 {
+	t := celsius*9/5 + 32
+	simple := 0.5 * (t + 61 + (t-68)*1.2 + humidity*0.094)
+	if (simple+t)/2 < 80 {
+		return (simple - 32) * 5 / 9
+	}
+	res := -42.379 + 2.04901523*t + 10.14333127*humidity - 0.22475541*t*humidity - 0.00683783*t*t -
+		0.05481717*humidity*humidity + 0.00122874*t*t*humidity + 0.00085282*t*humidity*humidity -
+		0.00000199*t*t*humidity*humidity
+	switch {
+	case humidity < 13 && t <= 112:
+		res -= (13 - humidity) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case humidity > 85 && t <= 87:
+		res += (humidity - 85) / 10 * (87 - t) / 5
+	}
+	return (res - 32) * 5 / 9
+}
+
+// humidex returns the Canadian humidex, a dimensionless number read as °C
+func humidex(celsius, humidity float64) float64 {
+	dewPointKelvin := dewPoint(celsius, humidity) + 273.15
+	vapourPressure := 6.11 * math.Exp(5417.7530*(1/273.16-1/dewPointKelvin))
+	return celsius + 0.5555*(vapourPressure-10)
+}
+
+// wind chill is defined for temperatures up to 10°C and winds above 4.8 km/h
+const (
+	windChillToCelsius   = 10.0
+	windChillFromKmPerH  = 4.8
+	metersPerSecondToKmH = 3.6
+)
+
+// windChill returns the wind chill temperature in °C (the North American formula) for the wind in m/s,
+// NaN outside of the range it is defined for
+func windChill(celsius, metersPerSecond float64) float64 {
+	v := metersPerSecond * metersPerSecondToKmH
+	if math.IsNaN(v) || celsius > windChillToCelsius || v <= windChillFromKmPerH {
+		return math.NaN()
+	}
+	p := math.Pow(v, 0.16)
+	return 13.12 + 0.6215*celsius - 11.37*p + 0.3965*celsius*p
+}
+
+// thresholds of the ventilation advice
+const (
+	mouldRiskHumidity        = 70.0 // relative humidity inside in percent
+	ventilateHumidity        = 60.0
+	keepClosedHumidity       = 50.0
+	absoluteHumidityMarginGM = 1.0 // g/m³ of difference worth acting on
+)
+
+// ventilationAdvice returns the message key of the advice comparing the water content of the air inside and outside,
+// empty if there is nothing to advise
+func ventilationAdvice(inside, outside *TemperatureHumidityData) string {
+	switch {
+	case inside.Humidity >= ventilateHumidity && outside.AbsoluteHumidityValue < inside.AbsoluteHumidityValue-absoluteHumidityMarginGM:
+		// the outside air is drier, airing brings the humidity down
+		return "advice_open_window"
+	case inside.Humidity >= mouldRiskHumidity:
+		return "advice_mould_risk"
+	case inside.Humidity >= keepClosedHumidity && outside.AbsoluteHumidityValue > inside.AbsoluteHumidityValue+absoluteHumidityMarginGM:
+		// airing would bring even more water in
+		return "advice_keep_closed"
+	}
+	return ""
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fkirill.org/eink-meteo-station/config"
 	"fkirill.org/eink-meteo-station/data/ha"
+	"fkirill.org/eink-meteo-station/i18n"
 	"fkirill.org/eink-meteo-station/images"
+	"fkirill.org/eink-meteo-station/units"
+	"github.com/rotisserie/eris"
 	"math"
 	"strconv"
 	"time"
@@ -12,15 +15,19 @@ import (
 
 type PressureData struct {
 	Warning           bool   // display an error sign
-	PressureInt       string // three digits
+	PressureInt       string // integer part in the configured units
 	PressureFrac      string // one digit
+	PressureUnit      string // mmHg, hPa or inHg
 	PressureRising    bool   // One of the three must be true
 	PressureSteady    bool   // the other two must be false
 	PressureFalling   bool
 	PressureAboveNorm bool   // one of the two must be true, the other must be false
 	PressureBelowNorm bool   // when delta == 0.0, it is considered "above" for display purposes
-	PressureDeltaInt  string // two characters, first may be space if delta <10 mmHg
-	PressureDeltaFrac string // one digit
+	PressureDelta     string // distance from the norm, one decimal, two for inHg
+	PressureSparkline string // inline SVG of the history, empty if not configured
+	TendencyCode      int    // WMO characteristic of the pressure tendency over 3 hours, 0 to 8
+	Tendency          string // change over 3 hours in the configured units and the characteristic
+	Forecast          string // local Zambretti forecast from the pressure, its trend, the wind and the season
 	WarningPng        string
 	RisingPng         string
 	FallingPng        string
@@ -30,8 +37,9 @@ type PressureData struct {
 type TemperatureHumidityData struct {
 	Title                  string
 	Warning                bool   // display an error sign
-	TemperatureInt         string // two digits
+	TemperatureInt         string // two or three digits
 	TemperatureFrac        string // one digit
+	TemperatureUnit        string // °C or °F
 	TemperatureRising      bool   // One of the three must be true
 	TemperatureFalling     bool   // the other two must be false
 	TemperatureSteady      bool
@@ -41,6 +49,16 @@ type TemperatureHumidityData struct {
 	HumidityFalling        bool   // the other two must be false
 	HumiditySteady         bool
 	HundredPercentHumidity bool
+	TemperatureSparkline   string // inline SVG of the history, empty if not configured
+	HumiditySparkline      string
+	DewPointLabel          string
+	DewPoint               string // in the configured units
+	FeelsLikeLabel         string
+	FeelsLike              string  // wind chill, heat index or humidex, the temperature itself if none applies
+	AbsoluteHumidity       string  // g/m³ with one decimal
+	Advice                 string  // ventilation advice on the inside view, empty if none
+	Humidity               float64 // relative humidity in percent
+	AbsoluteHumidityValue  float64 // g/m³
 	WarningPng             string
 	ThermometerPng         string
 	RisingPng              string
@@ -53,23 +71,29 @@ type EnvironmentDataProvider interface {
 	GetInsideTemperatureHumidity() (*TemperatureHumidityData, error)
 	GetOutsideTemperatureHumidity() (*TemperatureHumidityData, error)
 	GetPressure() (*PressureData, error)
+	// GetVentilationAdvice compares the water content of the air inside and outside, empty if there is nothing to advise
+	GetVentilationAdvice(inside, outside *TemperatureHumidityData) string
 }
 
 type environmentDataProvider struct {
-	config config.ConfigApi
-	haApi  ha.HomeAssistantApi
+	config     config.ConfigApi
+	haApi      ha.HomeAssistantApi
+	units      units.Units
+	translator i18n.Translator
+	sparklines map[string]*cachedSparkline // sensor name to its graph
 }
 
 func (e *environmentDataProvider) GetInsideTemperatureHumidity() (*TemperatureHumidityData, error) {
 	insideTemperatureSensorName := e.config.GetInternalTemperatureSensorName()
 	insideHumiditySensorName := e.config.GetInternalHumiditySensorName()
-	return e.getTemperatureHumidity("Inside", insideTemperatureSensorName, insideHumiditySensorName)
+	// there is no wind inside
+	return e.getTemperatureHumidity(e.translator.Text("inside"), insideTemperatureSensorName, insideHumiditySensorName, math.NaN())
 }
 
 func (e *environmentDataProvider) GetOutsideTemperatureHumidity() (*TemperatureHumidityData, error) {
 	outsideTemperatureSensorName := e.config.GetExternalTemperatureSensorName()
 	outsideHumiditySensorName := e.config.GetExternalHumiditySensorName()
-	return e.getTemperatureHumidity("Outside", outsideTemperatureSensorName, outsideHumiditySensorName)
+	return e.getTemperatureHumidity(e.translator.Text("outside"), outsideTemperatureSensorName, outsideHumiditySensorName, e.getWindSpeed())
 }
 
 func (e *environmentDataProvider) GetPressure() (*PressureData, error) {
@@ -83,8 +107,8 @@ func (e *environmentDataProvider) GetPressure() (*PressureData, error) {
 		return nil, err
 	}
 	now := time.Now()
-	pressureVal /= hPaToMmHgCoeff
-	pressureHistory, err := e.haApi.DownloadSensorHistoryFromHA(pressureSensorName, now.Add(-60*time.Minute), now, false)
+	// a bit more than the tendency period to know the pressure at its start
+	pressureHistory, err := e.haApi.DownloadSensorHistoryFromHA(pressureSensorName, now.Add(-tendencyPeriod-10*time.Minute), now, false)
 	if err != nil {
 		return nil, err
 	}
@@ -92,28 +116,48 @@ func (e *environmentDataProvider) GetPressure() (*PressureData, error) {
 	if err != nil {
 		return nil, err
 	}
-	pressureSlope := slope(pressureNumericSeries) * 3600 / hPaToMmHgCoeff
-	pressureRising := pressureSlope >= 1.0
-	pressureFalling := pressureSlope <= -1.0
+	if len(pressureNumericSeries) == 0 {
+		return nil, errors.New("no pressure history available")
+	}
+	lastHour := make([]*ha.NumericHistoryValue, 0, len(pressureNumericSeries))
+	for _, v := range pressureNumericSeries {
+		if v.Timestamp.After(now.Add(-60 * time.Minute)) {
+			lastHour = append(lastHour, v)
+		}
+	}
+	pressureSlope := 0.0
+	if len(lastHour) > 1 {
+		pressureSlope = slope(lastHour) * 3600
+	}
+	tendencyCode, change := pressureTendency(pressureNumericSeries, now)
+	latitude, _ := e.config.GetDaylightCoordinates()
+	forecastKey, exceptional := zambrettiForecast(pressureVal, change, e.getWindDirection(), latitude, now.Month())
+	forecast := e.translator.Text(forecastKey)
+	if exceptional {
+		forecast = e.translator.Text("zambretti_exceptional") + ", " + forecast
+	}
+	pressureRising := pressureSlope >= pressureTrendThresholdHPa
+	pressureFalling := pressureSlope <= -pressureTrendThresholdHPa
 	pressureSteady := !(pressureRising || pressureFalling)
-	pressureDelta := pressureVal - normalPressureMmHg
+	displayPressure := e.units.Pressure(pressureVal)
+	pressureDelta := displayPressure - e.units.Pressure(units.NormalPressureHPa)
 	pressureAboveNorm := pressureDelta >= 0.0
-	pressureInt := strconv.Itoa(int(math.Trunc(pressureVal)))                  // always yields three digits
-	pressureDeltaInt := strconv.Itoa(int(math.Trunc(math.Abs(pressureDelta)))) // one or two digits
-	if len(pressureDeltaInt) == 1 {
-		pressureDeltaInt = " " + pressureDeltaInt
-	}
+	pressureInt := strconv.Itoa(int(math.Trunc(displayPressure)))
 	return &PressureData{
 		Warning:           false,
 		PressureInt:       pressureInt,
-		PressureFrac:      formatFrac(pressureVal),
+		PressureFrac:      formatFrac(displayPressure),
+		PressureUnit:      e.units.PressureUnit(),
 		PressureRising:    pressureRising,
 		PressureSteady:    pressureSteady,
 		PressureFalling:   pressureFalling,
 		PressureAboveNorm: pressureAboveNorm,
 		PressureBelowNorm: !pressureAboveNorm,
-		PressureDeltaInt:  pressureDeltaInt,
-		PressureDeltaFrac: formatFrac(math.Abs(pressureDelta)),
+		PressureDelta:     strconv.FormatFloat(math.Abs(pressureDelta), 'f', e.pressurePrecision(), 64),
+		PressureSparkline: e.getSparkline("pressure", pressureSensorName, e.units.Pressure),
+		TendencyCode:      tendencyCode,
+		Tendency:          e.formatPressureChange(change) + " " + e.units.PressureUnit() + ", " + e.translator.Text("tendency_"+strconv.Itoa(tendencyCode)),
+		Forecast:          forecast,
 		WarningPng:        images.Warning_png_src,
 		RisingPng:         images.Rising_png_src,
 		FallingPng:        images.Falling_png_src,
@@ -121,14 +165,94 @@ func (e *environmentDataProvider) GetPressure() (*PressureData, error) {
 	}, nil
 }
 
-func NewEnvironmentDataProvider(config config.ConfigApi, haApi ha.HomeAssistantApi) EnvironmentDataProvider {
-	return &environmentDataProvider{config, haApi}
+func NewEnvironmentDataProvider(
+	config config.ConfigApi,
+	haApi ha.HomeAssistantApi,
+	units units.Units,
+	translator i18n.Translator,
+) (EnvironmentDataProvider, error) {
+	for _, value := range []string{"temperature", "humidity", "pressure"} {
+		err := validateSparkline(value, config.GetSparkline(value))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &environmentDataProvider{config, haApi, units, translator, make(map[string]*cachedSparkline)}, nil
+}
+
+func (e *environmentDataProvider) GetVentilationAdvice(inside, outside *TemperatureHumidityData) string {
+	key := ventilationAdvice(inside, outside)
+	if key == "" {
+		return ""
+	}
+	return e.translator.Text(key)
+}
+
+// getWindDirection returns the wind direction in degrees, NaN if the sensor is not configured or not available
+func (e *environmentDataProvider) getWindDirection() float64 {
+	return e.getOptionalValue("wind direction", e.config.GetWindDirectionSensorName())
+}
+
+// getWindSpeed returns the wind speed in m/s, NaN if the sensor is not configured or not available
+func (e *environmentDataProvider) getWindSpeed() float64 {
+	return e.getOptionalValue("wind speed", e.config.GetWindSpeedSensorName())
+}
+
+// getOptionalValue returns the value of the optional sensor, NaN if it is not configured or not available
+func (e *environmentDataProvider) getOptionalValue(what, sensorName string) float64 {
+	if sensorName == "" {
+		return math.NaN()
+	}
+	value, err := e.haApi.DownloadSensorValueFromHA(sensorName)
+	if err != nil {
+		println(eris.ToString(eris.Wrapf(err, "Error loading %s", what), true))
+		return math.NaN()
+	}
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		// unavailable or unknown
+		return math.NaN()
+	}
+	return res
+}
+
+// feelsLike returns the apparent temperature in °C, humidex is the usual measure for metric units
+// and the heat index for imperial ones
+func (e *environmentDataProvider) feelsLike(celsius, humidity, windSpeed float64) float64 {
+	if chill := windChill(celsius, windSpeed); !math.IsNaN(chill) {
+		return chill
+	}
+	if celsius < heatIndexFromCelsius {
+		return celsius
+	}
+	if e.units.TemperatureUnit() == "°F" {
+		return heatIndex(celsius, humidity)
+	}
+	return humidex(celsius, humidity)
+}
+
+// pressurePrecision is the number of decimals of the pressure changes, a tenth of an inch is too coarse
+func (e *environmentDataProvider) pressurePrecision() int {
+	if e.units.PressureUnit() == "inHg" {
+		return 2
+	}
+	return 1
+}
+
+// formatPressureChange formats the change with the sign and the precision suitable for the configured units
+func (e *environmentDataProvider) formatPressureChange(changeHPa float64) string {
+	res := strconv.FormatFloat(e.units.Pressure(changeHPa), 'f', e.pressurePrecision(), 64)
+	if changeHPa >= 0 {
+		res = "+" + res
+	}
+	return res
 }
 
-const hPaToMmHgCoeff = 1.33
-const normalPressureMmHg = 760.0
+// approximately 1 mmHg per hour
+const pressureTrendThresholdHPa = 1.33
 
-func (e *environmentDataProvider) getTemperatureHumidity(title, temperatureSensorName, humiditySensorName string) (*TemperatureHumidityData, error) {
+// getTemperatureHumidity reads the pair of sensors, the wind speed in m/s is NaN if unknown
+func (e *environmentDataProvider) getTemperatureHumidity(title, temperatureSensorName, humiditySensorName string, windSpeed float64) (*TemperatureHumidityData, error) {
 	temp, err := e.haApi.DownloadSensorValueFromHA(temperatureSensorName)
 	if err != nil {
 		return nil, err
@@ -171,11 +295,14 @@ func (e *environmentDataProvider) getTemperatureHumidity(title, temperatureSenso
 	humidityFalling := humiditySlope <= -0.3
 	humiditySteady := !(humidityRising || humidityFalling)
 	hundredPercentHumidity := humidityVal > 99.9
+	displayTemp := e.units.Temperature(tempVal)
+	absHumidity := absoluteHumidity(tempVal, humidityVal)
 	return &TemperatureHumidityData{
 		Title:                  title,
 		Warning:                false,
-		TemperatureInt:         formatInt(tempVal),
-		TemperatureFrac:        formatFrac(tempVal),
+		TemperatureInt:         formatInt(displayTemp),
+		TemperatureFrac:        formatFrac(displayTemp),
+		TemperatureUnit:        e.units.TemperatureUnit(),
 		TemperatureRising:      tempRising,
 		TemperatureFalling:     tempFalling,
 		TemperatureSteady:      tempSteady,
@@ -185,6 +312,15 @@ func (e *environmentDataProvider) getTemperatureHumidity(title, temperatureSenso
 		HumidityFalling:        humidityFalling,
 		HumiditySteady:         humiditySteady,
 		HundredPercentHumidity: hundredPercentHumidity,
+		TemperatureSparkline:   e.getSparkline("temperature", temperatureSensorName, e.units.Temperature),
+		HumiditySparkline:      e.getSparkline("humidity", humiditySensorName, func(v float64) float64 { return v }),
+		DewPointLabel:          e.translator.Text("dew_point"),
+		DewPoint:               e.units.FormatTemperature(dewPoint(tempVal, humidityVal)) + e.units.TemperatureUnit(),
+		FeelsLikeLabel:         e.translator.Text("feels_like"),
+		FeelsLike:              e.units.FormatTemperature(e.feelsLike(tempVal, humidityVal, windSpeed)) + e.units.TemperatureUnit(),
+		AbsoluteHumidity:       strconv.FormatFloat(absHumidity, 'f', 1, 64),
+		Humidity:               humidityVal,
+		AbsoluteHumidityValue:  absHumidity,
 		WarningPng:             images.Warning_png_src,
 		ThermometerPng:         images.Thermometer_png_src,
 		RisingPng:              images.Rising_png_src,
@@ -196,7 +332,7 @@ func (e *environmentDataProvider) getTemperatureHumidity(title, temperatureSenso
 
 func formatInt(val float64) string {
 	truncated := int(math.Trunc(val))
-	if truncated <= -10 || truncated >= 100 {
+	if truncated <= -100 || truncated >= 1000 {
 		return ".."
 	}
 	if truncated < 0 {
@@ -0,0 +1,164 @@
+package environment
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"github.com/rotisserie/eris"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var sparklineWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// sizes of the graph in pixels, the labels of the extremes take the right part of it
+const (
+	sparklineWidth      = 290
+	sparklineHeight     = 56
+	sparklineLabelWidth = 70
+	sparklinePadding    = 5
+)
+
+// the history is downloaded again after this share of the window, every 15 minutes for a day
+const sparklineRefreshesPerWindow = 96
+
+type cachedSparkline struct {
+	svg       string
+	fetchedAt time.Time
+}
+
+func validateSparkline(value string, settings *config.SparklineSettings) error {
+	if settings == nil {
+		return nil
+	}
+	if _, exists := sparklineWindows[settings.Window]; !exists {
+		return eris.Errorf("unknown %s graph window '%s', expected '24h' or '7d'", value, settings.Window)
+	}
+	switch settings.Scale {
+	case "", "auto":
+		return nil
+	case "fixed":
+		if settings.Min >= settings.Max {
+			return eris.Errorf("%s graph min %v must be less than max %v", value, settings.Min, settings.Max)
+		}
+		return nil
+	}
+	return eris.Errorf("unknown %s graph scale '%s', expected 'auto' or 'fixed'", value, settings.Scale)
+}
+
+// getSparkline returns the history of the sensor as an inline SVG, empty if the graph of the value is not configured,
+// the last graph is kept if the history can't be downloaded
+func (e *environmentDataProvider) getSparkline(value, sensorName string, convert func(float64) float64) string {
+	settings := e.config.GetSparkline(value)
+	if settings == nil {
+		return ""
+	}
+	window := sparklineWindows[settings.Window]
+	now := time.Now()
+	cached, exists := e.sparklines[sensorName]
+	if exists && now.Sub(cached.fetchedAt) < window/sparklineRefreshesPerWindow {
+		return cached.svg
+	}
+	history, err := e.haApi.DownloadSensorHistoryFromHA(sensorName, now.Add(-window), now, false)
+	var series []*ha.NumericHistoryValue
+	if err == nil {
+		series, err = convertToNumericSeries(history)
+	}
+	if err != nil {
+		println(eris.ToString(eris.Wrapf(err, "Error loading history of %s", sensorName), true))
+		if exists {
+			return cached.svg
+		}
+		return ""
+	}
+	e.sparklines[sensorName] = &cachedSparkline{
+		svg:       sparklineSvg(series, now.Add(-window), now, settings, convert),
+		fetchedAt: now,
+	}
+	return e.sparklines[sensorName].svg
+}
+
+// bucketValues averages the series over the buckets of equal time, a bucket without samples holds the previous value
+// as sensors only report changes, buckets before the first sample are NaN
+func bucketValues(series []*ha.NumericHistoryValue, from, to time.Time, buckets int, convert func(float64) float64) []float64 {
+	sums := make([]float64, buckets)
+	counts := make([]int, buckets)
+	bucketLength := to.Sub(from) / time.Duration(buckets)
+	for _, v := range series {
+		i := int(v.Timestamp.Sub(from) / bucketLength)
+		// the first sample is the state at the start of the window, it may have been reported earlier
+		i = max(0, min(i, buckets-1))
+		sums[i] += convert(v.Value)
+		counts[i]++
+	}
+	res := make([]float64, buckets)
+	previous := math.NaN()
+	for i := range res {
+		if counts[i] > 0 {
+			previous = sums[i] / float64(counts[i])
+		}
+		res[i] = previous
+	}
+	return res
+}
+
+// sparklineSvg draws the line of the values with the minimum and the maximum marked, black only to stay sharp
+// in the black and white refresh mode
+func sparklineSvg(series []*ha.NumericHistoryValue, from, to time.Time, settings *config.SparklineSettings, convert func(float64) float64) string {
+	plotWidth := sparklineWidth - sparklineLabelWidth
+	values := bucketValues(series, from, to, plotWidth/2, convert)
+	minIndex, maxIndex := -1, -1
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if minIndex < 0 || v < values[minIndex] {
+			minIndex = i
+		}
+		if maxIndex < 0 || v > values[maxIndex] {
+			maxIndex = i
+		}
+	}
+	if minIndex < 0 {
+		return ""
+	}
+	low, high := values[minIndex], values[maxIndex]
+	if settings.Scale == "fixed" {
+		low, high = settings.Min, settings.Max
+	} else if high-low < 1 {
+		// flat lines are drawn in the middle instead of jumping between the edges on tiny changes
+		middle := (high + low) / 2
+		low, high = middle-0.5, middle+0.5
+	}
+	x := func(i int) float64 {
+		return float64(i*plotWidth) / float64(len(values)-1)
+	}
+	y := func(v float64) float64 {
+		v = math.Max(low, math.Min(high, v))
+		return sparklinePadding + (high-v)/(high-low)*(sparklineHeight-2*sparklinePadding)
+	}
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	sb := strings.Builder{}
+	sb.WriteString(`<svg width="` + strconv.Itoa(sparklineWidth) + `" height="` + strconv.Itoa(sparklineHeight) + `">`)
+	sb.WriteString(`<polyline fill="none" stroke="#000" stroke-width="3" stroke-linejoin="round" points="`)
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			sb.WriteString(format(x(i)) + "," + format(y(v)) + " ")
+		}
+	}
+	sb.WriteString(`"/>`)
+	for _, i := range []int{minIndex, maxIndex} {
+		sb.WriteString(`<circle r="5" fill="#000" cx="` + format(x(i)) + `" cy="` + format(y(values[i])) + `"/>`)
+	}
+	labelX := strconv.Itoa(plotWidth + 10)
+	sb.WriteString(`<text font-size="20" font-family="cartograph" x="` + labelX + `" y="20">` + format(values[maxIndex]) + `</text>`)
+	sb.WriteString(`<text font-size="20" font-family="cartograph" x="` + labelX + `" y="` + strconv.Itoa(sparklineHeight-4) + `">` + format(values[minIndex]) + `</text>`)
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package environment
+
+import (
+	"fkirill.org/eink-meteo-station/data/ha"
+	"math"
+	"time"
+)
+
+// pressure tendency is reported over 3 hours, the characteristic compares both halves of the period
+const tendencyPeriod = 3 * time.Hour
+
+// changes below this many hPa per half of the period are considered steady
+const tendencySteadyHPa = 0.2
+
+// WMO code table 0200, characteristic of the pressure tendency
+const (
+	TendencyRisingThenFalling = iota // pressure is the same or higher than 3 hours ago
+	TendencyRisingThenSteady         // or rising then rising more slowly, higher
+	TendencyRising                   // steadily or unsteadily, higher
+	TendencySteadyThenRising         // or falling then rising, or rising then rising more quickly, higher
+	TendencySteady                   // the same as 3 hours ago
+	TendencyFallingThenRising        // the same or lower than 3 hours ago
+	TendencyFallingThenSteady        // or falling then falling more slowly, lower
+	TendencyFalling                  // steadily or unsteadily, lower
+	TendencySteadyThenFalling        // or rising then falling, or falling then falling more quickly, lower
+)
+
+// valueAt returns the last value reported at or before the moment, the first value if all of them are later
+func valueAt(series []*ha.NumericHistoryValue, moment time.Time) float64 {
+	res := series[0].Value
+	for _, v := range series {
+		if v.Timestamp.After(moment) {
+			break
+		}
+		res = v.Value
+	}
+	return res
+}
+
+// pressureTendency returns the WMO characteristic of the pressure tendency and the change in hPa over the period
+// ending at the given moment, the series must be in chronological order and non-empty
+func pressureTendency(series []*ha.NumericHistoryValue, now time.Time) (int, float64) {
+	start := valueAt(series, now.Add(-tendencyPeriod))
+	middle := valueAt(series, now.Add(-tendencyPeriod/2))
+	end := valueAt(series, now)
+	change := end - start
+	first, second := middle-start, end-middle
+	rising := func(d float64) bool { return d >= tendencySteadyHPa }
+	falling := func(d float64) bool { return d <= -tendencySteadyHPa }
+	switch {
+	case math.Abs(change) < tendencySteadyHPa:
+		switch {
+		case rising(first) && falling(second):
+			return TendencyRisingThenFalling, change
+		case falling(first) && rising(second):
+			return TendencyFallingThenRising, change
+		}
+		return TendencySteady, change
+	case change > 0:
+		switch {
+		case falling(second):
+			return TendencyRisingThenFalling, change
+		case !rising(first):
+			return TendencySteadyThenRising, change
+		case !rising(second) || second < first/2:
+			return TendencyRisingThenSteady, change
+		case second > first*2:
+			return TendencySteadyThenRising, change
+		}
+		return TendencyRising, change
+	}
+	switch {
+	case rising(second):
+		return TendencyFallingThenRising, change
+	case !falling(first):
+		return TendencySteadyThenFalling, change
+	case !falling(second) || second > first/2:
+		return TendencyFallingThenSteady, change
+	case second < first*2:
+		return TendencySteadyThenFalling, change
+	}
+	return TendencyFalling, change
+}
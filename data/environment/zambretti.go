@@ -0,0 +1,70 @@
+package environment
+
+import (
+	"math"
+	"time"
+)
+
+// Zambretti forecaster after the Negretti & Zambra pocket calculator, the tables and the adjustments
+// follow the well known beteljuice.com JavaScript version of it
+
+// the calculator covers the sea level pressure from 950 to 1050 hPa split into 22 bands
+const (
+	zambrettiBottomHPa = 950.0
+	zambrettiTopHPa    = 1050.0
+	zambrettiBands     = 22
+)
+
+// the change over 3 hours that counts as rising or falling
+const zambrettiTrendHPa = 1.6
+
+// forecast letters from A (settled fine) to Z (stormy, much rain) for every pressure band from the lowest one
+var (
+	zambrettiRising  = []byte("ZZZYYTQMLJIGFCBBAAAAAA")
+	zambrettiSteady  = []byte("ZZZZZZXXWSPNKEBBAAAAAA")
+	zambrettiFalling = []byte("ZZZZZZZZXXVUROHDBBBAAA")
+)
+
+// share of the pressure range added for the wind from N, NNE, NE and so on clockwise in the northern hemisphere
+var zambrettiWindAdjustment = []float64{6, 5, 5, 2, -0.5, -2, -5, -8.5, -12, -10, -6, -4.5, -3, -0.5, 1.5, 3}
+
+// zambrettiForecast returns the message key of the forecast, e.g. "zambretti_a", and whether the pressure
+// is outside the range of the calculator. The wind direction is in degrees, NaN if unknown.
+func zambrettiForecast(pressureHPa, change3hHPa, windDegrees, latitude float64, month time.Month) (string, bool) {
+	pressureRange := zambrettiTopHPa - zambrettiBottomHPa
+	southern := latitude < 0
+	if !math.IsNaN(windDegrees) {
+		point := int(math.Floor(windDegrees/22.5+0.5)) % 16
+		if point < 0 {
+			point += 16
+		}
+		// the southern hemisphere winds are mirrored, southerly winds bring the fine weather there
+		if southern {
+			point = (point + 8) % 16
+		}
+		pressureHPa += zambrettiWindAdjustment[point] / 100 * pressureRange
+	}
+	summer := month >= time.April && month <= time.September
+	if southern {
+		summer = !summer
+	}
+	rising := change3hHPa >= zambrettiTrendHPa
+	falling := change3hHPa <= -zambrettiTrendHPa
+	if summer {
+		if rising {
+			pressureHPa += 0.07 * pressureRange
+		} else if falling {
+			pressureHPa -= 0.07 * pressureRange
+		}
+	}
+	band := int(math.Floor((pressureHPa - zambrettiBottomHPa) / (pressureRange / zambrettiBands)))
+	exceptional := band < 0 || band >= zambrettiBands
+	band = max(0, min(band, zambrettiBands-1))
+	letters := zambrettiSteady
+	if rising {
+		letters = zambrettiRising
+	} else if falling {
+		letters = zambrettiFalling
+	}
+	return "zambretti_" + string(letters[band]-'A'+'a'), exceptional
+}
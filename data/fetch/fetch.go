@@ -0,0 +1,57 @@
+package fetch
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"github.com/rotisserie/eris"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// ReadSource reads a http(s):// or file:// URL or a file path, relative paths are resolved against the root dir
+func ReadSource(source string) ([]byte, error) {
+	return ReadSourceWithTimeout(source, requestTimeout)
+}
+
+// ReadSourceWithTimeout is ReadSource giving up on a URL after the timeout
+func ReadSourceWithTimeout(source string, timeout time.Duration) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return readUrl(source, timeout)
+	}
+	fileName := strings.TrimPrefix(source, "file://")
+	if !path.IsAbs(fileName) {
+		fileName = path.Join(config.GetRootDir(), fileName)
+	}
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error reading file '%s'", fileName)
+	}
+	return content, nil
+}
+
+func readUrl(url string, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error fetching '%s'", url)
+	}
+	defer func() {
+		closeErr := response.Body.Close()
+		if closeErr != nil {
+			println(eris.ToString(eris.Wrap(closeErr, "Error closing web request body"), true))
+		}
+	}()
+	if response.StatusCode != http.StatusOK {
+		return nil, eris.Errorf("error fetching '%s': %s", url, response.Status)
+	}
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error reading response from '%s'", url)
+	}
+	return content, nil
+}
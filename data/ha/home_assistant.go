@@ -15,6 +15,14 @@ type HomeAssistantHistoryItem struct {
 	Value     string    `json:"state"`
 }
 
+// HomeAssistantState is the state of an entity with its attributes such as the friendly name and the unit
+type HomeAssistantState struct {
+	EntityId    string         `json:"entity_id"`
+	State       string         `json:"state"`
+	Attributes  map[string]any `json:"attributes"`
+	LastChanged time.Time      `json:"last_changed"`
+}
+
 type NumericHistoryValue struct {
 	Timestamp time.Time
 	Value     float64
@@ -23,6 +31,8 @@ type NumericHistoryValue struct {
 type HomeAssistantApi interface {
 	DownloadSensorValueFromHA(sensorId string) (string, error)
 	DownloadSensorHistoryFromHA(sensorId string, startTime, endTime time.Time, significantOnly bool) ([]*HomeAssistantHistoryItem, error)
+	// DownloadStatesFromHA returns the states of all the entities in one call mapped by the entity id
+	DownloadStatesFromHA() (map[string]*HomeAssistantState, error)
 }
 
 type homeAssistantApi struct {
@@ -80,7 +90,7 @@ func (h homeAssistantApi) DownloadSensorHistoryFromHA(sensorId string, startTime
 	}
 	startDateTimeStr := startTime.UTC().Format(time.RFC3339)
 	endDateTimeStr := endTime.UTC().Format(time.RFC3339)
-	url := fmt.Sprintf("%s/api/history/period/%s?filter_entity_id=%s&end_time%s&minimal_response%s", h.getHAProtocolHostPort(), startDateTimeStr, sensorId, endDateTimeStr, significantOnlyStr)
+	url := fmt.Sprintf("%s/api/history/period/%s?filter_entity_id=%s&end_time=%s&minimal_response%s", h.getHAProtocolHostPort(), startDateTimeStr, sensorId, endDateTimeStr, significantOnlyStr)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -117,6 +127,39 @@ func (h homeAssistantApi) DownloadSensorHistoryFromHA(sensorId string, startTime
 	return res[0], nil
 }
 
+func (h homeAssistantApi) DownloadStatesFromHA() (map[string]*HomeAssistantState, error) {
+	client := &http.Client{}
+	url := fmt.Sprintf("%s/api/states", h.getHAProtocolHostPort())
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", h.getBearerToken())
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HA states request failed with status %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var states []*HomeAssistantState
+	err = json.Unmarshal(body, &states)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*HomeAssistantState, len(states))
+	for _, state := range states {
+		res[state.EntityId] = state
+	}
+	return res, nil
+}
+
 func NewHomeAssistantApi(config config.ConfigApi) HomeAssistantApi {
 	return &homeAssistantApi{config}
 }
@@ -0,0 +1,213 @@
+package holidays
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/i18n"
+	"sort"
+	"time"
+)
+
+type ruleKind int
+
+const (
+	fixedDate ruleKind = iota
+	nthWeekday
+	easterRelative
+)
+
+type substitution int
+
+const (
+	// the holiday is not moved when it falls on a weekend
+	noSubstitute substitution = iota
+	// a weekend holiday is observed on the next weekday which is not a holiday already
+	nextWeekday
+	// a Saturday holiday is observed on Friday and a Sunday one on Monday
+	nearestWeekday
+)
+
+// Pack is a versioned set of public holiday rules of a country or a region. The version changes whenever
+// the rules are corrected, so that the web UI shows which data set is in use.
+type Pack struct {
+	Code    string
+	Name    string
+	Version string
+	Rules   []*Rule
+}
+
+// Rule generates a single public holiday for any year
+type Rule struct {
+	Id               string
+	Names            map[string]string // by language, "en" is mandatory
+	kind             ruleKind
+	month            time.Month
+	day              int // day of month for fixed dates
+	weekday          time.Weekday
+	n                int // nth weekday of the month, -1 for the last one
+	easterOffsetDays int
+	durationDays     int // 0 is the same as 1
+	substitute       substitution
+	fromYear         int // first year the rule applies to, 0 for no limit
+	toYear           int // last year the rule applies to, 0 for no limit
+}
+
+// Name returns the holiday name in the given language, English if there is no translation
+func (r *Rule) Name(language string) string {
+	if name, exists := r.Names[language]; exists {
+		return name
+	}
+	return r.Names["en"]
+}
+
+func (r *Rule) appliesTo(year int) bool {
+	return (r.fromYear == 0 || year >= r.fromYear) && (r.toYear == 0 || year <= r.toYear)
+}
+
+func (r *Rule) date(year int) (time.Time, bool) {
+	switch r.kind {
+	case fixedDate:
+		res := date(year, r.month, r.day)
+		return res, res.Day() == r.day
+	case nthWeekday:
+		return NthWeekday(year, r.month, r.weekday, r.n)
+	case easterRelative:
+		return EasterSunday(year).AddDate(0, 0, r.easterOffsetDays), true
+	}
+	return time.Time{}, false
+}
+
+// FullId is the id of the generated special days, used to hide a holiday or to override it with a special day
+// of the same id
+func FullId(pack *Pack, rule *Rule) string {
+	return pack.Code + "/" + rule.Id
+}
+
+// FindPack returns the pack with the given code or nil if there is none
+func FindPack(code string) *Pack {
+	for _, pack := range Packs {
+		if pack.Code == code {
+			return pack
+		}
+	}
+	return nil
+}
+
+type holidayDate struct {
+	rule *Rule
+	date time.Time
+}
+
+// Generate returns public holidays of the pack for the given year as once off special days, a weekend holiday
+// which is observed on another day also gets a special day for the observed date.
+func Generate(pack *Pack, year int, translator i18n.Translator) []*config.SpecialDayOrInterval {
+	language := translator.Language()
+	// holidays are listed in the pack order, so that the pack decides which one is shown on days covered by several
+	res := make([]*config.SpecialDayOrInterval, 0, len(pack.Rules))
+	dates := make([]*holidayDate, 0, len(pack.Rules))
+	// weekdays taken by holidays which are not moved, substitute days must not land on them
+	taken := make(map[time.Time]bool)
+	for _, rule := range pack.Rules {
+		if !rule.appliesTo(year) {
+			continue
+		}
+		day, ok := rule.date(year)
+		if !ok {
+			continue
+		}
+		res = append(res, specialDay(pack, rule, rule.Name(language), day, rule.durationDays))
+		dates = append(dates, &holidayDate{rule: rule, date: day})
+		if !isWeekend(day) {
+			taken[day] = true
+		}
+	}
+	// substitute days are assigned in the date order, e.g. Christmas gets the first free weekday before Boxing Day
+	sort.SliceStable(dates, func(i, j int) bool { return dates[i].date.Before(dates[j].date) })
+	for _, hd := range dates {
+		if !isWeekend(hd.date) {
+			continue
+		}
+		var observedDate time.Time
+		switch hd.rule.substitute {
+		case nextWeekday:
+			observedDate = hd.date
+			for isWeekend(observedDate) || taken[observedDate] {
+				observedDate = observedDate.AddDate(0, 0, 1)
+			}
+		case nearestWeekday:
+			if hd.date.Weekday() == time.Saturday {
+				observedDate = hd.date.AddDate(0, 0, -1)
+			} else {
+				observedDate = hd.date.AddDate(0, 0, 1)
+			}
+		default:
+			continue
+		}
+		taken[observedDate] = true
+		res = append(res, specialDay(pack, hd.rule, hd.rule.Name(language)+" "+translator.Text("observed"), observedDate, 1))
+	}
+	return res
+}
+
+func specialDay(pack *Pack, rule *Rule, name string, day time.Time, durationDays int) *config.SpecialDayOrInterval {
+	res := &config.SpecialDayOrInterval{
+		Id:              FullId(pack, rule),
+		DisplayText:     name,
+		Type:            "once_off",
+		StartDateDay:    day.Day(),
+		StartDateMonth:  int(day.Month()),
+		StartDateYear:   day.Year(),
+		EndDateDay:      day.Day(),
+		EndDateMonth:    int(day.Month()),
+		EndDateYear:     day.Year(),
+		IsPublicHoliday: true,
+	}
+	if durationDays > 1 {
+		end := day.AddDate(0, 0, durationDays-1)
+		res.Type = "interval"
+		res.EndDateDay, res.EndDateMonth, res.EndDateYear = end.Day(), int(end.Month()), end.Year()
+	}
+	return res
+}
+
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// NthWeekday returns the n-th (1-based) weekday of the month, negative n counts from the end of the month (-1 is the last one)
+func NthWeekday(year int, month time.Month, weekday time.Weekday, n int) (time.Time, bool) {
+	if n > 0 {
+		first := date(year, month, 1)
+		day := first.AddDate(0, 0, int((weekday-first.Weekday()+7)%7)+7*(n-1))
+		return day, day.Month() == month
+	}
+	if n < 0 {
+		last := date(year, month+1, 0)
+		day := last.AddDate(0, 0, -int((last.Weekday()-weekday+7)%7)+7*(n+1))
+		return day, day.Month() == month
+	}
+	return time.Time{}, false
+}
+
+// EasterSunday returns the date of the (Western) Easter Sunday using the anonymous Gregorian algorithm
+// https://en.wikipedia.org/wiki/Date_of_Easter#Anonymous_Gregorian_algorithm
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
@@ -0,0 +1,243 @@
+package holidays
+
+import (
+	"fkirill.org/eink-meteo-station/i18n"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type translator struct {
+	i18n.Translator
+	language string
+}
+
+func (t *translator) Language() string {
+	return t.language
+}
+
+func (t *translator) Text(key string) string {
+	return "(" + key + ")"
+}
+
+// generated formats the special days of the pack as "YYYY-MM-DD rule id: display text", the intervals
+// with the end date after the start one
+func generated(pack *Pack, year int, language string) []string {
+	res := make([]string, 0)
+	for _, sd := range Generate(pack, year, &translator{language: language}) {
+		text := date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay).Format(time.DateOnly)
+		if sd.Type == "interval" {
+			text += "/" + date(sd.EndDateYear, time.Month(sd.EndDateMonth), sd.EndDateDay).Format(time.DateOnly)
+		}
+		res = append(res, text+" "+strings.TrimPrefix(sd.Id, pack.Code+"/")+": "+sd.DisplayText)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// the expected dates are the published ones of the governments
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		pack     string
+		year     int
+		language string
+		expected []string
+	}{
+		{
+			pack: "UK", year: 2021, language: "en",
+			expected: []string{
+				"2021-01-01 new_year: New Year's Day",
+				"2021-04-02 good_friday: Good Friday",
+				"2021-04-05 easter_monday: Easter Monday",
+				"2021-05-03 early_may: Early May bank holiday",
+				"2021-05-31 spring: Spring bank holiday",
+				"2021-08-30 summer: Summer bank holiday",
+				"2021-12-25 christmas: Christmas Day",
+				"2021-12-26 boxing_day: Boxing Day",
+				// both days fall on the weekend, Christmas takes the Monday and Boxing Day the Tuesday
+				"2021-12-27 christmas: Christmas Day (observed)",
+				"2021-12-28 boxing_day: Boxing Day (observed)",
+			},
+		},
+		{
+			pack: "UK", year: 2022, language: "en",
+			expected: []string{
+				"2022-01-01 new_year: New Year's Day",
+				"2022-01-03 new_year: New Year's Day (observed)",
+				"2022-04-15 good_friday: Good Friday",
+				"2022-04-18 easter_monday: Easter Monday",
+				"2022-05-02 early_may: Early May bank holiday",
+				// moved to the 2nd of June by a proclamation for the Platinum Jubilee, which the rules don't know
+				"2022-05-30 spring: Spring bank holiday",
+				"2022-08-29 summer: Summer bank holiday",
+				"2022-12-25 christmas: Christmas Day",
+				// Boxing Day is on the Monday already, Christmas is observed on the Tuesday
+				"2022-12-26 boxing_day: Boxing Day",
+				"2022-12-27 christmas: Christmas Day (observed)",
+			},
+		},
+		{
+			pack: "US", year: 2021, language: "en",
+			expected: []string{
+				"2021-01-01 new_year: New Year's Day",
+				"2021-01-18 mlk_day: Martin Luther King Jr. Day",
+				"2021-02-15 washingtons_birthday: Washington's Birthday",
+				"2021-05-31 memorial_day: Memorial Day",
+				"2021-06-18 juneteenth: Juneteenth (observed)",
+				"2021-06-19 juneteenth: Juneteenth",
+				"2021-07-04 independence_day: Independence Day",
+				"2021-07-05 independence_day: Independence Day (observed)",
+				"2021-09-06 labor_day: Labor Day",
+				"2021-10-11 columbus_day: Columbus Day",
+				"2021-11-11 veterans_day: Veterans Day",
+				"2021-11-25 thanksgiving: Thanksgiving Day",
+				"2021-12-24 christmas: Christmas Day (observed)",
+				"2021-12-25 christmas: Christmas Day",
+			},
+		},
+		{
+			pack: "US", year: 2022, language: "en",
+			expected: []string{
+				// New Year's Day on a Saturday is observed on the Friday of the year before
+				"2021-12-31 new_year: New Year's Day (observed)",
+				"2022-01-01 new_year: New Year's Day",
+				"2022-01-17 mlk_day: Martin Luther King Jr. Day",
+				"2022-02-21 washingtons_birthday: Washington's Birthday",
+				"2022-05-30 memorial_day: Memorial Day",
+				"2022-06-19 juneteenth: Juneteenth",
+				"2022-06-20 juneteenth: Juneteenth (observed)",
+				"2022-07-04 independence_day: Independence Day",
+				"2022-09-05 labor_day: Labor Day",
+				"2022-10-10 columbus_day: Columbus Day",
+				"2022-11-11 veterans_day: Veterans Day",
+				"2022-11-24 thanksgiving: Thanksgiving Day",
+				"2022-12-25 christmas: Christmas Day",
+				"2022-12-26 christmas: Christmas Day (observed)",
+			},
+		},
+		{
+			pack: "US", year: 2020, language: "en",
+			expected: []string{
+				"2020-01-01 new_year: New Year's Day",
+				"2020-01-20 mlk_day: Martin Luther King Jr. Day",
+				"2020-02-17 washingtons_birthday: Washington's Birthday",
+				"2020-05-25 memorial_day: Memorial Day",
+				// Juneteenth is a federal holiday since 2021
+				"2020-07-03 independence_day: Independence Day (observed)",
+				"2020-07-04 independence_day: Independence Day",
+				"2020-09-07 labor_day: Labor Day",
+				"2020-10-12 columbus_day: Columbus Day",
+				"2020-11-11 veterans_day: Veterans Day",
+				"2020-11-26 thanksgiving: Thanksgiving Day",
+				"2020-12-25 christmas: Christmas Day",
+			},
+		},
+		{
+			pack: "AU-NSW", year: 2021, language: "en",
+			expected: []string{
+				"2021-01-01 new_year: New Year's Day",
+				"2021-01-26 australia_day: Australia Day",
+				"2021-04-02 good_friday: Good Friday",
+				"2021-04-03 easter_saturday: Easter Saturday",
+				"2021-04-04 easter_sunday: Easter Sunday",
+				"2021-04-05 easter_monday: Easter Monday",
+				// no substitute for Anzac Day on a Sunday
+				"2021-04-25 anzac_day: Anzac Day",
+				"2021-06-14 queens_birthday: Queen's Birthday",
+				"2021-10-04 labour_day: Labour Day",
+				"2021-12-25 christmas: Christmas Day",
+				"2021-12-26 boxing_day: Boxing Day",
+				"2021-12-27 christmas: Christmas Day (observed)",
+				"2021-12-28 boxing_day: Boxing Day (observed)",
+			},
+		},
+		{
+			pack: "AU-NSW", year: 2025, language: "en",
+			expected: []string{
+				"2025-01-01 new_year: New Year's Day",
+				"2025-01-26 australia_day: Australia Day",
+				"2025-01-27 australia_day: Australia Day (observed)",
+				"2025-04-18 good_friday: Good Friday",
+				"2025-04-19 easter_saturday: Easter Saturday",
+				"2025-04-20 easter_sunday: Easter Sunday",
+				"2025-04-21 easter_monday: Easter Monday",
+				"2025-04-25 anzac_day: Anzac Day",
+				"2025-06-09 kings_birthday: King's Birthday",
+				"2025-10-06 labour_day: Labour Day",
+				"2025-12-25 christmas: Christmas Day",
+				"2025-12-26 boxing_day: Boxing Day",
+			},
+		},
+		{
+			pack: "RU", year: 2022, language: "ru",
+			expected: []string{
+				"2022-01-01/2022-01-08 new_year_holidays: Новогодние каникулы",
+				"2022-01-07 christmas: Рождество Христово",
+				"2022-02-23 defender_day: День защитника Отечества",
+				"2022-03-08 womens_day: Международный женский день",
+				"2022-05-01 labour_day: Праздник Весны и Труда",
+				"2022-05-02 labour_day: Праздник Весны и Труда (observed)",
+				"2022-05-09 victory_day: День Победы",
+				"2022-06-12 russia_day: День России",
+				"2022-06-13 russia_day: День России (observed)",
+				"2022-11-04 unity_day: День народного единства",
+			},
+		},
+		{
+			pack: "RU", year: 2023, language: "ru",
+			expected: []string{
+				"2023-01-01/2023-01-08 new_year_holidays: Новогодние каникулы",
+				"2023-01-07 christmas: Рождество Христово",
+				"2023-02-23 defender_day: День защитника Отечества",
+				"2023-03-08 womens_day: Международный женский день",
+				"2023-05-01 labour_day: Праздник Весны и Труда",
+				"2023-05-09 victory_day: День Победы",
+				"2023-06-12 russia_day: День России",
+				"2023-11-04 unity_day: День народного единства",
+				"2023-11-06 unity_day: День народного единства (observed)",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.pack+" "+strconv.Itoa(test.year), func(t *testing.T) {
+			pack := FindPack(test.pack)
+			if pack == nil {
+				t.Fatalf("no pack %s", test.pack)
+			}
+			got := generated(pack, test.year, test.language)
+			if !slices.Equal(got, test.expected) {
+				t.Errorf("got\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(test.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestNthWeekday(t *testing.T) {
+	tests := []struct {
+		year     int
+		month    time.Month
+		weekday  time.Weekday
+		n        int
+		expected string // empty if there is no such day
+	}{
+		{2024, time.May, time.Monday, -1, "2024-05-27"},
+		{2024, time.September, time.Monday, 1, "2024-09-02"},
+		{2024, time.November, time.Thursday, 4, "2024-11-28"},
+		{2024, time.February, time.Thursday, 5, "2024-02-29"},
+		{2023, time.February, time.Thursday, 5, ""},
+		{2024, time.March, time.Sunday, -2, "2024-03-24"},
+		{2024, time.March, time.Sunday, 0, ""},
+	}
+	for _, test := range tests {
+		day, ok := NthWeekday(test.year, test.month, test.weekday, test.n)
+		got := ""
+		if ok {
+			got = day.Format(time.DateOnly)
+		}
+		if got != test.expected {
+			t.Errorf("%d %s %d %s: got '%s', expected '%s'", test.year, test.month, test.n, test.weekday, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package holidays
+
+import "time"
+
+// Packs are the built-in holiday data sets, bump the version of a pack whenever its rules change
+var Packs = []*Pack{
+	{
+		Code:    "AU-NSW",
+		Name:    "Australia, New South Wales",
+		Version: "2024.1",
+		Rules: []*Rule{
+			fixed("new_year", time.January, 1, nextWeekday, names("New Year's Day", "Новый год")),
+			fixed("australia_day", time.January, 26, nextWeekday, names("Australia Day", "День Австралии")),
+			easter("good_friday", -2, names("Good Friday", "Страстная пятница")),
+			easter("easter_saturday", -1, names("Easter Saturday", "Великая суббота")),
+			easter("easter_sunday", 0, names("Easter Sunday", "Пасха")),
+			easter("easter_monday", 1, names("Easter Monday", "Пасхальный понедельник")),
+			// NSW doesn't add a substitute day when Anzac Day falls on a weekend
+			fixed("anzac_day", time.April, 25, noSubstitute, names("Anzac Day", "День АНЗАК")),
+			until(2022, nth("queens_birthday", time.June, time.Monday, 2, names("Queen's Birthday", "День рождения королевы"))),
+			since(2023, nth("kings_birthday", time.June, time.Monday, 2, names("King's Birthday", "День рождения короля"))),
+			nth("labour_day", time.October, time.Monday, 1, names("Labour Day", "День труда")),
+			fixed("christmas", time.December, 25, nextWeekday, names("Christmas Day", "Рождество")),
+			fixed("boxing_day", time.December, 26, nextWeekday, names("Boxing Day", "День подарков")),
+		},
+	},
+	{
+		Code:    "RU",
+		Name:    "Russia",
+		Version: "2024.1",
+		Rules: []*Rule{
+			// Christmas goes first so that it is shown instead of the New Year holidays on the 7th of January
+			fixed("christmas", time.January, 7, noSubstitute, names("Orthodox Christmas", "Рождество Христово")),
+			// days off of the New Year holidays falling on weekends are moved by an annual government decree,
+			// they can't be calculated and have to be added as special days
+			days(8, fixed("new_year_holidays", time.January, 1, noSubstitute, names("New Year holidays", "Новогодние каникулы"))),
+			fixed("defender_day", time.February, 23, nextWeekday, names("Defender of the Fatherland Day", "День защитника Отечества")),
+			fixed("womens_day", time.March, 8, nextWeekday, names("International Women's Day", "Международный женский день")),
+			fixed("labour_day", time.May, 1, nextWeekday, names("Spring and Labour Day", "Праздник Весны и Труда")),
+			fixed("victory_day", time.May, 9, nextWeekday, names("Victory Day", "День Победы")),
+			fixed("russia_day", time.June, 12, nextWeekday, names("Russia Day", "День России")),
+			fixed("unity_day", time.November, 4, nextWeekday, names("Unity Day", "День народного единства")),
+		},
+	},
+	{
+		Code:    "UK",
+		Name:    "United Kingdom, England and Wales",
+		Version: "2024.1",
+		Rules: []*Rule{
+			fixed("new_year", time.January, 1, nextWeekday, names("New Year's Day", "Новый год")),
+			easter("good_friday", -2, names("Good Friday", "Страстная пятница")),
+			easter("easter_monday", 1, names("Easter Monday", "Пасхальный понедельник")),
+			nth("early_may", time.May, time.Monday, 1, names("Early May bank holiday", "Майский банковский выходной")),
+			nth("spring", time.May, time.Monday, -1, names("Spring bank holiday", "Весенний банковский выходной")),
+			nth("summer", time.August, time.Monday, -1, names("Summer bank holiday", "Летний банковский выходной")),
+			fixed("christmas", time.December, 25, nextWeekday, names("Christmas Day", "Рождество")),
+			fixed("boxing_day", time.December, 26, nextWeekday, names("Boxing Day", "День подарков")),
+		},
+	},
+	{
+		Code:    "US",
+		Name:    "United States, federal holidays",
+		Version: "2024.1",
+		Rules: []*Rule{
+			fixed("new_year", time.January, 1, nearestWeekday, names("New Year's Day", "Новый год")),
+			nth("mlk_day", time.January, time.Monday, 3, names("Martin Luther King Jr. Day", "День Мартина Лютера Кинга")),
+			nth("washingtons_birthday", time.February, time.Monday, 3, names("Washington's Birthday", "День рождения Вашингтона")),
+			nth("memorial_day", time.May, time.Monday, -1, names("Memorial Day", "День памяти")),
+			since(2021, fixed("juneteenth", time.June, 19, nearestWeekday, names("Juneteenth", "Джунтинс"))),
+			fixed("independence_day", time.July, 4, nearestWeekday, names("Independence Day", "День независимости")),
+			nth("labor_day", time.September, time.Monday, 1, names("Labor Day", "День труда")),
+			nth("columbus_day", time.October, time.Monday, 2, names("Columbus Day", "День Колумба")),
+			fixed("veterans_day", time.November, 11, nearestWeekday, names("Veterans Day", "День ветеранов")),
+			nth("thanksgiving", time.November, time.Thursday, 4, names("Thanksgiving Day", "День благодарения")),
+			fixed("christmas", time.December, 25, nearestWeekday, names("Christmas Day", "Рождество")),
+		},
+	},
+}
+
+func names(en string, ru string) map[string]string {
+	return map[string]string{"en": en, "ru": ru}
+}
+
+func fixed(id string, month time.Month, day int, substitute substitution, names map[string]string) *Rule {
+	return &Rule{Id: id, Names: names, kind: fixedDate, month: month, day: day, substitute: substitute}
+}
+
+func nth(id string, month time.Month, weekday time.Weekday, n int, names map[string]string) *Rule {
+	return &Rule{Id: id, Names: names, kind: nthWeekday, month: month, weekday: weekday, n: n}
+}
+
+func easter(id string, offsetDays int, names map[string]string) *Rule {
+	return &Rule{Id: id, Names: names, kind: easterRelative, easterOffsetDays: offsetDays}
+}
+
+func days(durationDays int, rule *Rule) *Rule {
+	rule.durationDays = durationDays
+	return rule
+}
+
+func since(year int, rule *Rule) *Rule {
+	rule.fromYear = year
+	return rule
+}
+
+func until(year int, rule *Rule) *Rule {
+	rule.toYear = year
+	return rule
+}
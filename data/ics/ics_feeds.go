@@ -0,0 +1,130 @@
+package ics
+
+import (
+	"bytes"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/fetch"
+	"github.com/rotisserie/eris"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRefreshMinutes = 60
+
+// checkInterval is how often the background refresh looks for the feeds due, the feeds added or changed
+// on the web page are loaded within it
+const checkInterval = time.Minute
+
+// IcsFeedsProvider keeps special days from the configured iCalendar feeds. The feeds are re-read in the background
+// once their refresh interval has passed, feeds which fail to load keep their last known events.
+type IcsFeedsProvider interface {
+	// GetSpecialDays returns the events of the last refresh without waiting for the feeds
+	GetSpecialDays() []*config.SpecialDayOrInterval
+	// Version changes every time the events change so that the widgets showing them can redraw
+	Version() uint64
+}
+
+type feedState struct {
+	source      string
+	specialDays []*config.SpecialDayOrInterval
+	nextRefresh time.Time
+}
+
+type icsFeedsProvider struct {
+	cfg      config.ConfigApi
+	feeds    map[string]*feedState // only used by the refresh goroutine
+	lock     sync.Mutex
+	snapshot []*config.SpecialDayOrInterval
+	version  atomic.Uint64
+}
+
+func (p *icsFeedsProvider) GetSpecialDays() []*config.SpecialDayOrInterval {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.snapshot
+}
+
+func (p *icsFeedsProvider) Version() uint64 {
+	return p.version.Load()
+}
+
+func (p *icsFeedsProvider) run() {
+	ticker := time.NewTicker(checkInterval)
+	for {
+		p.refresh(time.Now())
+		<-ticker.C
+	}
+}
+
+// refresh loads the feeds whose refresh interval has passed, the snapshot is only replaced if the events changed
+func (p *icsFeedsProvider) refresh(now time.Time) {
+	changed := false
+	configured := make(map[string]bool)
+	res := make([]*config.SpecialDayOrInterval, 0)
+	for _, feed := range p.cfg.GetIcsFeeds() {
+		configured[feed.Id] = true
+		state, exists := p.feeds[feed.Id]
+		if !exists || state.source != feed.Source {
+			state = &feedState{source: feed.Source}
+			p.feeds[feed.Id] = state
+			changed = true
+		}
+		if !now.Before(state.nextRefresh) {
+			refreshMinutes := feed.RefreshMinutes
+			if refreshMinutes <= 0 {
+				refreshMinutes = defaultRefreshMinutes
+			}
+			state.nextRefresh = now.Add(time.Duration(refreshMinutes) * time.Minute)
+			specialDays, err := loadFeed(feed)
+			if err != nil {
+				println(eris.ToString(eris.Wrapf(err, "Error loading iCalendar feed '%s'", feed.Id), true))
+			} else if !reflect.DeepEqual(specialDays, state.specialDays) {
+				state.specialDays = specialDays
+				changed = true
+			}
+		}
+		res = append(res, state.specialDays...)
+	}
+	for id := range p.feeds {
+		if !configured[id] {
+			delete(p.feeds, id)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	p.lock.Lock()
+	p.snapshot = res
+	p.lock.Unlock()
+	p.version.Add(1)
+}
+
+func loadFeed(feed *config.IcsFeed) ([]*config.SpecialDayOrInterval, error) {
+	content, err := fetch.ReadSource(feed.Source)
+	if err != nil {
+		return nil, err
+	}
+	events, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	return ToSpecialDays(events, feed)
+}
+
+func newIcsFeedsProvider(cfg config.ConfigApi) *icsFeedsProvider {
+	return &icsFeedsProvider{
+		cfg:      cfg,
+		feeds:    make(map[string]*feedState),
+		snapshot: make([]*config.SpecialDayOrInterval, 0),
+	}
+}
+
+// NewIcsFeedsProvider starts the background refresh, the first one loads all the feeds
+func NewIcsFeedsProvider(cfg config.ConfigApi) IcsFeedsProvider {
+	res := newIcsFeedsProvider(cfg)
+	go res.run()
+	return res
+}
@@ -0,0 +1,86 @@
+package ics
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type feedsConfig struct {
+	config.ConfigApi
+	feeds []*config.IcsFeed
+}
+
+func (c *feedsConfig) GetIcsFeeds() []*config.IcsFeed {
+	return c.feeds
+}
+
+func TestFeedRefresh(t *testing.T) {
+	content, err := os.ReadFile("testdata/school.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	cfg := &feedsConfig{feeds: []*config.IcsFeed{{Id: "school", Source: server.URL + "/term.ics", RefreshMinutes: 60, IsSchoolHoliday: true}}}
+	p := newIcsFeedsProvider(cfg)
+	start := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	check := func(step string, expectedRequests int32, expectedVersion uint64, expectedDays int) {
+		t.Helper()
+		if requests.Load() != expectedRequests {
+			t.Errorf("%s: got %d requests, expected %d", step, requests.Load(), expectedRequests)
+		}
+		if p.Version() != expectedVersion {
+			t.Errorf("%s: got version %d, expected %d", step, p.Version(), expectedVersion)
+		}
+		if len(p.GetSpecialDays()) != expectedDays {
+			t.Errorf("%s: got %d special days, expected %d", step, len(p.GetSpecialDays()), expectedDays)
+		}
+	}
+
+	check("before the first refresh", 0, 0, 0)
+	p.refresh(start)
+	check("first refresh", 1, 1, 3)
+	days := p.GetSpecialDays()
+	if days[0].Id != "school/term-1@example.org" || days[0].Type != "interval" || !days[0].IsSchoolHoliday {
+		t.Errorf("got %+v", days[0])
+	}
+	if days[2].Recurrence == nil || len(days[2].Recurrence.ExceptDates) != 1 || days[2].Recurrence.ExceptDates[0] != "2024-10-30" {
+		t.Errorf("got recurrence %+v", days[2].Recurrence)
+	}
+
+	p.refresh(start.Add(30 * time.Minute))
+	check("within the refresh interval the cache is used", 1, 1, 3)
+
+	p.refresh(start.Add(time.Hour))
+	check("unchanged feed keeps the version", 2, 1, 3)
+
+	failing.Store(true)
+	p.refresh(start.Add(2 * time.Hour))
+	check("failed feed keeps the last events", 3, 1, 3)
+
+	failing.Store(false)
+	content = []byte(calendarWithSingleEvent)
+	p.refresh(start.Add(3 * time.Hour))
+	check("changed feed", 4, 2, 1)
+
+	cfg.feeds = nil
+	p.refresh(start.Add(3*time.Hour + time.Minute))
+	check("removed feed", 4, 3, 0)
+}
+
+const calendarWithSingleEvent = "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:only\r\nSUMMARY:Sports day\r\nDTSTART;VALUE=DATE:20241115\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
@@ -0,0 +1,218 @@
+package ics
+
+import (
+	"bufio"
+	"github.com/rotisserie/eris"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT from an iCalendar (RFC 5545) file, only the properties needed for the calendar are kept
+type Event struct {
+	Uid          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time // in the event time zone, midnight UTC for all-day events
+	End          time.Time // exclusive like DTEND, zero if the event had neither DTEND nor DURATION
+	AllDay       bool
+	RRule        string      // raw RRULE value, empty for non-recurring events
+	ExDates      []time.Time // EXDATE values, the occurrences of the RRULE left out
+	RecurrenceId time.Time   // the occurrence of the recurring event with the same UID this one replaces, zero if none
+	Cancelled    bool        // STATUS:CANCELLED, a cancelled RECURRENCE-ID removes the occurrence
+}
+
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads all VEVENTs from an iCalendar stream. The events with the dates which can't be read are left out
+// with a log line so that a single broken event doesn't hide the whole feed.
+func Parse(reader io.Reader) ([]*Event, error) {
+	lines, err := unfoldLines(reader)
+	if err != nil {
+		return nil, err
+	}
+	contentLines := make([]*contentLine, 0, len(lines))
+	for _, line := range lines {
+		if cl := parseContentLine(line); cl != nil {
+			contentLines = append(contentLines, cl)
+		}
+	}
+	// the time zones may be defined after the events using them
+	zones := newZoneResolver(parseVTimezones(contentLines))
+	res := make([]*Event, 0)
+	var current *Event
+	var duration string
+	var eventErr error
+	for _, cl := range contentLines {
+		switch cl.name {
+		case "BEGIN":
+			if cl.value == "VEVENT" {
+				current = &Event{}
+				duration = ""
+				eventErr = nil
+			}
+			continue
+		case "END":
+			if cl.value == "VEVENT" && current != nil {
+				if eventErr == nil && current.Start.IsZero() {
+					eventErr = eris.New("no DTSTART")
+				}
+				if eventErr == nil && current.End.IsZero() && duration != "" {
+					d, err := parseDuration(duration)
+					if err != nil {
+						eventErr = eris.Wrap(err, "error parsing DURATION")
+					}
+					current.End = current.Start.Add(d)
+				}
+				if eventErr != nil {
+					println(eris.ToString(eris.Wrapf(eventErr, "Event '%s' is left out", current.Uid), false))
+				} else {
+					res = append(res, current)
+				}
+				current = nil
+			}
+			continue
+		}
+		if current == nil || eventErr != nil {
+			continue
+		}
+		switch cl.name {
+		case "UID":
+			current.Uid = cl.value
+		case "SUMMARY":
+			current.Summary = unescapeText(cl.value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(cl.value)
+		case "LOCATION":
+			current.Location = unescapeText(cl.value)
+		case "STATUS":
+			current.Cancelled = strings.EqualFold(cl.value, "CANCELLED")
+		case "RRULE":
+			current.RRule = cl.value
+		case "DURATION":
+			duration = cl.value
+		case "DTSTART":
+			current.Start, current.AllDay, err = zones.parseDateTime(cl)
+			if err != nil {
+				eventErr = eris.Wrap(err, "error parsing DTSTART")
+			}
+		case "DTEND":
+			current.End, _, err = zones.parseDateTime(cl)
+			if err != nil {
+				eventErr = eris.Wrap(err, "error parsing DTEND")
+			}
+		case "RECURRENCE-ID":
+			current.RecurrenceId, _, err = zones.parseDateTime(cl)
+			if err != nil {
+				eventErr = eris.Wrap(err, "error parsing RECURRENCE-ID")
+			}
+		case "EXDATE":
+			// a list of the dates sharing the parameters
+			for _, value := range strings.Split(cl.value, ",") {
+				exDate, _, err := zones.parseDateTime(&contentLine{name: cl.name, params: cl.params, value: value})
+				if err != nil {
+					eventErr = eris.Wrap(err, "error parsing EXDATE")
+					break
+				}
+				current.ExDates = append(current.ExDates, exDate)
+			}
+		}
+	}
+	return res, nil
+}
+
+// unfoldLines joins the lines split according to https://datatracker.ietf.org/doc/html/rfc5545#section-3.1
+func unfoldLines(reader io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	res := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(res) > 0 {
+			res[len(res)-1] += line[1:]
+			continue
+		}
+		res = append(res, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, eris.Wrap(err, "error reading iCalendar data")
+	}
+	return res, nil
+}
+
+// parseContentLine splits "NAME;PARAM=VALUE:value" into parts, returns nil for malformed lines
+func parseContentLine(line string) *contentLine {
+	colon := -1
+	inQuotes := false
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return nil
+	}
+	parts := strings.Split(line[:colon], ";")
+	res := &contentLine{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if found {
+			res.params[strings.ToUpper(key)] = strings.Trim(value, "\"")
+		}
+	}
+	return res
+}
+
+// parseDuration supports the "dur-value" format, e.g. P1D, PT1H30M or P2W
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+		value = value[1:]
+	}
+	value = strings.TrimPrefix(value, "+")
+	if !strings.HasPrefix(value, "P") {
+		return 0, eris.Errorf("malformed duration '%s'", value)
+	}
+	res := time.Duration(0)
+	number := 0
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			number = number*10 + int(c-'0')
+			continue
+		case c == 'T':
+			continue
+		case c == 'W':
+			res += time.Duration(number) * 7 * 24 * time.Hour
+		case c == 'D':
+			res += time.Duration(number) * 24 * time.Hour
+		case c == 'H':
+			res += time.Duration(number) * time.Hour
+		case c == 'M':
+			res += time.Duration(number) * time.Minute
+		case c == 'S':
+			res += time.Duration(number) * time.Second
+		default:
+			return 0, eris.Errorf("malformed duration '%s'", value)
+		}
+		number = 0
+	}
+	return sign * res, nil
+}
+
+func unescapeText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
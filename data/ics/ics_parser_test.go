@@ -0,0 +1,261 @@
+package ics_test
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ics"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"strings"
+	"testing"
+	"time"
+)
+
+// calendar wraps the lines into a VCALENDAR with the CRLF line ends of RFC 5545
+func calendar(lines ...string) string {
+	return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n")
+}
+
+func event(lines ...string) []string {
+	return append(append([]string{"BEGIN:VEVENT"}, lines...), "END:VEVENT")
+}
+
+// customZone is an Outlook style VTIMEZONE with a name unknown to the system, Central European rules
+var customZone = []string{
+	"BEGIN:VTIMEZONE",
+	"TZID:Customized Time Zone",
+	"BEGIN:STANDARD",
+	"DTSTART:16010101T030000",
+	"TZOFFSETFROM:+0200",
+	"TZOFFSETTO:+0100",
+	"RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10",
+	"END:STANDARD",
+	"BEGIN:DAYLIGHT",
+	"DTSTART:16010101T020000",
+	"TZOFFSETFROM:+0100",
+	"TZOFFSETTO:+0200",
+	"RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3",
+	"END:DAYLIGHT",
+	"END:VTIMEZONE",
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(t *testing.T, events []*ics.Event)
+	}{
+		{
+			name:    "all-day event",
+			content: calendar(event("UID:a", "SUMMARY:Holiday", "DTSTART;VALUE=DATE:20240101", "DTEND;VALUE=DATE:20240103")...),
+			check: func(t *testing.T, events []*ics.Event) {
+				e := events[0]
+				if !e.AllDay || !e.Start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !e.End.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("got all day %v from %v to %v", e.AllDay, e.Start, e.End)
+				}
+			},
+		},
+		{
+			name:    "timed event in UTC with a duration",
+			content: calendar(event("UID:a", "DTSTART:20240101T100000Z", "DURATION:PT1H30M")...),
+			check: func(t *testing.T, events []*ics.Event) {
+				e := events[0]
+				if e.AllDay || !e.Start.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) || !e.End.Equal(time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC)) {
+					t.Errorf("got all day %v from %v to %v", e.AllDay, e.Start, e.End)
+				}
+			},
+		},
+		{
+			name:    "IANA time zone",
+			content: calendar(event("UID:a", "DTSTART;TZID=Europe/Berlin:20240701T100000")...),
+			check:   expectStart(time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "quoted IANA time zone with a slash prefix",
+			content: calendar(event("UID:a", `DTSTART;TZID="/Australia/Sydney":20240701T100000`)...),
+			check:   expectStart(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "Windows time zone",
+			content: calendar(event("UID:a", "DTSTART;TZID=W. Europe Standard Time:20240115T100000")...),
+			check:   expectStart(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "VTIMEZONE in the summer",
+			content: calendar(append(customZone, event("UID:a", `DTSTART;TZID="Customized Time Zone":20240701T100000`)...)...),
+			check:   expectStart(time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "VTIMEZONE in the winter after the events",
+			content: calendar(append(event("UID:a", `DTSTART;TZID="Customized Time Zone":20241201T100000`), customZone...)...),
+			check:   expectStart(time.Date(2024, 12, 1, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "VTIMEZONE on the day of the change",
+			content: calendar(append(customZone, event("UID:a", `DTSTART;TZID="Customized Time Zone":20240331T120000`)...)...),
+			check:   expectStart(time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:    "unknown time zone falls back to the local time",
+			content: calendar(event("UID:a", "DTSTART;TZID=Mars/Olympus_Mons:20240701T100000")...),
+			check:   expectStart(time.Date(2024, 7, 1, 10, 0, 0, 0, time.Local)),
+		},
+		{
+			name: "folded lines",
+			content: calendar(event(
+				"UID:a",
+				"SUMMARY:Parent-teacher ",
+				" meeting\\, room 4",
+				"DESCRIPTION:first\\n",
+				"\tsecond",
+				"DTSTART;VALUE=DATE:",
+				" 20240101",
+			)...),
+			check: func(t *testing.T, events []*ics.Event) {
+				e := events[0]
+				if e.Summary != "Parent-teacher meeting, room 4" || e.Description != "first\nsecond" || !e.AllDay {
+					t.Errorf("got summary %q, description %q, all day %v", e.Summary, e.Description, e.AllDay)
+				}
+			},
+		},
+		{
+			name: "exception dates and a replaced occurrence",
+			content: calendar(append(
+				event("UID:a", "DTSTART;TZID=Europe/London:20240101T100000", "RRULE:FREQ=WEEKLY", "EXDATE;TZID=Europe/London:20240108T100000,20240115T100000", "EXDATE;VALUE=DATE:20240122"),
+				event("UID:a", "RECURRENCE-ID;TZID=Europe/London:20240129T100000", "DTSTART;TZID=Europe/London:20240130T100000", "STATUS:CONFIRMED")...,
+			)...),
+			check: func(t *testing.T, events []*ics.Event) {
+				if len(events[0].ExDates) != 3 || !events[0].ExDates[1].Equal(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)) {
+					t.Errorf("got exception dates %v", events[0].ExDates)
+				}
+				if !events[1].RecurrenceId.Equal(time.Date(2024, 1, 29, 10, 0, 0, 0, time.UTC)) || events[1].Cancelled {
+					t.Errorf("got recurrence id %v, cancelled %v", events[1].RecurrenceId, events[1].Cancelled)
+				}
+			},
+		},
+		{
+			name:    "broken event is left out",
+			content: calendar(append(event("UID:broken", "DTSTART:yesterday"), event("UID:fine", "DTSTART;VALUE=DATE:20240101")...)...),
+			check: func(t *testing.T, events []*ics.Event) {
+				if len(events) != 1 || events[0].Uid != "fine" {
+					t.Errorf("got %d events", len(events))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := ics.Parse(strings.NewReader(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) == 0 {
+				t.Fatal("no events")
+			}
+			test.check(t, events)
+		})
+	}
+}
+
+func expectStart(expected time.Time) func(t *testing.T, events []*ics.Event) {
+	return func(t *testing.T, events []*ics.Event) {
+		if events[0].AllDay || !events[0].Start.Equal(expected) {
+			t.Errorf("got start %v, expected %v", events[0].Start, expected)
+		}
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRRuleExpansion(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		from, to time.Time
+		expected []time.Time // start dates of the occurrences
+	}{
+		{
+			name:     "weekly on two days",
+			lines:    event("UID:a", "DTSTART;VALUE=DATE:20240101", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE"),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 1, 14),
+			expected: []time.Time{date(2024, 1, 1), date(2024, 1, 3), date(2024, 1, 8), date(2024, 1, 10)},
+		},
+		{
+			name:     "second Sunday of May every year",
+			lines:    event("UID:a", "DTSTART;VALUE=DATE:20200510", "RRULE:FREQ=YEARLY;BYMONTH=5;BYDAY=2SU"),
+			from:     date(2024, 1, 1),
+			to:       date(2025, 12, 31),
+			expected: []time.Time{date(2024, 5, 12), date(2025, 5, 11)},
+		},
+		{
+			name:     "every other month with a count",
+			lines:    event("UID:a", "DTSTART;VALUE=DATE:20240115", "RRULE:FREQ=MONTHLY;INTERVAL=2;COUNT=3"),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 12, 31),
+			expected: []time.Time{date(2024, 1, 15), date(2024, 3, 15), date(2024, 5, 15)},
+		},
+		{
+			name:     "daily until",
+			lines:    event("UID:a", "DTSTART:20240101T100000Z", "RRULE:FREQ=DAILY;UNTIL=20240103T100000Z"),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 1, 31),
+			expected: []time.Time{date(2024, 1, 1), date(2024, 1, 2), date(2024, 1, 3)},
+		},
+		{
+			name:     "exception dates",
+			lines:    event("UID:a", "DTSTART;VALUE=DATE:20240101", "RRULE:FREQ=WEEKLY;COUNT=4", "EXDATE;VALUE=DATE:20240108,20240115"),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 1, 31),
+			expected: []time.Time{date(2024, 1, 1), date(2024, 1, 22)},
+		},
+		{
+			name: "moved and cancelled occurrences",
+			lines: append(append(
+				event("UID:a", "DTSTART;VALUE=DATE:20240101", "RRULE:FREQ=WEEKLY;COUNT=4"),
+				event("UID:a", "RECURRENCE-ID;VALUE=DATE:20240108", "DTSTART;VALUE=DATE:20240110")...),
+				event("UID:a", "RECURRENCE-ID;VALUE=DATE:20240115", "DTSTART;VALUE=DATE:20240115", "STATUS:CANCELLED")...),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 1, 31),
+			expected: []time.Time{date(2024, 1, 1), date(2024, 1, 22), date(2024, 1, 10)},
+		},
+		{
+			name:     "times of the day are ignored",
+			lines:    event("UID:a", "DTSTART:20240101T100000Z", "RRULE:FREQ=DAILY;COUNT=2;BYHOUR=10,14"),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 1, 31),
+			expected: []time.Time{date(2024, 1, 1), date(2024, 1, 2)},
+		},
+		{
+			name:     "unsupported rule keeps the first occurrence",
+			lines:    event("UID:a", "DTSTART;VALUE=DATE:20240101", "RRULE:FREQ=MONTHLY;BYDAY=MO,TU;BYSETPOS=-1"),
+			from:     date(2024, 1, 1),
+			to:       date(2024, 12, 31),
+			expected: []time.Time{date(2024, 1, 1)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := ics.Parse(strings.NewReader(calendar(test.lines...)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			specialDays, err := ics.ToSpecialDays(events, &config.IcsFeed{Id: "feed"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]time.Time, 0)
+			for _, occurrence := range specialdays.Resolve(specialDays, test.from, test.to) {
+				got = append(got, occurrence.Start)
+			}
+			if len(got) != len(test.expected) {
+				t.Fatalf("got %v, expected %v", got, test.expected)
+			}
+			for i := range got {
+				if !got[i].Equal(test.expected[i]) {
+					t.Fatalf("got %v, expected %v", got, test.expected)
+				}
+			}
+		})
+	}
+}
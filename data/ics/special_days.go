@@ -0,0 +1,211 @@
+package ics
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"github.com/rotisserie/eris"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultDisplayTextTemplate = "{{.Summary}}"
+
+type displayTextData struct {
+	Summary     string
+	Description string
+	Location    string
+	FeedId      string
+}
+
+// ToSpecialDays converts events of a feed into the special day model used by the calendar.
+// Recurrence rules the model cannot express are reduced to their first occurrence. The occurrences moved
+// by the events with RECURRENCE-ID are shown on their new dates, the cancelled ones are left out.
+func ToSpecialDays(events []*Event, feed *config.IcsFeed) ([]*config.SpecialDayOrInterval, error) {
+	templateText := feed.DisplayTextTemplate
+	if templateText == "" {
+		templateText = defaultDisplayTextTemplate
+	}
+	displayTextTemplate, err := template.New(feed.Id).Parse(templateText)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error parsing display text template of feed '%s'", feed.Id)
+	}
+	// the start dates of the recurring events replaced by other events, by the UID
+	replaced := make(map[string][]string)
+	for _, event := range events {
+		if !event.RecurrenceId.IsZero() {
+			replaced[event.Uid] = append(replaced[event.Uid], eventDay(event.RecurrenceId, event.AllDay).Format(time.DateOnly))
+		}
+	}
+	res := make([]*config.SpecialDayOrInterval, 0, len(events))
+	for _, event := range events {
+		if event.Cancelled {
+			continue
+		}
+		displayText := strings.Builder{}
+		err := displayTextTemplate.Execute(&displayText, &displayTextData{
+			Summary:     event.Summary,
+			Description: event.Description,
+			Location:    event.Location,
+			FeedId:      feed.Id,
+		})
+		if err != nil {
+			return nil, eris.Wrapf(err, "error rendering display text of event '%s'", event.Uid)
+		}
+		firstDay, lastDay := eventDays(event)
+		sd := &config.SpecialDayOrInterval{
+			Id:              feed.Id + "/" + event.Uid,
+			DisplayText:     displayText.String(),
+			Type:            "once_off",
+			StartDateDay:    firstDay.Day(),
+			StartDateMonth:  int(firstDay.Month()),
+			StartDateYear:   firstDay.Year(),
+			EndDateDay:      lastDay.Day(),
+			EndDateMonth:    int(lastDay.Month()),
+			EndDateYear:     lastDay.Year(),
+			IsPublicHoliday: feed.IsPublicHoliday,
+			IsSchoolHoliday: feed.IsSchoolHoliday,
+		}
+		durationDays := int(lastDay.Sub(firstDay).Hours()/24) + 1
+		if durationDays > 1 {
+			sd.Type = "interval"
+		}
+		if !event.RecurrenceId.IsZero() {
+			// a single moved occurrence, the rule stays with the recurring event
+			sd.Id += "/" + eventDay(event.RecurrenceId, event.AllDay).Format(time.DateOnly)
+		} else if event.RRule != "" {
+			recurrence, err := ParseRRule(event.RRule, firstDay)
+			if err != nil {
+				println(eris.ToString(eris.Wrapf(err, "Only the first occurrence of event '%s' from feed '%s' is shown", event.Uid, feed.Id), false))
+			} else {
+				recurrence.DurationDays = durationDays
+				for _, exDate := range event.ExDates {
+					recurrence.ExceptDates = append(recurrence.ExceptDates, eventDay(exDate, event.AllDay).Format(time.DateOnly))
+				}
+				recurrence.ExceptDates = append(recurrence.ExceptDates, replaced[event.Uid]...)
+				sd.Type = "recurring"
+				sd.Recurrence = recurrence
+			}
+		}
+		res = append(res, sd)
+	}
+	return res, nil
+}
+
+// eventDay returns the calendar day of the time as a midnight UTC date, the timed events are shown on the local date
+func eventDay(t time.Time, allDay bool) time.Time {
+	if !allDay {
+		t = t.In(time.Local)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// eventDays returns the first and the last (inclusive) calendar days of an event as midnight UTC dates
+func eventDays(event *Event) (time.Time, time.Time) {
+	firstDay := eventDay(event.Start, event.AllDay)
+	if event.End.IsZero() || !event.End.After(event.Start) {
+		return firstDay, firstDay
+	}
+	// DTEND is exclusive, an event ending at midnight doesn't cover the following day
+	lastDay := eventDay(event.End.Add(-time.Nanosecond), event.AllDay)
+	if lastDay.Before(firstDay) {
+		return firstDay, firstDay
+	}
+	return firstDay, lastDay
+}
+
+var rruleWeekdays = map[string]int{"SU": 0, "MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6}
+
+// ParseRRule converts the subset of RFC 5545 recurrence rules supported by config.Recurrence,
+// firstDay is the first occurrence (DTSTART) of the event.
+func ParseRRule(rrule string, firstDay time.Time) (*config.Recurrence, error) {
+	res := &config.Recurrence{}
+	parts := make(map[string]string)
+	for _, part := range strings.Split(rrule, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return nil, eris.Errorf("malformed RRULE part '%s'", part)
+		}
+		parts[strings.ToUpper(key)] = strings.ToUpper(value)
+	}
+	var err error
+	for key, value := range parts {
+		switch key {
+		case "FREQ":
+			res.Frequency = strings.ToLower(value)
+			if res.Frequency != "daily" && res.Frequency != "weekly" && res.Frequency != "monthly" && res.Frequency != "yearly" {
+				return nil, eris.Errorf("unsupported RRULE frequency '%s'", value)
+			}
+		case "INTERVAL":
+			res.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			res.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			if len(value) < 8 {
+				return nil, eris.Errorf("malformed RRULE UNTIL '%s'", value)
+			}
+			var until time.Time
+			until, err = time.Parse("20060102", value[:8])
+			res.UntilDay, res.UntilMonth, res.UntilYear = until.Day(), int(until.Month()), until.Year()
+		case "BYDAY":
+			err = parseByDay(value, res)
+		case "BYMONTHDAY":
+			if value != strconv.Itoa(firstDay.Day()) {
+				return nil, eris.Errorf("unsupported RRULE BYMONTHDAY '%s' different from the start date", value)
+			}
+		case "BYMONTH":
+			if value != strconv.Itoa(int(firstDay.Month())) {
+				return nil, eris.Errorf("unsupported RRULE BYMONTH '%s' different from the start date", value)
+			}
+		case "WKST":
+			// weeks always start on Monday
+		case "BYHOUR", "BYMINUTE", "BYSECOND":
+			// the calendar shows days, several times of the same day are a single occurrence
+			println("RRULE part " + key + " is ignored, only the days of '" + rrule + "' are shown")
+		default:
+			return nil, eris.Errorf("unsupported RRULE part '%s'", key)
+		}
+		if err != nil {
+			return nil, eris.Wrapf(err, "malformed RRULE %s '%s'", key, value)
+		}
+	}
+	if res.Frequency == "" {
+		return nil, eris.New("RRULE without FREQ")
+	}
+	if res.WeekOfMonth != 0 && res.Frequency != "monthly" && res.Frequency != "yearly" {
+		return nil, eris.Errorf("unsupported RRULE BYDAY with a week number for %s frequency", res.Frequency)
+	}
+	if res.WeekOfMonth != 0 && res.Frequency == "yearly" && parts["BYMONTH"] == "" {
+		return nil, eris.New("unsupported yearly RRULE BYDAY with a week number but without BYMONTH")
+	}
+	if len(res.Weekdays) > 0 && res.WeekOfMonth == 0 && res.Frequency != "weekly" {
+		return nil, eris.Errorf("unsupported RRULE BYDAY without a week number for %s frequency", res.Frequency)
+	}
+	return res, nil
+}
+
+// parseByDay handles "MO,WE,FR" lists and single "2SU" or "-1MO" nth weekday values
+func parseByDay(value string, res *config.Recurrence) error {
+	days := strings.Split(value, ",")
+	for _, day := range days {
+		if len(day) < 2 {
+			return eris.Errorf("malformed weekday '%s'", day)
+		}
+		weekday, exists := rruleWeekdays[day[len(day)-2:]]
+		if !exists {
+			return eris.Errorf("unknown weekday '%s'", day)
+		}
+		if ordinal := day[:len(day)-2]; ordinal != "" {
+			if len(days) > 1 {
+				return eris.New("multiple BYDAY values with week numbers are not supported")
+			}
+			weekOfMonth, err := strconv.Atoi(strings.TrimPrefix(ordinal, "+"))
+			if err != nil {
+				return err
+			}
+			res.WeekOfMonth = weekOfMonth
+		}
+		res.Weekdays = append(res.Weekdays, weekday)
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package ics
+
+import (
+	"fkirill.org/eink-meteo-station/data/holidays"
+	"github.com/rotisserie/eris"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zoneResolver reads the times with the TZID parameter. The IANA names are loaded from the system, the Windows
+// names used by Outlook and Exchange are mapped to the IANA ones and the rest are taken from the VTIMEZONE blocks
+// of the file. The times in a zone known by none of them are read as the local ones.
+type zoneResolver struct {
+	vtimezones map[string]*vtimezone
+	locations  map[string]*time.Location // nil for the zones which are not IANA or Windows ones
+	logged     map[string]bool           // the unknown zones are logged once per file
+}
+
+func newZoneResolver(vtimezones map[string]*vtimezone) *zoneResolver {
+	return &zoneResolver{
+		vtimezones: vtimezones,
+		locations:  make(map[string]*time.Location),
+		logged:     make(map[string]bool),
+	}
+}
+
+func (z *zoneResolver) location(tzid string) *time.Location {
+	if loc, exists := z.locations[tzid]; exists {
+		return loc
+	}
+	// some producers prefix the IANA names with a slash
+	name := strings.TrimPrefix(tzid, "/")
+	if ianaName, exists := windowsZones[name]; exists {
+		name = ianaName
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = nil
+	}
+	z.locations[tzid] = loc
+	return loc
+}
+
+// parseDateTime returns the time and whether it is a date of an all-day event
+func (z *zoneResolver) parseDateTime(cl *contentLine) (time.Time, bool, error) {
+	value := cl.value
+	if cl.params["VALUE"] == "DATE" || len(value) == 8 {
+		res, err := time.ParseInLocation("20060102", value, time.UTC)
+		return res, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		res, err := time.ParseInLocation("20060102T150405Z", value, time.UTC)
+		return res, false, err
+	}
+	tzid, exists := cl.params["TZID"]
+	if !exists {
+		// the floating time is the same wall clock time in every time zone
+		res, err := time.ParseInLocation("20060102T150405", value, time.Local)
+		return res, false, err
+	}
+	if loc := z.location(tzid); loc != nil {
+		res, err := time.ParseInLocation("20060102T150405", value, loc)
+		return res, false, err
+	}
+	wall, err := time.ParseInLocation("20060102T150405", value, time.UTC)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if vtz, exists := z.vtimezones[tzid]; exists {
+		offset := vtz.offsetAt(wall)
+		return wall.Add(-time.Duration(offset) * time.Second).In(time.FixedZone(tzid, offset)), false, nil
+	}
+	if !z.logged[tzid] {
+		z.logged[tzid] = true
+		println("Unknown time zone '" + tzid + "', its times are taken as the local ones")
+	}
+	res, err := time.ParseInLocation("20060102T150405", value, time.Local)
+	return res, false, err
+}
+
+// observance is a STANDARD or a DAYLIGHT part of a VTIMEZONE, the onsets are in the local time before the change
+type observance struct {
+	start     time.Time // DTSTART, the wall clock time as UTC
+	offsetTo  int       // seconds east of UTC
+	month     time.Month
+	weekday   time.Weekday
+	week      int       // 1 to 5 or -1 for the last one, 0 if the rule has no week number
+	monthDays []int     // BYMONTHDAY of the rules like "the first Sunday on or after the 8th"
+	until     time.Time // zero if the rule has no end
+	yearly    bool      // RRULE FREQ=YEARLY, the onset repeats every year
+}
+
+type vtimezone struct {
+	observances []*observance
+}
+
+// parseVTimezones collects the VTIMEZONE blocks by their TZID, the blocks with the rules which can't be read
+// are left out and their times are taken as the local ones
+func parseVTimezones(lines []*contentLine) map[string]*vtimezone {
+	res := make(map[string]*vtimezone)
+	var tzid string
+	var current *vtimezone
+	var obs *observance
+	var err error
+	for _, cl := range lines {
+		switch {
+		case cl.name == "BEGIN" && cl.value == "VTIMEZONE":
+			current = &vtimezone{}
+			tzid = ""
+			err = nil
+		case current == nil:
+		case cl.name == "END" && cl.value == "VTIMEZONE":
+			if err != nil {
+				println(eris.ToString(eris.Wrapf(err, "Time zone '%s' is left out", tzid), false))
+			} else if tzid != "" && len(current.observances) > 0 {
+				res[tzid] = current
+			}
+			current = nil
+		case cl.name == "TZID":
+			tzid = cl.value
+		case cl.name == "BEGIN" && (cl.value == "STANDARD" || cl.value == "DAYLIGHT"):
+			obs = &observance{}
+		case obs == nil:
+		case cl.name == "END" && (cl.value == "STANDARD" || cl.value == "DAYLIGHT"):
+			current.observances = append(current.observances, obs)
+			obs = nil
+		default:
+			// the first error of the block is kept
+			if lineErr := obs.parseLine(cl); err == nil {
+				err = lineErr
+			}
+		}
+	}
+	return res
+}
+
+func (o *observance) parseLine(cl *contentLine) error {
+	var err error
+	switch cl.name {
+	case "DTSTART":
+		o.start, err = time.ParseInLocation("20060102T150405", cl.value, time.UTC)
+	case "TZOFFSETTO":
+		o.offsetTo, err = parseUtcOffset(cl.value)
+	case "RRULE":
+		err = o.parseRule(cl.value)
+	}
+	return err
+}
+
+// parseUtcOffset reads +HHMM or +HHMMSS into seconds
+func parseUtcOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 || (value[0] != '+' && value[0] != '-') {
+		return 0, eris.Errorf("malformed UTC offset '%s'", value)
+	}
+	res := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if 1+2*i >= len(value) {
+			break
+		}
+		part, err := strconv.Atoi(value[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, eris.Wrapf(err, "malformed UTC offset '%s'", value)
+		}
+		res += part * unit
+	}
+	if value[0] == '-' {
+		res = -res
+	}
+	return res, nil
+}
+
+// parseRule reads the yearly rules of the daylight saving changes, e.g. FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU
+func (o *observance) parseRule(rrule string) error {
+	for _, part := range strings.Split(strings.ToUpper(rrule), ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			if value != "YEARLY" {
+				return eris.Errorf("unsupported time zone rule frequency '%s'", value)
+			}
+			o.yearly = true
+		case "BYMONTH":
+			month, err := strconv.Atoi(value)
+			if err != nil || month < 1 || month > 12 {
+				return eris.Errorf("malformed time zone rule month '%s'", value)
+			}
+			o.month = time.Month(month)
+		case "BYDAY":
+			if len(value) < 2 {
+				return eris.Errorf("malformed time zone rule weekday '%s'", value)
+			}
+			weekday, exists := rruleWeekdays[value[len(value)-2:]]
+			if !exists {
+				return eris.Errorf("unknown time zone rule weekday '%s'", value)
+			}
+			o.weekday = time.Weekday(weekday)
+			if ordinal := value[:len(value)-2]; ordinal != "" {
+				week, err := strconv.Atoi(strings.TrimPrefix(ordinal, "+"))
+				if err != nil {
+					return eris.Wrapf(err, "malformed time zone rule weekday '%s'", value)
+				}
+				o.week = week
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil {
+					return eris.Wrapf(err, "malformed time zone rule day '%s'", value)
+				}
+				o.monthDays = append(o.monthDays, monthDay)
+			}
+		case "UNTIL":
+			if len(value) < 15 {
+				return eris.Errorf("malformed time zone rule end '%s'", value)
+			}
+			until, err := time.ParseInLocation("20060102T150405", value[:15], time.UTC)
+			if err != nil {
+				return eris.Wrapf(err, "malformed time zone rule end '%s'", value)
+			}
+			o.until = until
+		case "INTERVAL", "WKST":
+			if key == "INTERVAL" && value != "1" {
+				return eris.Errorf("unsupported time zone rule interval '%s'", value)
+			}
+		default:
+			return eris.Errorf("unsupported time zone rule part '%s'", key)
+		}
+	}
+	if o.yearly && o.month == 0 {
+		return eris.New("time zone rule without BYMONTH")
+	}
+	return nil
+}
+
+// onset returns the change in the year at the time of the day of DTSTART, false if there is none that year
+func (o *observance) onset(year int) (time.Time, bool) {
+	if !o.yearly {
+		return o.start, year == o.start.Year()
+	}
+	var day time.Time
+	ok := true
+	switch {
+	case len(o.monthDays) > 0:
+		// the first of the days which falls on the weekday
+		ok = false
+		for _, monthDay := range o.monthDays {
+			candidate := time.Date(year, o.month, monthDay, 0, 0, 0, 0, time.UTC)
+			if candidate.Weekday() == o.weekday {
+				day, ok = candidate, true
+				break
+			}
+		}
+	case o.week != 0:
+		day, ok = holidays.NthWeekday(year, o.month, o.weekday, o.week)
+	default:
+		day = time.Date(year, o.month, o.start.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+	res := day.Add(time.Duration(o.start.Hour())*time.Hour + time.Duration(o.start.Minute())*time.Minute + time.Duration(o.start.Second())*time.Second)
+	if res.Before(o.start) || (!o.until.IsZero() && res.After(o.until)) {
+		return time.Time{}, false
+	}
+	return res, true
+}
+
+// offsetAt returns the UTC offset in seconds of the wall clock time, the one of the latest change before it
+func (v *vtimezone) offsetAt(wall time.Time) int {
+	var latest time.Time
+	res := 0
+	found := false
+	for _, obs := range v.observances {
+		// the last change may have happened in the previous year
+		for _, year := range []int{wall.Year(), wall.Year() - 1} {
+			onset, ok := obs.onset(year)
+			if !ok || onset.After(wall) {
+				continue
+			}
+			if !found || onset.After(latest) {
+				latest, res, found = onset, obs.offsetTo, true
+			}
+			break
+		}
+	}
+	if !found {
+		// before all the changes the earliest observance is taken
+		earliest := v.observances[0]
+		for _, obs := range v.observances {
+			if obs.start.Before(earliest.start) {
+				earliest = obs
+			}
+		}
+		return earliest.offsetTo
+	}
+	return res
+}
+
+// windowsZones maps the Windows time zone names to the IANA ones, from the CLDR windowsZones.xml territory 001
+var windowsZones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"UTC-11":                          "Etc/GMT+11",
+	"Aleutian Standard Time":          "America/Adak",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Marquesas Standard Time":         "Pacific/Marquesas",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"UTC-09":                          "Etc/GMT+9",
+	"Pacific Standard Time (Mexico)":  "America/Tijuana",
+	"UTC-08":                          "Etc/GMT+8",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Mountain Standard Time (Mexico)": "America/Mazatlan",
+	"Mountain Standard Time":          "America/Denver",
+	"Yukon Standard Time":             "America/Whitehorse",
+	"Central America Standard Time":   "America/Guatemala",
+	"Central Standard Time":           "America/Chicago",
+	"Easter Island Standard Time":     "Pacific/Easter",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Eastern Standard Time (Mexico)":  "America/Cancun",
+	"Eastern Standard Time":           "America/New_York",
+	"Haiti Standard Time":             "America/Port-au-Prince",
+	"Cuba Standard Time":              "America/Havana",
+	"US Eastern Standard Time":        "America/Indianapolis",
+	"Turks And Caicos Standard Time":  "America/Grand_Turk",
+	"Paraguay Standard Time":          "America/Asuncion",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Venezuela Standard Time":         "America/Caracas",
+	"Central Brazilian Standard Time": "America/Cuiaba",
+	"SA Western Standard Time":        "America/La_Paz",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"Tocantins Standard Time":         "America/Araguaina",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"SA Eastern Standard Time":        "America/Cayenne",
+	"Argentina Standard Time":         "America/Buenos_Aires",
+	"Greenland Standard Time":         "America/Godthab",
+	"Montevideo Standard Time":        "America/Montevideo",
+	"Magallanes Standard Time":        "America/Punta_Arenas",
+	"Saint Pierre Standard Time":      "America/Miquelon",
+	"Bahia Standard Time":             "America/Bahia",
+	"UTC-02":                          "Etc/GMT+2",
+	"Azores Standard Time":            "Atlantic/Azores",
+	"Cape Verde Standard Time":        "Atlantic/Cape_Verde",
+	"UTC":                             "Etc/UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"Sao Tome Standard Time":          "Africa/Sao_Tome",
+	"Morocco Standard Time":           "Africa/Casablanca",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Romance Standard Time":           "Europe/Paris",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"Jordan Standard Time":            "Asia/Amman",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"Middle East Standard Time":       "Asia/Beirut",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"Syria Standard Time":             "Asia/Damascus",
+	"West Bank Standard Time":         "Asia/Hebron",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"FLE Standard Time":               "Europe/Kiev",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"South Sudan Standard Time":       "Africa/Juba",
+	"Kaliningrad Standard Time":       "Europe/Kaliningrad",
+	"Sudan Standard Time":             "Africa/Khartoum",
+	"Libya Standard Time":             "Africa/Tripoli",
+	"Namibia Standard Time":           "Africa/Windhoek",
+	"Arabic Standard Time":            "Asia/Baghdad",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Arab Standard Time":              "Asia/Riyadh",
+	"Belarus Standard Time":           "Europe/Minsk",
+	"Russian Standard Time":           "Europe/Moscow",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+	"Volgograd Standard Time":         "Europe/Volgograd",
+	"Iran Standard Time":              "Asia/Tehran",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Astrakhan Standard Time":         "Europe/Astrakhan",
+	"Azerbaijan Standard Time":        "Asia/Baku",
+	"Russia Time Zone 3":              "Europe/Samara",
+	"Mauritius Standard Time":         "Indian/Mauritius",
+	"Saratov Standard Time":           "Europe/Saratov",
+	"Georgian Standard Time":          "Asia/Tbilisi",
+	"Caucasus Standard Time":          "Asia/Yerevan",
+	"Afghanistan Standard Time":       "Asia/Kabul",
+	"West Asia Standard Time":         "Asia/Tashkent",
+	"Ekaterinburg Standard Time":      "Asia/Yekaterinburg",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"Qyzylorda Standard Time":         "Asia/Qyzylorda",
+	"India Standard Time":             "Asia/Calcutta",
+	"Sri Lanka Standard Time":         "Asia/Colombo",
+	"Nepal Standard Time":             "Asia/Katmandu",
+	"Central Asia Standard Time":      "Asia/Almaty",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"Omsk Standard Time":              "Asia/Omsk",
+	"Myanmar Standard Time":           "Asia/Rangoon",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"Altai Standard Time":             "Asia/Barnaul",
+	"W. Mongolia Standard Time":       "Asia/Hovd",
+	"North Asia Standard Time":        "Asia/Krasnoyarsk",
+	"N. Central Asia Standard Time":   "Asia/Novosibirsk",
+	"Tomsk Standard Time":             "Asia/Tomsk",
+	"China Standard Time":             "Asia/Shanghai",
+	"North Asia East Standard Time":   "Asia/Irkutsk",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"Ulaanbaatar Standard Time":       "Asia/Ulaanbaatar",
+	"Aus Central W. Standard Time":    "Australia/Eucla",
+	"Transbaikal Standard Time":       "Asia/Chita",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"North Korea Standard Time":       "Asia/Pyongyang",
+	"Korea Standard Time":             "Asia/Seoul",
+	"Yakutsk Standard Time":           "Asia/Yakutsk",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"AUS Central Standard Time":       "Australia/Darwin",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"West Pacific Standard Time":      "Pacific/Port_Moresby",
+	"Tasmania Standard Time":          "Australia/Hobart",
+	"Vladivostok Standard Time":       "Asia/Vladivostok",
+	"Lord Howe Standard Time":         "Australia/Lord_Howe",
+	"Bougainville Standard Time":      "Pacific/Bougainville",
+	"Russia Time Zone 10":             "Asia/Srednekolymsk",
+	"Magadan Standard Time":           "Asia/Magadan",
+	"Norfolk Standard Time":           "Pacific/Norfolk",
+	"Sakhalin Standard Time":          "Asia/Sakhalin",
+	"Central Pacific Standard Time":   "Pacific/Guadalcanal",
+	"Russia Time Zone 11":             "Asia/Kamchatka",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"UTC+12":                          "Etc/GMT-12",
+	"Fiji Standard Time":              "Pacific/Fiji",
+	"Chatham Islands Standard Time":   "Pacific/Chatham",
+	"UTC+13":                          "Etc/GMT-13",
+	"Tonga Standard Time":             "Pacific/Tongatapu",
+	"Samoa Standard Time":             "Pacific/Apia",
+	"Line Islands Standard Time":      "Pacific/Kiritimati",
+}
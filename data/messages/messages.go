@@ -0,0 +1,268 @@
+package messages
+
+import (
+	"encoding/json"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"github.com/rotisserie/eris"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// message priorities, the higher ones are shown first
+const (
+	PriorityNormal    = 0
+	PriorityImportant = 1
+	PriorityUrgent    = 2
+)
+
+// haMessageId is the id of the message taken from the Home Assistant entity, it is never stored
+const haMessageId = "ha"
+
+// the Home Assistant entity is read at most this often rather than on every redraw
+const haPollInterval = time.Minute
+
+// Schedule limits a message to some hours of some days, a message without a schedule is shown all the time
+type Schedule struct {
+	From     string `json:"from"`     // HH:MM, the hours cross the midnight if from is after to
+	To       string `json:"to"`       // HH:MM
+	Weekdays []int  `json:"weekdays"` // 0 (Sunday) to 6 (Saturday) the hours start on, every day if empty
+}
+
+type Message struct {
+	Id       string     `json:"id"`
+	Text     string     `json:"text"`
+	Priority int        `json:"priority"` // 0 normal, 1 important or 2 urgent
+	Created  time.Time  `json:"created"`
+	Expires  *time.Time `json:"expires,omitempty"` // the message is removed after this time, never if not set
+	Schedule *Schedule  `json:"schedule,omitempty"`
+}
+
+// MessageStore keeps the messages posted from the web page and the API, the web server and the widget
+// use it from different goroutines
+type MessageStore interface {
+	// List returns all the messages which have not expired
+	List() []*Message
+	// Active returns the messages to show now, with the one from Home Assistant, the urgent ones first
+	Active(now time.Time) []*Message
+	// Put checks the message and stores it, a message with the same id is replaced.
+	// The message comes back with the error if it is fine but the file couldn't be written.
+	Put(message *Message) (*Message, error)
+	// Remove returns false if there is no message with the id
+	Remove(id string) (bool, error)
+}
+
+type messageStore struct {
+	config     config.ConfigApi
+	haApi      ha.HomeAssistantApi
+	lock       sync.Mutex
+	messages   []*Message
+	haText     string // the last text of the Home Assistant entity, empty if there is none
+	haPolledAt time.Time
+}
+
+func NewMessageStore(cfg config.ConfigApi, haApi ha.HomeAssistantApi) (MessageStore, error) {
+	res := &messageStore{config: cfg, haApi: haApi, messages: make([]*Message, 0)}
+	buf, err := os.ReadFile(cfg.GetMessagesFile())
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, eris.Wrap(err, "error reading messages file")
+	}
+	err = json.Unmarshal(buf, &res.messages)
+	if err != nil {
+		return nil, eris.Wrap(err, "couldn't parse messages file")
+	}
+	return res, nil
+}
+
+func (s *messageStore) save() error {
+	data, err := json.Marshal(s.messages)
+	if err != nil {
+		return eris.Wrap(err, "error serializing messages")
+	}
+	err = os.WriteFile(s.config.GetMessagesFile(), data, 0644)
+	if err != nil {
+		return eris.Wrap(err, "error writing to messages file")
+	}
+	return nil
+}
+
+// prune drops the expired messages, the file is rewritten with the next change
+func (s *messageStore) prune(now time.Time) {
+	s.messages = slices.DeleteFunc(s.messages, func(m *Message) bool {
+		return m.Expires != nil && !now.Before(*m.Expires)
+	})
+}
+
+func (s *messageStore) List() []*Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(time.Now())
+	return slices.Clone(s.messages)
+}
+
+func (s *messageStore) Active(now time.Time) []*Message {
+	haText := s.haMessage(now)
+	s.lock.Lock()
+	s.prune(now)
+	res := make([]*Message, 0, len(s.messages)+1)
+	for _, m := range s.messages {
+		if m.Schedule == nil || m.Schedule.covers(now) {
+			res = append(res, m)
+		}
+	}
+	s.lock.Unlock()
+	if haText != "" {
+		res = append(res, &Message{Id: haMessageId, Text: haText, Priority: PriorityNormal})
+	}
+	slices.SortStableFunc(res, func(a, b *Message) int {
+		if a.Priority != b.Priority {
+			return b.Priority - a.Priority
+		}
+		return a.Created.Compare(b.Created)
+	})
+	return res
+}
+
+// haMessage reads the Home Assistant entity once the poll interval has passed, the last text is kept if HA fails.
+// HA is called without the lock so that a slow server doesn't hold up the web page.
+func (s *messageStore) haMessage(now time.Time) string {
+	entity := s.config.GetMessagesHaEntity()
+	if entity == "" {
+		return ""
+	}
+	s.lock.Lock()
+	text := s.haText
+	poll := s.haPolledAt.IsZero() || now.Sub(s.haPolledAt) >= haPollInterval || now.Before(s.haPolledAt)
+	if poll {
+		s.haPolledAt = now
+	}
+	s.lock.Unlock()
+	if !poll {
+		return text
+	}
+	state, err := s.haApi.DownloadSensorValueFromHA(entity)
+	if err != nil {
+		println(eris.ToString(eris.Wrapf(err, "Error loading message from %s", entity), true))
+		return text
+	}
+	state = strings.TrimSpace(state)
+	if state == "unknown" || state == "unavailable" {
+		state = ""
+	}
+	s.lock.Lock()
+	s.haText = state
+	s.lock.Unlock()
+	return state
+}
+
+func (s *messageStore) Put(message *Message) (*Message, error) {
+	message.Text = strings.TrimSpace(message.Text)
+	if message.Text == "" {
+		return nil, eris.New("message text must not be empty")
+	}
+	if message.Priority < PriorityNormal || message.Priority > PriorityUrgent {
+		return nil, eris.Errorf("message priority must be 0, 1 or 2, got %d", message.Priority)
+	}
+	if message.Id == haMessageId {
+		return nil, eris.Errorf("message id '%s' is reserved for Home Assistant", haMessageId)
+	}
+	if message.Schedule != nil {
+		err := message.Schedule.check()
+		if err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	if message.Expires != nil && !now.Before(*message.Expires) {
+		return nil, eris.New("message has already expired")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(now)
+	if message.Id == "" {
+		message.Id = strconv.FormatInt(now.UnixNano(), 36)
+	}
+	message.Created = now
+	i := slices.IndexFunc(s.messages, func(m *Message) bool { return m.Id == message.Id })
+	if i >= 0 {
+		s.messages[i] = message
+	} else {
+		s.messages = append(s.messages, message)
+	}
+	return message, s.save()
+}
+
+func (s *messageStore) Remove(id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(time.Now())
+	i := slices.IndexFunc(s.messages, func(m *Message) bool { return m.Id == id })
+	if i < 0 {
+		return false, nil
+	}
+	s.messages = slices.Delete(s.messages, i, i+1)
+	return true, s.save()
+}
+
+// parseTimeOfDay parses HH:MM into the offset from the midnight
+func parseTimeOfDay(text string) (time.Duration, error) {
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, eris.Wrapf(err, "schedule hours must be given as HH:MM, got '%s'", text)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (sc *Schedule) check() error {
+	from, err := parseTimeOfDay(sc.From)
+	if err != nil {
+		return err
+	}
+	to, err := parseTimeOfDay(sc.To)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return eris.Errorf("schedule hours must not start and end at the same time %s", sc.From)
+	}
+	for _, weekday := range sc.Weekdays {
+		if weekday < 0 || weekday > 6 {
+			return eris.Errorf("schedule weekday must be from 0 (Sunday) to 6 (Saturday), got %d", weekday)
+		}
+	}
+	return nil
+}
+
+// covers checks the hours of today and, for the hours crossing the midnight, the ones started yesterday
+func (sc *Schedule) covers(now time.Time) bool {
+	from, err := parseTimeOfDay(sc.From)
+	if err != nil {
+		return false
+	}
+	to, err := parseTimeOfDay(sc.To)
+	if err != nil {
+		return false
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, day := range []time.Time{midnight, midnight.AddDate(0, 0, -1)} {
+		if len(sc.Weekdays) > 0 && !slices.Contains(sc.Weekdays, int(day.Weekday())) {
+			continue
+		}
+		start := day.Add(from)
+		end := day.Add(to)
+		if to < from {
+			end = day.AddDate(0, 0, 1).Add(to)
+		}
+		if !now.Before(start) && now.Before(end) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package messages
+
+import (
+	"errors"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type messagesConfig struct {
+	config.ConfigApi
+	file string
+}
+
+func (c *messagesConfig) GetMessagesFile() string {
+	return c.file
+}
+
+func (c *messagesConfig) GetMessagesHaEntity() string {
+	return "input_text.station_message"
+}
+
+type haEntity struct {
+	ha.HomeAssistantApi
+	state string
+	err   error
+	calls int
+}
+
+func (h *haEntity) DownloadSensorValueFromHA(sensorId string) (string, error) {
+	h.calls++
+	return h.state, h.err
+}
+
+func TestHaMessagePolling(t *testing.T) {
+	entity := &haEntity{state: "Put the bins out"}
+	store, err := NewMessageStore(&messagesConfig{file: filepath.Join(t.TempDir(), "messages.json")}, entity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		name     string
+		at       time.Duration
+		state    string
+		err      error
+		calls    int
+		expected string // empty if there is no message from HA
+	}{
+		{name: "first render", at: 0, state: "Put the bins out", calls: 1, expected: "Put the bins out"},
+		{name: "cached within the interval", at: 30 * time.Second, state: "changed", calls: 1, expected: "Put the bins out"},
+		{name: "polled after the interval", at: time.Minute, state: "changed", calls: 2, expected: "changed"},
+		{name: "last text kept when HA fails", at: 2 * time.Minute, err: errors.New("timeout"), calls: 3, expected: "changed"},
+		{name: "unavailable entity", at: 3 * time.Minute, state: "unavailable", calls: 4},
+		{name: "empty entity", at: 4 * time.Minute, state: " ", calls: 5},
+	}
+	for _, step := range steps {
+		entity.state, entity.err = step.state, step.err
+		active := store.Active(start.Add(step.at))
+		got := ""
+		if len(active) > 0 && active[0].Id == haMessageId {
+			got = active[0].Text
+		}
+		if got != step.expected || entity.calls != step.calls {
+			t.Errorf("%s: got '%s' after %d calls, expected '%s' after %d", step.name, got, entity.calls, step.expected, step.calls)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package specialdays
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/holidays"
+	"fkirill.org/eink-meteo-station/data/ics"
+	"fkirill.org/eink-meteo-station/i18n"
+	"slices"
+	"time"
+)
+
+// SpecialDaysProvider merges special days entered in the configuration with the public holidays of the selected
+// holiday packs and the ones imported from iCalendar feeds. Configured entries come first so that they take
+// precedence on days covered by several entries, a configured entry with the id of a pack holiday replaces it.
+type SpecialDaysProvider interface {
+	GetSpecialDays() []*config.SpecialDayOrInterval
+	// Version changes when the events of the iCalendar feeds change in the background
+	Version() uint64
+}
+
+// TimeProvider is the part of utils.TimeProvider used here, the renderable utils are not imported so that
+// the data packages don't depend on the display library
+type TimeProvider interface {
+	LocalNow() time.Time
+}
+
+type specialDaysProvider struct {
+	cfg          config.ConfigApi
+	icsProvider  ics.IcsFeedsProvider
+	translator   i18n.Translator
+	timeProvider TimeProvider
+}
+
+func (p *specialDaysProvider) GetSpecialDays() []*config.SpecialDayOrInterval {
+	res := make([]*config.SpecialDayOrInterval, 0)
+	res = append(res, p.cfg.GetSpecialDays()...)
+	res = append(res, p.publicHolidays(res)...)
+	res = append(res, p.icsProvider.GetSpecialDays()...)
+	return res
+}
+
+func (p *specialDaysProvider) Version() uint64 {
+	return p.icsProvider.Version()
+}
+
+// publicHolidays generates pack holidays for the previous, the current and the next year, which is enough
+// for everything shown on the screen
+func (p *specialDaysProvider) publicHolidays(configured []*config.SpecialDayOrInterval) []*config.SpecialDayOrInterval {
+	hidden := p.cfg.GetHiddenHolidays()
+	res := make([]*config.SpecialDayOrInterval, 0)
+	year := p.timeProvider.LocalNow().Year()
+	for _, code := range p.cfg.GetHolidayPacks() {
+		pack := holidays.FindPack(code)
+		if pack == nil {
+			println("Unknown holiday pack '" + code + "'")
+			continue
+		}
+		for y := year - 1; y <= year+1; y++ {
+			for _, sd := range holidays.Generate(pack, y, p.translator) {
+				if slices.Contains(hidden, sd.Id) || slices.ContainsFunc(configured, func(c *config.SpecialDayOrInterval) bool {
+					return c.Id == sd.Id
+				}) {
+					continue
+				}
+				res = append(res, sd)
+			}
+		}
+	}
+	return res
+}
+
+func NewSpecialDaysProvider(
+	cfg config.ConfigApi,
+	icsProvider ics.IcsFeedsProvider,
+	translator i18n.Translator,
+	timeProvider TimeProvider,
+) SpecialDaysProvider {
+	return &specialDaysProvider{cfg: cfg, icsProvider: icsProvider, translator: translator, timeProvider: timeProvider}
+}
@@ -0,0 +1,238 @@
+package specialdays
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/holidays"
+	"sort"
+	"time"
+)
+
+// Occurrence is a single appearance of a special day on the calendar, Start and End are inclusive dates
+// (midnight UTC), both are the same for single-day entries.
+type Occurrence struct {
+	SpecialDay *config.SpecialDayOrInterval
+	Start      time.Time
+	End        time.Time
+}
+
+func (o *Occurrence) Covers(day time.Time) bool {
+	return !day.Before(o.Start) && !day.After(o.End)
+}
+
+func (o *Occurrence) IsMultiDay() bool {
+	return o.End.After(o.Start)
+}
+
+// upper bound for the number of recurrence periods checked in a single call, protects against broken rules
+const maxPeriods = 100000
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Resolve returns occurrences of all special days overlapping the [from, to] date range.
+// Occurrences are returned in the order of the special days list, so that the first covering occurrence
+// for a date is the one which takes precedence.
+func Resolve(specialDays []*config.SpecialDayOrInterval, from, to time.Time) []*Occurrence {
+	res := make([]*Occurrence, 0)
+	for _, sd := range specialDays {
+		res = append(res, Occurrences(sd, from, to)...)
+	}
+	return res
+}
+
+// SortByStart sorts occurrences chronologically, keeping the list order for the ones starting on the same date
+func SortByStart(occurrences []*Occurrence) {
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Start.Before(occurrences[j].Start)
+	})
+}
+
+// FirstCovering returns the first occurrence covering the given date or nil if there is none
+func FirstCovering(occurrences []*Occurrence, day time.Time) *Occurrence {
+	for _, o := range occurrences {
+		if o.Covers(day) {
+			return o
+		}
+	}
+	return nil
+}
+
+// Occurrences returns all occurrences of a single special day overlapping the [from, to] date range
+func Occurrences(sd *config.SpecialDayOrInterval, from, to time.Time) []*Occurrence {
+	from = date(from.Year(), from.Month(), from.Day())
+	to = date(to.Year(), to.Month(), to.Day())
+	res := make([]*Occurrence, 0)
+	add := func(start, end time.Time) {
+		if !end.Before(from) && !start.After(to) {
+			res = append(res, &Occurrence{SpecialDay: sd, Start: start, End: end})
+		}
+	}
+	switch sd.Type {
+	case "once_off":
+		start := date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay)
+		add(start, start)
+	case "annual":
+		for year := from.Year(); year <= to.Year(); year++ {
+			start := date(year, time.Month(sd.StartDateMonth), sd.StartDateDay)
+			// 29th of February only happens in leap years
+			if start.Day() == sd.StartDateDay {
+				add(start, start)
+			}
+		}
+	case "interval":
+		start := date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay)
+		end := date(sd.EndDateYear, time.Month(sd.EndDateMonth), sd.EndDateDay)
+		add(start, end)
+	case "recurring":
+		if sd.Recurrence == nil {
+			return res
+		}
+		duration := durationDays(sd.Recurrence)
+		// the occurrences starting before the range still overlap it if they last long enough
+		for _, start := range recurrenceDates(sd, sd.Recurrence, from.AddDate(0, 0, 1-duration), to) {
+			add(start, start.AddDate(0, 0, duration-1))
+		}
+	}
+	return res
+}
+
+func durationDays(r *config.Recurrence) int {
+	if r.DurationDays < 1 {
+		return 1
+	}
+	return r.DurationDays
+}
+
+// recurrenceDates enumerates start dates of a recurring special day up to the given date, honouring the count
+// and until limits. Without the count the periods before the from date are skipped, the dates before it may still
+// be returned. With the count all the occurrences from the first one have to be counted, the count bounds the walk.
+func recurrenceDates(sd *config.SpecialDayOrInterval, r *config.Recurrence, from, to time.Time) []time.Time {
+	anchor := date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay)
+	var until time.Time
+	if r.UntilYear != 0 {
+		until = date(r.UntilYear, time.Month(r.UntilMonth), r.UntilDay)
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	except := make(map[time.Time]bool, len(r.ExceptDates))
+	for _, text := range r.ExceptDates {
+		if day, err := time.Parse(time.DateOnly, text); err == nil {
+			except[day] = true
+		}
+	}
+	firstPeriod := 0
+	if r.Count == 0 {
+		firstPeriod = periodsBefore(anchor, r, from) / interval
+	}
+	res := make([]time.Time, 0)
+	count := 0
+	for period := firstPeriod; period < firstPeriod+maxPeriods; period++ {
+		periodStart, candidates := periodDates(anchor, r, period*interval)
+		if periodStart.After(to) || (!until.IsZero() && periodStart.After(until)) {
+			break
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(anchor) {
+				continue
+			}
+			if !until.IsZero() && candidate.After(until) {
+				return res
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return res
+			}
+			if candidate.After(to) {
+				return res
+			}
+			if !except[candidate] {
+				res = append(res, candidate)
+			}
+		}
+	}
+	return res
+}
+
+// periodsBefore returns the number of whole periods of the frequency from the anchor to the one before the date
+// the given date is in, the candidates of the skipped periods all fall before the date
+func periodsBefore(anchor time.Time, r *config.Recurrence, day time.Time) int {
+	var res int
+	switch r.Frequency {
+	case "daily":
+		res = int(day.Sub(anchor).Hours() / 24)
+	case "weekly":
+		res = int(day.Sub(anchor).Hours()/24) / 7
+	case "monthly":
+		res = (day.Year()-anchor.Year())*12 + int(day.Month()-anchor.Month())
+	case "yearly":
+		res = day.Year() - anchor.Year()
+	case "easter":
+		// the offset may move the date into another year
+		offsetYears := r.EasterOffsetDays / 365
+		if offsetYears < 0 {
+			offsetYears = -offsetYears
+		}
+		res = day.Year() - anchor.Year() - offsetYears
+	}
+	return max(0, res-1)
+}
+
+// periodDates returns the first date of the n-th period after the anchor and the candidate dates within it
+func periodDates(anchor time.Time, r *config.Recurrence, n int) (time.Time, []time.Time) {
+	switch r.Frequency {
+	case "daily":
+		day := anchor.AddDate(0, 0, n)
+		return day, []time.Time{day}
+	case "weekly":
+		// weeks start on Monday like the RFC 5545 default
+		weekStart := anchor.AddDate(0, 0, -int((anchor.Weekday()+6)%7)+7*n)
+		res := make([]time.Time, 0, 7)
+		for _, weekday := range weekdays(anchor, r) {
+			res = append(res, weekStart.AddDate(0, 0, int((weekday+6)%7)))
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+		return weekStart, res
+	case "monthly":
+		monthStart := date(anchor.Year(), anchor.Month()+time.Month(n), 1)
+		return monthStart, dayInMonth(anchor, r, monthStart)
+	case "yearly":
+		monthStart := date(anchor.Year()+n, anchor.Month(), 1)
+		return date(anchor.Year()+n, time.January, 1), dayInMonth(anchor, r, monthStart)
+	case "easter":
+		year := anchor.Year() + n
+		return date(year, time.January, 1), []time.Time{holidays.EasterSunday(year).AddDate(0, 0, r.EasterOffsetDays)}
+	}
+	// unknown frequency, nothing to enumerate
+	return date(9999, time.December, 31), nil
+}
+
+func weekdays(anchor time.Time, r *config.Recurrence) []time.Weekday {
+	if len(r.Weekdays) == 0 {
+		return []time.Weekday{anchor.Weekday()}
+	}
+	res := make([]time.Weekday, 0, len(r.Weekdays))
+	for _, weekday := range r.Weekdays {
+		res = append(res, time.Weekday(weekday%7))
+	}
+	return res
+}
+
+// dayInMonth returns either the nth weekday of the month or the day of month matching the anchor date,
+// months too short for the anchor day (e.g. 31st) are skipped
+func dayInMonth(anchor time.Time, r *config.Recurrence, monthStart time.Time) []time.Time {
+	if r.WeekOfMonth == 0 {
+		day := date(monthStart.Year(), monthStart.Month(), anchor.Day())
+		if day.Month() != monthStart.Month() {
+			return nil
+		}
+		return []time.Time{day}
+	}
+	day, ok := holidays.NthWeekday(monthStart.Year(), monthStart.Month(), weekdays(anchor, r)[0], r.WeekOfMonth)
+	if !ok {
+		return nil
+	}
+	return []time.Time{day}
+}
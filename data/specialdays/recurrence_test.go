@@ -0,0 +1,178 @@
+package specialdays
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"testing"
+	"time"
+)
+
+func recurring(year int, month time.Month, day int, r *config.Recurrence) *config.SpecialDayOrInterval {
+	return &config.SpecialDayOrInterval{
+		Id:             "test",
+		Type:           "recurring",
+		StartDateYear:  year,
+		StartDateMonth: int(month),
+		StartDateDay:   day,
+		Recurrence:     r,
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name       string
+		specialDay *config.SpecialDayOrInterval
+		from, to   time.Time
+		expected   []string // YYYY-MM-DD start dates, with /YYYY-MM-DD end dates for the multi-day ones
+	}{
+		{
+			name:       "last Monday of May",
+			specialDay: recurring(2020, time.May, 25, &config.Recurrence{Frequency: "yearly", Weekdays: []int{1}, WeekOfMonth: -1}),
+			from:       date(2024, time.January, 1),
+			to:         date(2026, time.December, 31),
+			expected:   []string{"2024-05-27", "2025-05-26", "2026-05-25"},
+		},
+		{
+			name:       "second Sunday of May",
+			specialDay: recurring(2020, time.May, 10, &config.Recurrence{Frequency: "yearly", Weekdays: []int{0}, WeekOfMonth: 2}),
+			from:       date(2024, time.January, 1),
+			to:         date(2025, time.December, 31),
+			expected:   []string{"2024-05-12", "2025-05-11"},
+		},
+		{
+			name:       "fifth Friday only in the months which have one",
+			specialDay: recurring(2024, time.March, 29, &config.Recurrence{Frequency: "monthly", Weekdays: []int{5}, WeekOfMonth: 5}),
+			from:       date(2024, time.March, 1),
+			to:         date(2024, time.September, 30),
+			expected:   []string{"2024-03-29", "2024-05-31", "2024-08-30"},
+		},
+		{
+			name:       "every second year from the start date",
+			specialDay: recurring(2021, time.July, 10, &config.Recurrence{Frequency: "yearly", Interval: 2}),
+			from:       date(2024, time.January, 1),
+			to:         date(2027, time.December, 31),
+			expected:   []string{"2025-07-10", "2027-07-10"},
+		},
+		{
+			name:       "29th of February yearly only in the leap years",
+			specialDay: recurring(2020, time.February, 29, &config.Recurrence{Frequency: "yearly"}),
+			from:       date(2021, time.January, 1),
+			to:         date(2028, time.December, 31),
+			expected:   []string{"2024-02-29", "2028-02-29"},
+		},
+		{
+			name:       "31st monthly skips the short months",
+			specialDay: recurring(2024, time.January, 31, &config.Recurrence{Frequency: "monthly"}),
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.June, 30),
+			expected:   []string{"2024-01-31", "2024-03-31", "2024-05-31"},
+		},
+		{
+			name:       "Easter Sunday in known years",
+			specialDay: recurring(2000, time.April, 23, &config.Recurrence{Frequency: "easter"}),
+			from:       date(2019, time.January, 1),
+			to:         date(2025, time.December, 31),
+			expected:   []string{"2019-04-21", "2020-04-12", "2021-04-04", "2022-04-17", "2023-04-09", "2024-03-31", "2025-04-20"},
+		},
+		{
+			name:       "Good Friday with the Easter offset",
+			specialDay: recurring(2000, time.April, 21, &config.Recurrence{Frequency: "easter", EasterOffsetDays: -2}),
+			from:       date(2038, time.January, 1),
+			to:         date(2038, time.December, 31),
+			expected:   []string{"2038-04-23"},
+		},
+		{
+			name:       "fortnightly on two weekdays",
+			specialDay: recurring(2024, time.January, 1, &config.Recurrence{Frequency: "weekly", Interval: 2, Weekdays: []int{1, 4}}),
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.January, 31),
+			expected:   []string{"2024-01-01", "2024-01-04", "2024-01-15", "2024-01-18", "2024-01-29"},
+		},
+		{
+			name:       "daily from a distant start date",
+			specialDay: recurring(2000, time.January, 1, &config.Recurrence{Frequency: "daily", Interval: 3}),
+			from:       date(2024, time.March, 1),
+			to:         date(2024, time.March, 7),
+			expected:   []string{"2024-03-01", "2024-03-04", "2024-03-07"},
+		},
+		{
+			name:       "count is taken from the first occurrence",
+			specialDay: recurring(2024, time.January, 8, &config.Recurrence{Frequency: "weekly", Count: 4}),
+			from:       date(2024, time.January, 20),
+			to:         date(2024, time.March, 31),
+			expected:   []string{"2024-01-22", "2024-01-29"},
+		},
+		{
+			name:       "until date is inclusive",
+			specialDay: recurring(2024, time.January, 15, &config.Recurrence{Frequency: "monthly", UntilYear: 2024, UntilMonth: 3, UntilDay: 15}),
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.December, 31),
+			expected:   []string{"2024-01-15", "2024-02-15", "2024-03-15"},
+		},
+		{
+			name:       "except dates are left out",
+			specialDay: recurring(2024, time.January, 1, &config.Recurrence{Frequency: "weekly", ExceptDates: []string{"2024-01-08"}}),
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.January, 15),
+			expected:   []string{"2024-01-01", "2024-01-15"},
+		},
+		{
+			name:       "multi-day occurrence starting before the range",
+			specialDay: recurring(2023, time.December, 30, &config.Recurrence{Frequency: "yearly", DurationDays: 5}),
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.January, 31),
+			expected:   []string{"2023-12-30/2024-01-03"},
+		},
+		{
+			name:       "unknown frequency",
+			specialDay: recurring(2024, time.January, 1, &config.Recurrence{Frequency: "hourly"}),
+			from:       date(2024, time.January, 1),
+			to:         date(2024, time.January, 31),
+			expected:   []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, o := range Occurrences(test.specialDay, test.from, test.to) {
+				text := o.Start.Format(time.DateOnly)
+				if o.IsMultiDay() {
+					text += "/" + o.End.Format(time.DateOnly)
+				}
+				got = append(got, text)
+			}
+			if len(got) != len(test.expected) {
+				t.Fatalf("got %v, expected %v", got, test.expected)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Fatalf("got %v, expected %v", got, test.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	specialDays := []*config.SpecialDayOrInterval{
+		{Id: "term break", Type: "interval", StartDateYear: 2024, StartDateMonth: 4, StartDateDay: 13, EndDateYear: 2024, EndDateMonth: 4, EndDateDay: 28},
+		{Id: "anzac", Type: "annual", StartDateMonth: 4, StartDateDay: 25},
+		{Id: "leap", Type: "annual", StartDateMonth: 2, StartDateDay: 29},
+		{Id: "trip", Type: "once_off", StartDateYear: 2024, StartDateMonth: 4, StartDateDay: 20},
+	}
+	occurrences := Resolve(specialDays, date(2024, time.April, 1), date(2024, time.April, 30))
+	if len(occurrences) != 3 {
+		t.Fatalf("got %d occurrences, expected 3", len(occurrences))
+	}
+	// the first one in the list takes precedence on the dates covered by several
+	if o := FirstCovering(occurrences, date(2024, time.April, 25)); o == nil || o.SpecialDay.Id != "term break" {
+		t.Errorf("got %+v covering the 25th, expected the term break", o)
+	}
+	if o := FirstCovering(occurrences, date(2024, time.April, 29)); o != nil {
+		t.Errorf("got %+v covering the 29th, expected none", o)
+	}
+	SortByStart(occurrences)
+	ids := []string{occurrences[0].SpecialDay.Id, occurrences[1].SpecialDay.Id, occurrences[2].SpecialDay.Id}
+	if ids[0] != "term break" || ids[1] != "trip" || ids[2] != "anzac" {
+		t.Errorf("got %v sorted by the start", ids)
+	}
+}
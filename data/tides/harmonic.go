@@ -0,0 +1,228 @@
+package tides
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"github.com/rotisserie/eris"
+	"math"
+	"strings"
+	"time"
+)
+
+// The tide is the sum of the cosines of the constituents, h = Z0 + sum(f * H * cos(V + u - G)), where V is
+// the equilibrium argument from the positions of the Sun and the Moon, f and u are the nodal corrections
+// over the 18.6 year lunar node cycle and H and G are the amplitude and the Greenwich phase lag of the station.
+// The arguments and the nodal corrections follow Schureman, Manual of Harmonic Analysis and Prediction of Tides,
+// https://tidesandcurrents.noaa.gov/publications/SpecialPubNo98.pdf
+
+// astronomical arguments in degrees
+const (
+	argT  = iota // hour angle of the mean Sun
+	argS         // mean longitude of the Moon
+	argH         // mean longitude of the Sun
+	argP         // longitude of the lunar perigee
+	argN         // longitude of the lunar ascending node
+	argP1        // longitude of the solar perigee
+)
+
+// astronomicalArguments are the mean longitudes at the time, the polynomials are from Meeus, Astronomical Algorithms
+func astronomicalArguments(t time.Time) [6]float64 {
+	t = t.UTC()
+	jd := float64(t.Unix())/86400.0 + 2440587.5
+	c := (jd - 2451545.0) / 36525.0
+	hours := float64(t.Hour()) + float64(t.Minute())/60.0 + (float64(t.Second())+float64(t.Nanosecond())/1e9)/3600.0
+	return [6]float64{
+		180.0 + 15.0*hours,
+		218.3164477 + 481267.88123421*c,
+		280.46646 + 36000.76983*c,
+		83.3532465 + 4069.0137287*c,
+		125.04452 - 1934.136261*c,
+		282.93735 + 1.71946*c,
+	}
+}
+
+// speeds of the arguments in degrees per hour
+var argumentSpeeds = [6]float64{15.0, 0.5490165, 0.0410686, 0.0046418, -0.0022064, 0.0000020}
+
+// nodal returns the node factor f and the nodal angle u in degrees for the longitude of the node
+type nodal func(n float64) (float64, float64)
+
+func noNodal(_ float64) (float64, float64) {
+	return 1, 0
+}
+
+func nodalM2(n float64) (float64, float64) {
+	return 1.0004 - 0.0373*cosDeg(n) + 0.0002*cosDeg(2*n), -2.14 * sinDeg(n)
+}
+
+func nodalK1(n float64) (float64, float64) {
+	return 1.0060 + 0.1150*cosDeg(n) - 0.0088*cosDeg(2*n) + 0.0006*cosDeg(3*n),
+		-8.86*sinDeg(n) + 0.68*sinDeg(2*n) - 0.07*sinDeg(3*n)
+}
+
+func nodalO1(n float64) (float64, float64) {
+	return 1.0089 + 0.1871*cosDeg(n) - 0.0147*cosDeg(2*n) + 0.0014*cosDeg(3*n),
+		10.80*sinDeg(n) - 1.34*sinDeg(2*n) + 0.19*sinDeg(3*n)
+}
+
+func nodalK2(n float64) (float64, float64) {
+	return 1.0241 + 0.2863*cosDeg(n) + 0.0083*cosDeg(2*n) - 0.0015*cosDeg(3*n),
+		-17.74*sinDeg(n) + 0.68*sinDeg(2*n) - 0.04*sinDeg(3*n)
+}
+
+func nodalJ1(n float64) (float64, float64) {
+	return 1.1029 + 0.1676*cosDeg(n) - 0.0170*cosDeg(2*n) + 0.0016*cosDeg(3*n),
+		-12.94*sinDeg(n) + 1.34*sinDeg(2*n) - 0.19*sinDeg(3*n)
+}
+
+func nodalMf(n float64) (float64, float64) {
+	return 1.043 + 0.414*cosDeg(n), -23.7*sinDeg(n) + 2.7*sinDeg(2*n) - 0.4*sinDeg(3*n)
+}
+
+func nodalMm(n float64) (float64, float64) {
+	return 1.000 - 0.130*cosDeg(n), 0
+}
+
+// nodalInverseM2 is for 2SM2 which is S2 twice less M2, f of M2 stays and u changes the sign
+func nodalInverseM2(n float64) (float64, float64) {
+	f, u := nodalM2(n)
+	return f, -u
+}
+
+// nodalPower is used by the shallow water and the compound constituents, e.g. M4 is M2 squared
+func nodalPower(base nodal, power float64) nodal {
+	return func(n float64) (float64, float64) {
+		f, u := base(n)
+		return math.Pow(f, power), u * power
+	}
+}
+
+type constituent struct {
+	doodson [6]float64 // multipliers of the astronomical arguments
+	offset  float64    // degrees
+	nodal   nodal
+}
+
+func (c *constituent) speed() float64 {
+	res := 0.0
+	for i, d := range c.doodson {
+		res += d * argumentSpeeds[i]
+	}
+	return res
+}
+
+// constituents are the ones published for most of the stations, L2 takes the nodal correction of M2
+// which is a few centimetres off at most
+var constituents = map[string]*constituent{
+	"M2":   {doodson: [6]float64{2, -2, 2, 0, 0, 0}, nodal: nodalM2},
+	"S2":   {doodson: [6]float64{2, 0, 0, 0, 0, 0}, nodal: noNodal},
+	"N2":   {doodson: [6]float64{2, -3, 2, 1, 0, 0}, nodal: nodalM2},
+	"K2":   {doodson: [6]float64{2, 0, 2, 0, 0, 0}, nodal: nodalK2},
+	"2N2":  {doodson: [6]float64{2, -4, 2, 2, 0, 0}, nodal: nodalM2},
+	"MU2":  {doodson: [6]float64{2, -4, 4, 0, 0, 0}, nodal: nodalM2},
+	"NU2":  {doodson: [6]float64{2, -3, 4, -1, 0, 0}, nodal: nodalM2},
+	"L2":   {doodson: [6]float64{2, -1, 2, -1, 0, 0}, offset: 180, nodal: nodalM2},
+	"T2":   {doodson: [6]float64{2, 0, -1, 0, 0, 1}, nodal: noNodal},
+	"K1":   {doodson: [6]float64{1, 0, 1, 0, 0, 0}, offset: -90, nodal: nodalK1},
+	"O1":   {doodson: [6]float64{1, -2, 1, 0, 0, 0}, offset: 90, nodal: nodalO1},
+	"P1":   {doodson: [6]float64{1, 0, -1, 0, 0, 0}, offset: 90, nodal: noNodal},
+	"Q1":   {doodson: [6]float64{1, -3, 1, 1, 0, 0}, offset: 90, nodal: nodalO1},
+	"J1":   {doodson: [6]float64{1, 1, 1, -1, 0, 0}, offset: -90, nodal: nodalJ1},
+	"M4":   {doodson: [6]float64{4, -4, 4, 0, 0, 0}, nodal: nodalPower(nodalM2, 2)},
+	"MS4":  {doodson: [6]float64{4, -2, 2, 0, 0, 0}, nodal: nodalM2},
+	"MN4":  {doodson: [6]float64{4, -5, 4, 1, 0, 0}, nodal: nodalPower(nodalM2, 2)},
+	"M6":   {doodson: [6]float64{6, -6, 6, 0, 0, 0}, nodal: nodalPower(nodalM2, 3)},
+	"M3":   {doodson: [6]float64{3, -3, 3, 0, 0, 0}, nodal: nodalPower(nodalM2, 1.5)},
+	"MF":   {doodson: [6]float64{0, 2, 0, 0, 0, 0}, nodal: nodalMf},
+	"MM":   {doodson: [6]float64{0, 1, 0, -1, 0, 0}, nodal: nodalMm},
+	"SSA":  {doodson: [6]float64{0, 0, 2, 0, 0, 0}, nodal: noNodal},
+	"SA":   {doodson: [6]float64{0, 0, 1, 0, 0, 0}, nodal: noNodal},
+	"S4":   {doodson: [6]float64{4, 0, 0, 0, 0, 0}, nodal: noNodal},
+	"2SM2": {doodson: [6]float64{2, 2, -2, 0, 0, 0}, nodal: nodalInverseM2},
+}
+
+func sinDeg(angleDeg float64) float64 {
+	return math.Sin(angleDeg * math.Pi / 180.0)
+}
+
+func cosDeg(angleDeg float64) float64 {
+	return math.Cos(angleDeg * math.Pi / 180.0)
+}
+
+type stationConstituent struct {
+	amplitude float64
+	phase     float64
+	*constituent
+}
+
+// harmonicModel predicts the tides of the station from its constituents
+type harmonicModel struct {
+	datum        float64
+	constituents []*stationConstituent
+}
+
+func newHarmonicModel(datum float64, configured []*config.TideConstituent) (*harmonicModel, error) {
+	res := &harmonicModel{datum: datum, constituents: make([]*stationConstituent, 0, len(configured))}
+	for _, c := range configured {
+		known, exists := constituents[strings.ToUpper(c.Name)]
+		if !exists {
+			return nil, eris.Errorf("unknown tide constituent '%s'", c.Name)
+		}
+		res.constituents = append(res.constituents, &stationConstituent{amplitude: c.Amplitude, phase: c.Phase, constituent: known})
+	}
+	return res, nil
+}
+
+// levelAndSlope returns the height and its change per hour, the sign of the change tells the high and the low tides apart
+func (m *harmonicModel) levelAndSlope(t time.Time) (float64, float64) {
+	args := astronomicalArguments(t)
+	height, slope := m.datum, 0.0
+	for _, c := range m.constituents {
+		f, u := c.nodal(args[argN])
+		v := c.offset
+		for i, d := range c.doodson {
+			v += d * args[i]
+		}
+		angle := v + u - c.phase
+		height += f * c.amplitude * cosDeg(angle)
+		slope -= f * c.amplitude * c.speed() * math.Pi / 180.0 * sinDeg(angle)
+	}
+	return height, slope
+}
+
+func (m *harmonicModel) height(t time.Time) (float64, error) {
+	h, _ := m.levelAndSlope(t)
+	return h, nil
+}
+
+// the tides are looked for in the steps shorter than the time between a high and a low tide of
+// the shallow water constituents, then refined to a few seconds
+const (
+	searchStep      = 10 * time.Minute
+	searchPrecision = 5 * time.Second
+)
+
+func (m *harmonicModel) tides(from, to time.Time) ([]*Tide, error) {
+	res := make([]*Tide, 0)
+	_, previousSlope := m.levelAndSlope(from)
+	for t := from; t.Before(to); t = t.Add(searchStep) {
+		next := t.Add(searchStep)
+		_, slope := m.levelAndSlope(next)
+		if (previousSlope > 0) != (slope > 0) {
+			// bisection of the slope between the steps
+			lo, hi := t, next
+			for hi.Sub(lo) > searchPrecision {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, s := m.levelAndSlope(mid); (s > 0) == (previousSlope > 0) {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			at := lo.Add(hi.Sub(lo) / 2).Round(time.Minute)
+			height, _ := m.levelAndSlope(at)
+			res = append(res, &Tide{Time: at, Height: height, High: previousSlope > 0})
+		}
+		previousSlope = slope
+	}
+	return res, nil
+}
@@ -0,0 +1,127 @@
+package tides
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNodalCorrections(t *testing.T) {
+	for _, n := range []float64{0, 45, 90, 200, 300} {
+		fM2, uM2 := nodalM2(n)
+		tests := []struct {
+			name string
+			f, u float64
+		}{
+			{"2SM2", fM2, -uM2},
+			{"M4", fM2 * fM2, 2 * uM2},
+			{"MS4", fM2, uM2},
+			{"S2", 1, 0},
+		}
+		for _, test := range tests {
+			f, u := constituents[test.name].nodal(n)
+			if math.Abs(f-test.f) > 1e-9 || math.Abs(u-test.u) > 1e-9 {
+				t.Errorf("%s at the node longitude %v: got f %f and u %f, expected %f and %f", test.name, n, f, u, test.f, test.u)
+			}
+		}
+	}
+}
+
+func TestSlope(t *testing.T) {
+	model, err := newHarmonicModel(1.2, []*config.TideConstituent{
+		{Name: "M2", Amplitude: 0.54, Phase: 331},
+		{Name: "S2", Amplitude: 0.13, Phase: 334},
+		{Name: "K1", Amplitude: 0.37, Phase: 106},
+		{Name: "O1", Amplitude: 0.23, Phase: 89},
+		{Name: "M4", Amplitude: 0.02, Phase: 150},
+		{Name: "2SM2", Amplitude: 0.01, Phase: 40},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the slope is the derivative of the height per hour
+	for at := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC); at.Before(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)); at = at.Add(97 * time.Minute) {
+		before, _ := model.levelAndSlope(at.Add(-time.Minute))
+		after, _ := model.levelAndSlope(at.Add(time.Minute))
+		_, slope := model.levelAndSlope(at)
+		if expected := (after - before) * 30; math.Abs(slope-expected) > 1e-4 {
+			t.Errorf("at %s: got slope %f, expected %f", at.Format(time.RFC3339), slope, expected)
+		}
+	}
+}
+
+func TestHarmonicTides(t *testing.T) {
+	// the spring and the neap tides are checked against the moon phases of January 2024 published in UTC,
+	// the mean longitudes are a few degrees off the true ones so the heights are a bit off the sums
+	newMoon := time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC)
+	firstQuarter := time.Date(2024, 1, 18, 3, 52, 0, 0, time.UTC)
+	fM2, _ := nodalM2(astronomicalArguments(newMoon)[argN])
+	m2Period := time.Duration(360 / constituents["M2"].speed() * float64(time.Hour))
+	tests := []struct {
+		name         string
+		datum        float64
+		constituents []*config.TideConstituent
+		from, to     time.Time
+		highs        []float64 // the heights of the high tides, the lows are the opposite around the datum
+		tolerance    float64
+	}{
+		{
+			name: "M2 alone, the high and the low tides are half of its period apart", datum: 2,
+			constituents: []*config.TideConstituent{{Name: "M2", Amplitude: 1}},
+			from:         newMoon.Add(-12 * time.Hour), to: newMoon.Add(12 * time.Hour),
+			highs:     []float64{2 + fM2},
+			tolerance: 1e-4,
+		},
+		{
+			name:         "spring tide at the new moon",
+			constituents: []*config.TideConstituent{{Name: "M2", Amplitude: 1}, {Name: "S2", Amplitude: 0.5}},
+			from:         newMoon.Add(-m2Period / 2), to: newMoon.Add(m2Period / 2),
+			highs:     []float64{fM2 + 0.5},
+			tolerance: 0.02,
+		},
+		{
+			name:         "neap tide at the first quarter",
+			constituents: []*config.TideConstituent{{Name: "M2", Amplitude: 1}, {Name: "S2", Amplitude: 0.5}},
+			from:         firstQuarter.Add(-m2Period / 2), to: firstQuarter.Add(m2Period / 2),
+			highs:     []float64{fM2 - 0.5},
+			tolerance: 0.02,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model, err := newHarmonicModel(test.datum, test.constituents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tides, err := model.tides(test.from, test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			highs := make([]float64, 0)
+			for i, tide := range tides {
+				if i > 0 {
+					if tide.High == tides[i-1].High {
+						t.Errorf("two %v tides in a row at %s", tide.High, tide.Time.Format(time.RFC3339))
+					}
+					if gap := tide.Time.Sub(tides[i-1].Time) - m2Period/2; len(test.constituents) == 1 && gap.Abs() > time.Minute {
+						t.Errorf("got %s between the tides, expected half of the M2 period", tide.Time.Sub(tides[i-1].Time))
+					}
+				}
+				if tide.High {
+					highs = append(highs, tide.Height)
+				} else if len(test.constituents) == 1 && math.Abs(2*test.datum-tide.Height-test.highs[0]) > test.tolerance {
+					t.Errorf("got low tide %f at %s, expected %f", tide.Height, tide.Time.Format(time.RFC3339), 2*test.datum-test.highs[0])
+				}
+			}
+			if len(highs) != len(test.highs) {
+				t.Fatalf("got high tides %v, expected %v", highs, test.highs)
+			}
+			for i, height := range highs {
+				if math.Abs(height-test.highs[i]) > test.tolerance {
+					t.Errorf("got high tide %f, expected %f", height, test.highs[i])
+				}
+			}
+		})
+	}
+}
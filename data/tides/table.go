@@ -0,0 +1,142 @@
+package tides
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/rotisserie/eris"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tableModel takes the high and the low tides from the table, the water level between them follows
+// the half of a cosine which is close to the real curve away from the estuaries
+type tableModel struct {
+	events []*Tide // by the time
+}
+
+type tableRow struct {
+	Time   string  `json:"time"`
+	Height float64 `json:"height"`
+	Type   string  `json:"type"` // high or low, optional
+}
+
+// the time formats of the table, the times without the offset are in the local time
+var tableTimeFormats = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02 15:04:05"}
+
+func parseTableTime(text string) (time.Time, error) {
+	for _, format := range tableTimeFormats {
+		if t, err := time.ParseInLocation(format, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, eris.Errorf("wrong tide time '%s', expected e.g. 2006-01-02 15:04 or RFC 3339", text)
+}
+
+// parseTable reads a JSON array of the rows or CSV lines of the time, the height and the optional type,
+// the CSV header and the lines starting with # are skipped
+func parseTable(content []byte) (*tableModel, error) {
+	rows := make([]*tableRow, 0)
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		err := json.Unmarshal(trimmed, &rows)
+		if err != nil {
+			return nil, eris.Wrap(err, "couldn't parse tide table")
+		}
+	} else {
+		records := csv.NewReader(bytes.NewReader(content))
+		records.FieldsPerRecord = -1
+		records.Comment = '#'
+		records.TrimLeadingSpace = true
+		for line := 1; ; line++ {
+			record, err := records.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, eris.Wrap(err, "couldn't read tide table")
+			}
+			if len(record) < 2 {
+				return nil, eris.Errorf("tide table line %d has no height", line)
+			}
+			height, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+			if err != nil {
+				if line == 1 {
+					continue // the header
+				}
+				return nil, eris.Wrapf(err, "wrong height on tide table line %d", line)
+			}
+			row := &tableRow{Time: strings.TrimSpace(record[0]), Height: height}
+			if len(record) > 2 {
+				row.Type = strings.TrimSpace(record[2])
+			}
+			rows = append(rows, row)
+		}
+	}
+	// the tides without the type are told apart by the neighbours once they are sorted
+	typed := make(map[*Tide]bool, len(rows))
+	res := &tableModel{events: make([]*Tide, 0, len(rows))}
+	for _, row := range rows {
+		t, err := parseTableTime(row.Time)
+		if err != nil {
+			return nil, err
+		}
+		tide := &Tide{Time: t, Height: row.Height}
+		switch strings.ToLower(row.Type) {
+		case "high", "h", "hw":
+			tide.High = true
+			typed[tide] = true
+		case "low", "l", "lw":
+			typed[tide] = true
+		case "":
+		default:
+			return nil, eris.Errorf("wrong tide type '%s', expected high or low", row.Type)
+		}
+		res.events = append(res.events, tide)
+	}
+	if len(res.events) < 2 {
+		return nil, eris.New("tide table needs at least two tides")
+	}
+	slices.SortFunc(res.events, func(a, b *Tide) int {
+		return a.Time.Compare(b.Time)
+	})
+	for i, tide := range res.events {
+		if typed[tide] {
+			continue
+		}
+		neighbour := i + 1
+		if neighbour == len(res.events) {
+			neighbour = i - 1
+		}
+		tide.High = tide.Height > res.events[neighbour].Height
+	}
+	return res, nil
+}
+
+func (m *tableModel) height(t time.Time) (float64, error) {
+	i, found := slices.BinarySearchFunc(m.events, t, func(tide *Tide, t time.Time) int {
+		return tide.Time.Compare(t)
+	})
+	if found {
+		return m.events[i].Height, nil
+	}
+	if i == 0 || i == len(m.events) {
+		return 0, eris.Errorf("tide table only covers %s to %s", m.events[0].Time.Format(time.RFC3339), m.events[len(m.events)-1].Time.Format(time.RFC3339))
+	}
+	before, after := m.events[i-1], m.events[i]
+	fraction := float64(t.Sub(before.Time)) / float64(after.Time.Sub(before.Time))
+	return before.Height + (after.Height-before.Height)*(1-math.Cos(math.Pi*fraction))/2, nil
+}
+
+func (m *tableModel) tides(from, to time.Time) ([]*Tide, error) {
+	res := make([]*Tide, 0)
+	for _, tide := range m.events {
+		if !tide.Time.Before(from) && tide.Time.Before(to) {
+			res = append(res, tide)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,128 @@
+package tides
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseTable(t *testing.T) {
+	utc := func(text string) time.Time {
+		res, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	tests := []struct {
+		name     string
+		content  string
+		expected []*Tide
+		err      bool
+	}{
+		{
+			name: "CSV with the header, the comments and the types",
+			content: "time,height,type\n# Fort Denison\n2024-01-15T03:10:00Z, 1.62, high\n" +
+				"2024-01-15T09:25:00Z, 0.41, L\n2024-01-15T15:40:00Z, 1.48, HW\n",
+			expected: []*Tide{
+				{Time: utc("2024-01-15T03:10:00Z"), Height: 1.62, High: true},
+				{Time: utc("2024-01-15T09:25:00Z"), Height: 0.41},
+				{Time: utc("2024-01-15T15:40:00Z"), Height: 1.48, High: true},
+			},
+		},
+		{
+			name: "CSV without the types out of order",
+			content: "2024-01-15T09:25:00Z,0.41\n2024-01-15T03:10:00Z,1.62\n" +
+				"2024-01-15T21:50:00Z,0.55\n2024-01-15T15:40:00Z,1.48\n",
+			expected: []*Tide{
+				{Time: utc("2024-01-15T03:10:00Z"), Height: 1.62, High: true},
+				{Time: utc("2024-01-15T09:25:00Z"), Height: 0.41},
+				{Time: utc("2024-01-15T15:40:00Z"), Height: 1.48, High: true},
+				{Time: utc("2024-01-15T21:50:00Z"), Height: 0.55},
+			},
+		},
+		{
+			name: "JSON without the types",
+			content: `[{"time": "2024-01-15T03:10:00Z", "height": 1.62}, {"time": "2024-01-15T09:25:00Z", "height": 0.41},
+				{"time": "2024-01-15T15:40:00Z", "height": 1.48}]`,
+			expected: []*Tide{
+				{Time: utc("2024-01-15T03:10:00Z"), Height: 1.62, High: true},
+				{Time: utc("2024-01-15T09:25:00Z"), Height: 0.41},
+				{Time: utc("2024-01-15T15:40:00Z"), Height: 1.48, High: true},
+			},
+		},
+		{
+			name: "JSON with some of the types and the local times",
+			content: `[{"time": "2024-01-15 03:10", "height": 1.62}, {"time": "2024-01-15 09:25", "height": 0.41, "type": "low"},
+				{"time": "2024-01-15T15:40", "height": 1.48, "type": "high"}]`,
+			expected: []*Tide{
+				{Time: time.Date(2024, 1, 15, 3, 10, 0, 0, time.Local), Height: 1.62, High: true},
+				{Time: time.Date(2024, 1, 15, 9, 25, 0, 0, time.Local), Height: 0.41},
+				{Time: time.Date(2024, 1, 15, 15, 40, 0, 0, time.Local), Height: 1.48, High: true},
+			},
+		},
+		{name: "unknown type", content: "2024-01-15T03:10:00Z,1.62,flood\n2024-01-15T09:25:00Z,0.41,low\n", err: true},
+		{name: "one tide", content: `[{"time": "2024-01-15T03:10:00Z", "height": 1.62}]`, err: true},
+		{name: "wrong height", content: "2024-01-15T03:10:00Z,1.62\n2024-01-15T09:25:00Z,low\n", err: true},
+		{name: "wrong time", content: "15/01/2024 03:10,1.62\n15/01/2024 09:25,0.41\n", err: true},
+		{name: "broken JSON", content: `[{"time": "2024-01-15T03:10:00Z", "height": "high"}]`, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table, err := parseTable([]byte(test.content))
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(table.events) != len(test.expected) {
+				t.Fatalf("got %d tides, expected %d", len(table.events), len(test.expected))
+			}
+			for i, tide := range table.events {
+				expected := test.expected[i]
+				if !tide.Time.Equal(expected.Time) || tide.Height != expected.Height || tide.High != expected.High {
+					t.Errorf("got %s %f high %v, expected %s %f high %v", tide.Time.Format(time.RFC3339), tide.Height, tide.High,
+						expected.Time.Format(time.RFC3339), expected.Height, expected.High)
+				}
+			}
+		})
+	}
+}
+
+func TestTableHeight(t *testing.T) {
+	table, err := parseTable([]byte("2024-01-15T03:00:00Z,1.6\n2024-01-15T09:00:00Z,0.4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at       time.Time
+		expected float64
+		err      bool
+	}{
+		{at: time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC), expected: 1.6},
+		{at: time.Date(2024, 1, 15, 6, 0, 0, 0, time.UTC), expected: 1.0},
+		{at: time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC), expected: 1.6 - 1.2*(1-math.Cos(math.Pi/6))/2},
+		{at: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), expected: 0.4},
+		{at: time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC), err: true},
+		{at: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), err: true},
+	}
+	for _, test := range tests {
+		height, err := table.height(test.at)
+		if test.err {
+			if err == nil {
+				t.Errorf("at %s: expected an error outside of the table", test.at.Format(time.RFC3339))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(height-test.expected) > 1e-9 {
+			t.Errorf("at %s: got %f, expected %f", test.at.Format(time.RFC3339), height, test.expected)
+		}
+	}
+}
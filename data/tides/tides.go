@@ -0,0 +1,125 @@
+package tides
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/fetch"
+	"github.com/rotisserie/eris"
+	"time"
+)
+
+// Tide is a high or a low tide, the height is in the configured unit above the chart datum
+type Tide struct {
+	Time   time.Time
+	Height float64
+	High   bool
+}
+
+type TidePoint struct {
+	Time   time.Time
+	Height float64
+}
+
+type TideData struct {
+	Level  float64 // now
+	Rising bool
+	Tides  []*Tide      // the next ones
+	Curve  []*TidePoint // from now over the configured hours
+}
+
+// TideProvider computes the tides offline from the harmonic constituents or reads them from the table
+type TideProvider interface {
+	GetTides(now time.Time) (*TideData, error)
+}
+
+// tideModel is either the harmonic prediction or the table
+type tideModel interface {
+	height(t time.Time) (float64, error)
+	tides(from, to time.Time) ([]*Tide, error)
+}
+
+// the curve is drawn from the heights this far apart
+const curveStep = 15 * time.Minute
+
+// the tides are looked for this far ahead, there are about 4 a day
+const tidesWindow = 3 * 24 * time.Hour
+
+type tideProvider struct {
+	config   config.ConfigApi
+	harmonic *harmonicModel // nil if the tides are read from the table
+	table    *tableModel
+	loadedAt time.Time
+}
+
+func NewTideProvider(cfg config.ConfigApi) (TideProvider, error) {
+	settings := cfg.GetTides()
+	res := &tideProvider{config: cfg}
+	if len(settings.Constituents) > 0 {
+		harmonic, err := newHarmonicModel(settings.Datum, settings.Constituents)
+		if err != nil {
+			return nil, err
+		}
+		res.harmonic = harmonic
+	}
+	return res, nil
+}
+
+// getModel reads the table again once per refresh interval, the old table is kept if it can't be read
+func (p *tideProvider) getModel(settings *config.TideSettings, now time.Time) (tideModel, error) {
+	if p.harmonic != nil {
+		return p.harmonic, nil
+	}
+	if settings.Table == "" {
+		return nil, eris.New("tides need the constituents or the table")
+	}
+	if p.table != nil && now.Sub(p.loadedAt) < time.Duration(settings.RefreshMinutes)*time.Minute {
+		return p.table, nil
+	}
+	content, err := fetch.ReadSource(settings.Table)
+	var table *tableModel
+	if err == nil {
+		table, err = parseTable(content)
+	}
+	if err != nil {
+		if p.table == nil {
+			return nil, eris.Wrap(err, "Error loading tide table")
+		}
+		println(eris.ToString(eris.Wrap(err, "Error loading tide table, the old one is used"), true))
+		return p.table, nil
+	}
+	p.table = table
+	p.loadedAt = now
+	return table, nil
+}
+
+func (p *tideProvider) GetTides(now time.Time) (*TideData, error) {
+	settings := p.config.GetTides()
+	model, err := p.getModel(settings, now)
+	if err != nil {
+		return nil, err
+	}
+	res := &TideData{Curve: make([]*TidePoint, 0)}
+	res.Level, err = model.height(now)
+	if err != nil {
+		return nil, err
+	}
+	tides, err := model.tides(now, now.Add(tidesWindow))
+	if err != nil {
+		return nil, err
+	}
+	if len(tides) == 0 {
+		return nil, eris.Errorf("no tides ahead of %s", now.Format(time.RFC3339))
+	}
+	// the water rises towards the high tide
+	res.Rising = tides[0].High
+	res.Tides = tides[:min(len(tides), settings.MaxTides)]
+	end := now.Add(time.Duration(settings.CurveHours) * time.Hour)
+	for t := now; !t.After(end); t = t.Add(curveStep) {
+		height, err := model.height(t)
+		if err != nil {
+			// the table may end before the curve does
+			break
+		}
+		res.Curve = append(res.Curve, &TidePoint{Time: t, Height: height})
+	}
+	return res, nil
+}
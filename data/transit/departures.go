@@ -0,0 +1,138 @@
+package transit
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/fetch"
+	"github.com/rotisserie/eris"
+	"slices"
+	"time"
+)
+
+// Departure is a departure from a stop, the expected time differs from the scheduled one by the realtime delay
+type Departure struct {
+	Route     string
+	Headsign  string
+	Scheduled time.Time
+	Expected  time.Time
+	Realtime  bool // the expected time comes from the realtime feed
+	tripId    string
+	stopId    string
+	sequence  int
+}
+
+type StopDepartures struct {
+	Label      string
+	Departures []*Departure // the next ones by the expected time
+}
+
+type TransitDataProvider interface {
+	GetDepartures() ([]*StopDepartures, error)
+}
+
+// departures are looked for this far ahead, later ones are not shown anyway
+const departuresWindow = 4 * time.Hour
+
+// the trips delayed by up to this long can still be ahead
+const maxDelay = time.Hour
+
+// the realtime feed is read on every redraw, the timetable is shown rather than waiting for it
+const realtimeTimeout = 10 * time.Second
+
+// TimeProvider is the part of utils.TimeProvider used here, the renderable utils are not imported so that
+// the data packages don't depend on the display library
+type TimeProvider interface {
+	UtcNow() time.Time
+}
+
+type transitDataProvider struct {
+	config          config.ConfigApi
+	timeProvider    TimeProvider
+	realtimeTimeout time.Duration
+	static          *timetable
+	loadedAt        time.Time
+}
+
+func NewTransitDataProvider(cfg config.ConfigApi, timeProvider TimeProvider) TransitDataProvider {
+	return &transitDataProvider{config: cfg, timeProvider: timeProvider, realtimeTimeout: realtimeTimeout}
+}
+
+// getTimetable reloads the static feed once a day, the old timetable is kept if the feed can't be loaded
+func (t *transitDataProvider) getTimetable(settings *config.TransitSettings, now time.Time) (*timetable, error) {
+	if t.static != nil && now.Sub(t.loadedAt) < 24*time.Hour {
+		return t.static, nil
+	}
+	stopIds := make([]string, 0, len(settings.Stops))
+	for _, stop := range settings.Stops {
+		stopIds = append(stopIds, stop.Id)
+	}
+	content, err := fetch.ReadSource(settings.Gtfs)
+	var static *timetable
+	if err == nil {
+		static, err = loadTimetable(content, stopIds)
+	}
+	if err != nil {
+		if t.static == nil {
+			return nil, eris.Wrap(err, "Error loading GTFS feed")
+		}
+		println(eris.ToString(eris.Wrap(err, "Error reloading GTFS feed"), true))
+		// tried again in an hour
+		t.loadedAt = now.Add(-23 * time.Hour)
+		return t.static, nil
+	}
+	t.static = static
+	t.loadedAt = now
+	return static, nil
+}
+
+// getTripUpdates returns nil if there is no realtime feed or it is unavailable, the static timetable is shown then
+func (t *transitDataProvider) getTripUpdates(settings *config.TransitSettings) map[string]*tripUpdate {
+	if settings.Realtime == "" {
+		return nil
+	}
+	content, err := fetch.ReadSourceWithTimeout(settings.Realtime, t.realtimeTimeout)
+	var res map[string]*tripUpdate
+	if err == nil {
+		res, err = parseTripUpdates(content)
+	}
+	if err != nil {
+		println(eris.ToString(eris.Wrap(err, "Error loading GTFS-Realtime feed"), true))
+		return nil
+	}
+	return res
+}
+
+func (t *transitDataProvider) GetDepartures() ([]*StopDepartures, error) {
+	settings := t.config.GetTransit()
+	now := t.timeProvider.UtcNow()
+	static, err := t.getTimetable(settings, now)
+	if err != nil {
+		return nil, err
+	}
+	updates := t.getTripUpdates(settings)
+	earliest := now.Add(time.Duration(settings.WalkMinutes) * time.Minute)
+	res := make([]*StopDepartures, 0, len(settings.Stops))
+	for _, stop := range settings.Stops {
+		stopDepartures := &StopDepartures{Label: stop.Label, Departures: make([]*Departure, 0)}
+		if stopDepartures.Label == "" {
+			stopDepartures.Label = static.stopNames[stop.Id]
+		}
+		for _, departure := range static.scheduledDepartures(stop.Id, earliest.Add(-maxDelay), earliest.Add(departuresWindow)) {
+			if len(stop.Routes) > 0 && !slices.Contains(stop.Routes, departure.Route) {
+				continue
+			}
+			if update, exists := updates[departure.tripId]; exists && !update.apply(departure) {
+				continue
+			}
+			if departure.Expected.Before(earliest) {
+				continue
+			}
+			stopDepartures.Departures = append(stopDepartures.Departures, departure)
+		}
+		slices.SortFunc(stopDepartures.Departures, func(a, b *Departure) int {
+			return a.Expected.Compare(b.Expected)
+		})
+		stopDepartures.Departures = stopDepartures.Departures[:min(len(stopDepartures.Departures), settings.MaxDepartures)]
+		res = append(res, stopDepartures)
+	}
+	return res, nil
+}
@@ -0,0 +1,261 @@
+package transit
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// testdata/gtfs.zip is a small feed of two routes through Central Station with the stands A and B and Park Road,
+// testdata/trip_updates.pb is a GTFS-Realtime feed message with the updates of its trips on Monday 2024-01-15:
+//   - early, 07:55 from Central: its first stop at Park Road is 10 minutes late, the delay carries over
+//   - delayed, 08:10 from Central and 08:20 from Park Road: 5 minutes late and 1 minute early at the stops
+//   - canceled, 08:20 from Central
+//   - late, 08:30 from Central and 08:40 from Park Road: the whole trip is 2 minutes late
+//   - express, 08:15 from the stand B: leaves at 08:25 by the absolute time of the stop
+//   - unknown: a trip the timetable doesn't have
+
+type transitConfig struct {
+	config.ConfigApi
+	settings *config.TransitSettings
+}
+
+func (c *transitConfig) GetTransit() *config.TransitSettings {
+	return c.settings
+}
+
+type fixedTime struct {
+	now time.Time
+}
+
+func (f *fixedTime) UtcNow() time.Time {
+	return f.now
+}
+
+// feedServer serves the fixtures, the slow feed answers after the realtime timeout of the tests
+func feedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	static, err := os.ReadFile("testdata/gtfs.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	realtime, err := os.ReadFile("testdata/trip_updates.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gtfs.zip":
+			_, _ = w.Write(static)
+		case "/trip_updates.pb":
+			_, _ = w.Write(realtime)
+		case "/slow.pb":
+			time.Sleep(500 * time.Millisecond)
+			_, _ = w.Write(realtime)
+		case "/broken.pb":
+			_, _ = w.Write(realtime[:len(realtime)-3])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// departure is a departure as the widget shows it
+type departure struct {
+	route    string
+	headsign string
+	expected string // HH:MM
+	realtime bool
+}
+
+func checkDepartures(t *testing.T, got []*StopDepartures, expected map[string][]departure) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("got %d stops, expected %d", len(got), len(expected))
+	}
+	for _, stop := range got {
+		expectedDepartures, exists := expected[stop.Label]
+		if !exists {
+			t.Errorf("unexpected stop %s", stop.Label)
+			continue
+		}
+		gotDepartures := make([]departure, 0, len(stop.Departures))
+		for _, d := range stop.Departures {
+			gotDepartures = append(gotDepartures, departure{d.Route, d.Headsign, d.Expected.UTC().Format("15:04"), d.Realtime})
+		}
+		if len(gotDepartures) != len(expectedDepartures) {
+			t.Errorf("%s: got %v, expected %v", stop.Label, gotDepartures, expectedDepartures)
+			continue
+		}
+		for i := range gotDepartures {
+			if gotDepartures[i] != expectedDepartures[i] {
+				t.Errorf("%s: got %v, expected %v", stop.Label, gotDepartures, expectedDepartures)
+				break
+			}
+		}
+	}
+}
+
+var scheduledDepartures = map[string][]departure{
+	"Central Station": {
+		{"12", "Harbour", "08:10", false},
+		{"Airport Express", "Airport T5", "08:15", false},
+		{"12", "Harbour", "08:20", false},
+		{"12", "Harbour", "08:30", false},
+	},
+	"Park Road": {
+		{"12", "Harbour", "08:20", false},
+		{"12", "Harbour", "08:30", false},
+		{"12", "Harbour", "08:40", false},
+	},
+}
+
+func TestDepartures(t *testing.T) {
+	server := feedServer(t)
+	defer server.Close()
+	tests := []struct {
+		name     string
+		realtime string
+		expected map[string][]departure
+	}{
+		{
+			name:     "delays of the realtime feed",
+			realtime: "/trip_updates.pb",
+			expected: map[string][]departure{
+				"Central Station": {
+					{"12", "Harbour", "08:05", true},
+					{"12", "Harbour", "08:15", true},
+					{"Airport Express", "Airport T5", "08:25", true},
+					{"12", "Harbour", "08:32", true},
+				},
+				"Park Road": {
+					{"12", "Harbour", "08:19", true},
+					{"12", "Harbour", "08:42", true},
+				},
+			},
+		},
+		{name: "timetable without the realtime feed", realtime: "", expected: scheduledDepartures},
+		{name: "timetable when the realtime feed is not found", realtime: "/missing.pb", expected: scheduledDepartures},
+		{name: "timetable when the realtime feed times out", realtime: "/slow.pb", expected: scheduledDepartures},
+		{name: "timetable when the realtime feed is cut off", realtime: "/broken.pb", expected: scheduledDepartures},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &config.TransitSettings{
+				Gtfs:          server.URL + "/gtfs.zip",
+				Stops:         []*config.TransitStop{{Id: "central"}, {Id: "park"}},
+				MaxDepartures: 5,
+			}
+			if test.realtime != "" {
+				settings.Realtime = server.URL + test.realtime
+			}
+			p := NewTransitDataProvider(&transitConfig{settings: settings}, &fixedTime{now: time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)})
+			p.(*transitDataProvider).realtimeTimeout = 100 * time.Millisecond
+			got, err := p.GetDepartures()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDepartures(t, got, test.expected)
+		})
+	}
+}
+
+func TestTimetable(t *testing.T) {
+	server := feedServer(t)
+	defer server.Close()
+	tests := []struct {
+		name     string
+		now      time.Time
+		stop     *config.TransitStop
+		walk     int
+		max      int
+		expected []departure
+	}{
+		{
+			name: "routes of the stop",
+			now:  time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC),
+			stop: &config.TransitStop{Id: "central", Label: "Central", Routes: []string{"Airport Express"}},
+			max:  5,
+			expected: []departure{
+				{"Airport Express", "Airport T5", "08:15", false},
+			},
+		},
+		{
+			name: "walk to the stop and the number of departures",
+			now:  time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC),
+			stop: &config.TransitStop{Id: "central", Label: "Central"},
+			walk: 12,
+			max:  2,
+			expected: []departure{
+				{"Airport Express", "Airport T5", "08:15", false},
+				{"12", "Harbour", "08:20", false},
+			},
+		},
+		{
+			name: "calendar exceptions on the bank holiday",
+			now:  time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			stop: &config.TransitStop{Id: "central", Label: "Central"},
+			max:  5,
+			expected: []departure{
+				{"12", "Harbour", "08:12", false},
+			},
+		},
+		{
+			name: "trip of the previous service day after the midnight",
+			now:  time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+			stop: &config.TransitStop{Id: "central", Label: "Central"},
+			max:  1,
+			expected: []departure{
+				{"12", "Harbour", "00:10", false},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &config.TransitSettings{
+				Gtfs:          server.URL + "/gtfs.zip",
+				Stops:         []*config.TransitStop{test.stop},
+				MaxDepartures: test.max,
+				WalkMinutes:   test.walk,
+			}
+			p := NewTransitDataProvider(&transitConfig{settings: settings}, &fixedTime{now: test.now})
+			got, err := p.GetDepartures()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDepartures(t, got, map[string][]departure{test.stop.Label: test.expected})
+		})
+	}
+}
+
+func TestTimetableReload(t *testing.T) {
+	server := feedServer(t)
+	defer server.Close()
+	settings := &config.TransitSettings{Gtfs: server.URL + "/missing.zip", Stops: []*config.TransitStop{{Id: "park"}}, MaxDepartures: 5}
+	clock := &fixedTime{now: time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)}
+	p := NewTransitDataProvider(&transitConfig{settings: settings}, clock)
+	_, err := p.GetDepartures()
+	if err == nil {
+		t.Fatal("expected an error without the timetable")
+	}
+	settings.Gtfs = server.URL + "/gtfs.zip"
+	_, err = p.GetDepartures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the timetable loaded before is kept when the feed goes missing on the daily reload
+	settings.Gtfs = server.URL + "/missing.zip"
+	clock.now = clock.now.AddDate(0, 0, 7)
+	got, err := p.GetDepartures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDepartures(t, got, map[string][]departure{"Park Road": {
+		{"12", "Harbour", "08:20", false},
+		{"12", "Harbour", "08:30", false},
+		{"12", "Harbour", "08:40", false},
+	}})
+}
@@ -0,0 +1,284 @@
+package transit
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"github.com/rotisserie/eris"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stopTime is a scheduled departure of a trip from a stop, the time is counted from the noon minus 12 hours
+// of the service day and can be over 24 hours for the trips running past the midnight
+type stopTime struct {
+	tripId    string
+	stopId    string // the platform, it is a child of the configured stop if that is a station
+	sequence  int
+	departure time.Duration
+	headsign  string
+}
+
+type trip struct {
+	routeId   string
+	serviceId string
+	headsign  string
+}
+
+type service struct {
+	weekdays   [7]bool
+	start, end string // YYYYMMDD, compared as strings
+}
+
+// timetable is the part of a GTFS static feed needed for the configured stops
+type timetable struct {
+	location   *time.Location
+	stopNames  map[string]string
+	stopTimes  map[string][]*stopTime // by the configured stop id
+	trips      map[string]*trip
+	routes     map[string]string // short names, long ones if there are none
+	services   map[string]*service
+	exceptions map[string]map[string]bool // service id to the date to whether the service is added or removed
+}
+
+// readTable calls the handler with every row of the CSV file of the feed, the handler gets the values by the column names
+func readTable(files map[string]*zip.File, name string, required bool, handle func(get func(column string) string) error) error {
+	file, exists := files[name]
+	if !exists {
+		if required {
+			return eris.Errorf("GTFS feed has no %s", name)
+		}
+		return nil
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return eris.Wrapf(err, "couldn't open %s", name)
+	}
+	defer reader.Close()
+	records := csv.NewReader(reader)
+	records.FieldsPerRecord = -1
+	records.LazyQuotes = true
+	header, err := records.Read()
+	if err != nil {
+		return eris.Wrapf(err, "couldn't read the header of %s", name)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+	var row []string
+	get := func(column string) string {
+		i, exists := columns[column]
+		if !exists || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	for {
+		row, err = records.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return eris.Wrapf(err, "couldn't read %s", name)
+		}
+		err = handle(get)
+		if err != nil {
+			return eris.Wrapf(err, "wrong row in %s", name)
+		}
+	}
+}
+
+// parseGtfsTime parses H:MM:SS, the hours can go over 24
+func parseGtfsTime(text string) (time.Duration, error) {
+	parts := strings.Split(text, ":")
+	if len(parts) != 3 {
+		return 0, eris.Errorf("wrong time '%s'", text)
+	}
+	res := time.Duration(0)
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		v, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, eris.Wrapf(err, "wrong time '%s'", text)
+		}
+		res += time.Duration(v) * unit
+	}
+	return res, nil
+}
+
+// loadTimetable reads the zipped feed keeping only the departures from the stops, a stop can be a station
+// with the departures from all its platforms
+func loadTimetable(content []byte, stopIds []string) (*timetable, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, eris.Wrap(err, "GTFS feed is not a zip file")
+	}
+	// some feeds are zipped with a folder
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[path.Base(file.Name)] = file
+	}
+	res := &timetable{
+		location:   time.Local,
+		stopNames:  make(map[string]string),
+		stopTimes:  make(map[string][]*stopTime),
+		trips:      make(map[string]*trip),
+		routes:     make(map[string]string),
+		services:   make(map[string]*service),
+		exceptions: make(map[string]map[string]bool),
+	}
+	err = readTable(files, "agency.txt", false, func(get func(string) string) error {
+		if location, err := time.LoadLocation(get("agency_timezone")); err == nil && get("agency_timezone") != "" {
+			res.location = location
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// platform id to the configured stop
+	platforms := make(map[string]string)
+	err = readTable(files, "stops.txt", true, func(get func(string) string) error {
+		id := get("stop_id")
+		switch {
+		case slices.Contains(stopIds, id):
+			platforms[id] = id
+			res.stopNames[id] = get("stop_name")
+		case slices.Contains(stopIds, get("parent_station")):
+			platforms[id] = get("parent_station")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readTable(files, "stop_times.txt", true, func(get func(string) string) error {
+		stopId, exists := platforms[get("stop_id")]
+		// no pickup at the last stops of the trips
+		if !exists || get("pickup_type") == "1" {
+			return nil
+		}
+		text := get("departure_time")
+		if text == "" {
+			text = get("arrival_time")
+		}
+		// the stops without the times are interpolated by the riders, they are not shown
+		if text == "" {
+			return nil
+		}
+		departure, err := parseGtfsTime(text)
+		if err != nil {
+			return err
+		}
+		sequence, err := strconv.Atoi(get("stop_sequence"))
+		if err != nil {
+			return eris.Wrapf(err, "wrong stop sequence '%s'", get("stop_sequence"))
+		}
+		res.stopTimes[stopId] = append(res.stopTimes[stopId], &stopTime{
+			tripId:    get("trip_id"),
+			stopId:    get("stop_id"),
+			sequence:  sequence,
+			departure: departure,
+			headsign:  get("stop_headsign"),
+		})
+		res.trips[get("trip_id")] = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readTable(files, "trips.txt", true, func(get func(string) string) error {
+		if _, exists := res.trips[get("trip_id")]; exists {
+			res.trips[get("trip_id")] = &trip{routeId: get("route_id"), serviceId: get("service_id"), headsign: get("trip_headsign")}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readTable(files, "routes.txt", true, func(get func(string) string) error {
+		name := get("route_short_name")
+		if name == "" {
+			name = get("route_long_name")
+		}
+		res.routes[get("route_id")] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// either of the calendars can be missing
+	days := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+	err = readTable(files, "calendar.txt", false, func(get func(string) string) error {
+		s := &service{start: get("start_date"), end: get("end_date")}
+		for i, day := range days {
+			s.weekdays[i] = get(day) == "1"
+		}
+		res.services[get("service_id")] = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readTable(files, "calendar_dates.txt", false, func(get func(string) string) error {
+		serviceId := get("service_id")
+		if res.exceptions[serviceId] == nil {
+			res.exceptions[serviceId] = make(map[string]bool)
+		}
+		res.exceptions[serviceId][get("date")] = get("exception_type") == "1"
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (t *timetable) serviceRuns(serviceId string, day time.Time) bool {
+	date := day.Format("20060102")
+	if added, exists := t.exceptions[serviceId][date]; exists {
+		return added
+	}
+	s, exists := t.services[serviceId]
+	return exists && date >= s.start && date <= s.end && s.weekdays[day.Weekday()]
+}
+
+// scheduledDepartures lists the departures from the stop between the times in no particular order,
+// the service days starting the day before are checked for the trips running past the midnight
+func (t *timetable) scheduledDepartures(stopId string, from, to time.Time) []*Departure {
+	res := make([]*Departure, 0)
+	from, to = from.In(t.location), to.In(t.location)
+	first := time.Date(from.Year(), from.Month(), from.Day()-1, 0, 0, 0, 0, t.location)
+	for day := first; !day.After(to); day = day.AddDate(0, 0, 1) {
+		// the GTFS times are counted from the noon minus 12 hours, it differs from the midnight on the DST change days
+		start := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, t.location).Add(-12 * time.Hour)
+		for _, st := range t.stopTimes[stopId] {
+			departure := start.Add(st.departure)
+			if departure.Before(from) || departure.After(to) {
+				continue
+			}
+			tr := t.trips[st.tripId]
+			if tr == nil || !t.serviceRuns(tr.serviceId, day) {
+				continue
+			}
+			headsign := st.headsign
+			if headsign == "" {
+				headsign = tr.headsign
+			}
+			res = append(res, &Departure{
+				Route:     t.routes[tr.routeId],
+				Headsign:  headsign,
+				Scheduled: departure,
+				Expected:  departure,
+				tripId:    st.tripId,
+				stopId:    st.stopId,
+				sequence:  st.sequence,
+			})
+		}
+	}
+	return res
+}
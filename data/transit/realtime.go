@@ -0,0 +1,319 @@
+package transit
+
+import (
+	"github.com/rotisserie/eris"
+	"time"
+)
+
+// the GTFS-Realtime feed is decoded by hand, only the trip updates are read,
+// see https://gtfs.org/documentation/realtime/proto/
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// schedule relationships of the trips and the stops
+const (
+	tripCanceled = 3
+	stopSkipped  = 1
+	stopNoData   = 2
+)
+
+type protoReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	res := uint64(0)
+	for shift := 0; shift < 64; shift += 7 {
+		if r.pos >= len(r.data) {
+			return 0, eris.New("protobuf message is cut off")
+		}
+		b := r.data[r.pos]
+		r.pos++
+		res |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return res, nil
+		}
+	}
+	return 0, eris.New("protobuf varint is too long")
+}
+
+func (r *protoReader) bytes() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, eris.New("protobuf message is cut off")
+	}
+	res := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return res, nil
+}
+
+func (r *protoReader) skip(wireType int) error {
+	var size int
+	switch wireType {
+	case wireVarint:
+		_, err := r.varint()
+		return err
+	case wireBytes:
+		_, err := r.bytes()
+		return err
+	case wireFixed64:
+		size = 8
+	case wireFixed32:
+		size = 4
+	default:
+		return eris.Errorf("unsupported protobuf wire type %d", wireType)
+	}
+	if size > len(r.data)-r.pos {
+		return eris.New("protobuf message is cut off")
+	}
+	r.pos += size
+	return nil
+}
+
+// readMessage calls the handler with every field of the message, the handler reads the value or skips it
+func readMessage(data []byte, handle func(field, wireType int, r *protoReader) error) error {
+	r := &protoReader{data: data}
+	for r.pos < len(r.data) {
+		key, err := r.varint()
+		if err != nil {
+			return err
+		}
+		err = handle(int(key>>3), int(key&7), r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMessageFields reads the fields of the nested messages with the handlers by the field number, other fields are skipped
+func readMessageFields(data []byte, handlers map[int]func(r *protoReader) error) error {
+	return readMessage(data, func(field, wireType int, r *protoReader) error {
+		if handle, exists := handlers[field]; exists {
+			return handle(r)
+		}
+		return r.skip(wireType)
+	})
+}
+
+type stopTimeUpdate struct {
+	sequence    int
+	hasSequence bool
+	stopId      string
+	skipped     bool
+	noData      bool
+	delay       time.Duration
+	hasDelay    bool
+	time        time.Time // zero if only the delay is known
+}
+
+type tripUpdate struct {
+	canceled bool
+	delay    time.Duration // the delay of the whole trip if the stops have none
+	hasDelay bool
+	stops    []*stopTimeUpdate
+}
+
+func readVarint(target *uint64) func(r *protoReader) error {
+	return func(r *protoReader) error {
+		v, err := r.varint()
+		*target = v
+		return err
+	}
+}
+
+func readString(target *string) func(r *protoReader) error {
+	return func(r *protoReader) error {
+		v, err := r.bytes()
+		*target = string(v)
+		return err
+	}
+}
+
+// readStopTimeEvent reads the delay and the time of the arrival or the departure
+func readStopTimeEvent(data []byte, update *stopTimeUpdate) error {
+	var delay, at uint64
+	hasDelay := false
+	err := readMessageFields(data, map[int]func(r *protoReader) error{
+		1: func(r *protoReader) error {
+			hasDelay = true
+			return readVarint(&delay)(r)
+		},
+		2: readVarint(&at),
+	})
+	if err != nil {
+		return err
+	}
+	if hasDelay {
+		update.delay = time.Duration(int32(delay)) * time.Second
+		update.hasDelay = true
+	}
+	if at != 0 {
+		update.time = time.Unix(int64(at), 0)
+	}
+	return nil
+}
+
+func readStopTimeUpdate(data []byte) (*stopTimeUpdate, error) {
+	res := &stopTimeUpdate{}
+	var sequence, relationship uint64
+	var arrival, departure []byte
+	err := readMessageFields(data, map[int]func(r *protoReader) error{
+		1: func(r *protoReader) error {
+			res.hasSequence = true
+			return readVarint(&sequence)(r)
+		},
+		2: func(r *protoReader) (err error) {
+			arrival, err = r.bytes()
+			return err
+		},
+		3: func(r *protoReader) (err error) {
+			departure, err = r.bytes()
+			return err
+		},
+		4: readString(&res.stopId),
+		5: readVarint(&relationship),
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.sequence = int(sequence)
+	res.skipped = relationship == stopSkipped
+	res.noData = relationship == stopNoData
+	// the departure is preferred, the arrival is taken if there is no departure
+	for _, event := range [][]byte{arrival, departure} {
+		if event != nil {
+			err = readStopTimeEvent(event, res)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// readTripUpdate returns the trip id with the update
+func readTripUpdate(data []byte) (string, *tripUpdate, error) {
+	res := &tripUpdate{}
+	var tripId string
+	var relationship, delay uint64
+	err := readMessageFields(data, map[int]func(r *protoReader) error{
+		1: func(r *protoReader) error {
+			descriptor, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			return readMessageFields(descriptor, map[int]func(r *protoReader) error{
+				1: readString(&tripId),
+				4: readVarint(&relationship),
+			})
+		},
+		2: func(r *protoReader) error {
+			update, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			stop, err := readStopTimeUpdate(update)
+			if err != nil {
+				return err
+			}
+			res.stops = append(res.stops, stop)
+			return nil
+		},
+		5: func(r *protoReader) error {
+			res.hasDelay = true
+			return readVarint(&delay)(r)
+		},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	res.canceled = relationship == tripCanceled
+	res.delay = time.Duration(int32(delay)) * time.Second
+	return tripId, res, nil
+}
+
+// parseTripUpdates reads the trip updates of the feed message by the trip id
+func parseTripUpdates(data []byte) (map[string]*tripUpdate, error) {
+	res := make(map[string]*tripUpdate)
+	err := readMessageFields(data, map[int]func(r *protoReader) error{
+		2: func(r *protoReader) error {
+			entity, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			return readMessageFields(entity, map[int]func(r *protoReader) error{
+				3: func(r *protoReader) error {
+					update, err := r.bytes()
+					if err != nil {
+						return err
+					}
+					tripId, tu, err := readTripUpdate(update)
+					if err != nil {
+						return err
+					}
+					if tripId != "" {
+						res[tripId] = tu
+					}
+					return nil
+				},
+			})
+		},
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "wrong GTFS-Realtime feed")
+	}
+	return res, nil
+}
+
+// apply sets the expected time of the departure, it returns false if the trip is canceled or the stop is skipped.
+// A stop without its own update takes the delay of the nearest earlier stop, then the delay of the trip.
+func (u *tripUpdate) apply(departure *Departure) bool {
+	if u.canceled {
+		return false
+	}
+	var earlier *stopTimeUpdate
+	for _, stop := range u.stops {
+		// the sequence tells the visits of the same stop on a loop apart
+		if (stop.hasSequence && stop.sequence == departure.sequence) || (!stop.hasSequence && stop.stopId == departure.stopId) {
+			switch {
+			case stop.skipped:
+				return false
+			case stop.noData:
+				return true
+			case !stop.time.IsZero():
+				departure.Expected = stop.time
+			case stop.hasDelay:
+				departure.Expected = departure.Scheduled.Add(stop.delay)
+			default:
+				return true
+			}
+			departure.Realtime = true
+			return true
+		}
+		if stop.hasSequence && stop.sequence < departure.sequence && stop.hasDelay && !stop.noData &&
+			(earlier == nil || stop.sequence > earlier.sequence) {
+			earlier = stop
+		}
+	}
+	switch {
+	case earlier != nil:
+		departure.Expected = departure.Scheduled.Add(earlier.delay)
+	case u.hasDelay:
+		departure.Expected = departure.Scheduled.Add(u.delay)
+	default:
+		return true
+	}
+	departure.Realtime = true
+	return true
+}
@@ -144,6 +144,8 @@ type weatherData struct {
 	City  weatherDataCity   `json:"city"`
 }
 
+// ForecastDataDay and ForecastDataGraph are always metric (°C, mm, m/s) as returned by OWM with units=metric,
+// conversion into the configured units is done at display time.
 type ForecastDataDay struct {
 	EpochDay             int
 	Date                 time.Time
@@ -151,7 +153,7 @@ type ForecastDataDay struct {
 	MaxTemp              float64
 	ExpectedRainAmountMm float64
 	ExpectedSnowAmountMm float64
-	MaxWindKmh           float64
+	MaxWindMs            float64
 	WeatherType          int
 }
 
@@ -160,7 +162,7 @@ type ForecastDataGraph struct {
 	Temperature float64
 	Humidity    float64
 	Clouds      float64
-	WindKmh     float64
+	WindMs      float64
 }
 
 type ForecastDataDaySlice []ForecastDataDay
@@ -194,7 +196,7 @@ func transformIntoForecastData(weather weatherData) (*ForecastData, error) {
 				MaxTemp:              -200,
 				ExpectedRainAmountMm: 0,
 				ExpectedSnowAmountMm: 0,
-				MaxWindKmh:           -200,
+				MaxWindMs:            -200,
 				WeatherType:          0,
 			}
 			daysMap[epochDay] = curDay
@@ -205,7 +207,7 @@ func transformIntoForecastData(weather weatherData) (*ForecastData, error) {
 			Temperature: item.Main.Temp,
 			Humidity:    item.Main.Humidity,
 			Clouds:      clouds,
-			WindKmh:     item.Wind.Speed,
+			WindMs:      item.Wind.Speed,
 		}
 		if curDay.MinTemp > item.Main.Temp {
 			curDay.MinTemp = item.Main.Temp
@@ -215,8 +217,8 @@ func transformIntoForecastData(weather weatherData) (*ForecastData, error) {
 		}
 		curDay.ExpectedRainAmountMm += item.Rain["3h"]
 		curDay.ExpectedSnowAmountMm += item.Snow["3h"]
-		if curDay.MaxWindKmh < item.Wind.Speed {
-			curDay.MaxWindKmh = item.Wind.Speed
+		if curDay.MaxWindMs < item.Wind.Speed {
+			curDay.MaxWindMs = item.Wind.Speed
 		}
 	}
 	days := make([]ForecastDataDay, 0)
@@ -2,6 +2,8 @@ package di
 
 import (
 	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/units"
 	"github.com/google/wire"
 )
 
@@ -9,6 +11,16 @@ func provideConfig() (config.ConfigApi, error) {
 	return config.NewConfigApi()
 }
 
+func provideUnits(cfg config.ConfigApi) (units.Units, error) {
+	return units.NewUnits(cfg)
+}
+
+func provideTranslator(cfg config.ConfigApi) (i18n.Translator, error) {
+	return i18n.NewTranslator(cfg)
+}
+
 var configModule = wire.NewSet(
 	provideConfig,
+	provideUnits,
+	provideTranslator,
 )
@@ -2,10 +2,21 @@ package di
 
 import (
 	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/airquality"
+	"fkirill.org/eink-meteo-station/data/alerts"
 	"fkirill.org/eink-meteo-station/data/daylight"
+	"fkirill.org/eink-meteo-station/data/energy"
 	"fkirill.org/eink-meteo-station/data/environment"
 	"fkirill.org/eink-meteo-station/data/ha"
+	"fkirill.org/eink-meteo-station/data/ics"
+	"fkirill.org/eink-meteo-station/data/messages"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"fkirill.org/eink-meteo-station/data/tides"
+	"fkirill.org/eink-meteo-station/data/transit"
 	"fkirill.org/eink-meteo-station/data/weather"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
 	"github.com/google/wire"
 )
 
@@ -17,17 +28,67 @@ func provideHomeAssistantApi(cfg config.ConfigApi) ha.HomeAssistantApi {
 	return ha.NewHomeAssistantApi(cfg)
 }
 
-func provideEnvironmentData(cfg config.ConfigApi, haApi ha.HomeAssistantApi) environment.EnvironmentDataProvider {
-	return environment.NewEnvironmentDataProvider(cfg, haApi)
+func provideEnvironmentData(
+	cfg config.ConfigApi,
+	haApi ha.HomeAssistantApi,
+	units units.Units,
+	translator i18n.Translator,
+) (environment.EnvironmentDataProvider, error) {
+	return environment.NewEnvironmentDataProvider(cfg, haApi, units, translator)
+}
+
+func provideAirQualityData(cfg config.ConfigApi, haApi ha.HomeAssistantApi) (airquality.AirQualityDataProvider, error) {
+	return airquality.NewAirQualityDataProvider(cfg, haApi)
+}
+
+func provideEnergyData(cfg config.ConfigApi, haApi ha.HomeAssistantApi) (energy.EnergyDataProvider, error) {
+	return energy.NewEnergyDataProvider(cfg, haApi)
+}
+
+func provideTransitData(cfg config.ConfigApi, timeProvider utils.TimeProvider) transit.TransitDataProvider {
+	return transit.NewTransitDataProvider(cfg, timeProvider)
+}
+
+func provideMessageStore(cfg config.ConfigApi, haApi ha.HomeAssistantApi) (messages.MessageStore, error) {
+	return messages.NewMessageStore(cfg, haApi)
+}
+
+func provideTideProvider(cfg config.ConfigApi) (tides.TideProvider, error) {
+	return tides.NewTideProvider(cfg)
+}
+
+func provideAlertsProvider(cfg config.ConfigApi, timeProvider utils.TimeProvider) (alerts.AlertsProvider, error) {
+	return alerts.NewAlertsProvider(cfg, timeProvider)
 }
 
 func provideSunriseSunsetProvider() daylight.SunriseSunsetProvider {
 	return daylight.NewSunriseSunsetProvider()
 }
 
+func provideIcsFeedsProvider(cfg config.ConfigApi) ics.IcsFeedsProvider {
+	return ics.NewIcsFeedsProvider(cfg)
+}
+
+func provideSpecialDaysProvider(
+	cfg config.ConfigApi,
+	icsProvider ics.IcsFeedsProvider,
+	translator i18n.Translator,
+	timeProvider utils.TimeProvider,
+) specialdays.SpecialDaysProvider {
+	return specialdays.NewSpecialDaysProvider(cfg, icsProvider, translator, timeProvider)
+}
+
 var dataModule = wire.NewSet(
 	provideForecastData,
 	provideHomeAssistantApi,
 	provideEnvironmentData,
+	provideAirQualityData,
+	provideEnergyData,
+	provideTransitData,
+	provideMessageStore,
+	provideTideProvider,
+	provideAlertsProvider,
 	provideSunriseSunsetProvider,
+	provideIcsFeedsProvider,
+	provideSpecialDaysProvider,
 )
@@ -2,40 +2,76 @@ package di
 
 import (
 	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/airquality"
+	"fkirill.org/eink-meteo-station/data/alerts"
 	"fkirill.org/eink-meteo-station/data/daylight"
+	"fkirill.org/eink-meteo-station/data/energy"
 	"fkirill.org/eink-meteo-station/data/environment"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"fkirill.org/eink-meteo-station/data/messages"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"fkirill.org/eink-meteo-station/data/tides"
+	"fkirill.org/eink-meteo-station/data/transit"
 	"fkirill.org/eink-meteo-station/data/weather"
 	"fkirill.org/eink-meteo-station/eink"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
 	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/agenda"
+	"fkirill.org/eink-meteo-station/renderable/air_quality"
+	"fkirill.org/eink-meteo-station/renderable/alert_banner"
 	"fkirill.org/eink-meteo-station/renderable/calendar"
 	"fkirill.org/eink-meteo-station/renderable/clock"
+	"fkirill.org/eink-meteo-station/renderable/departures"
+	"fkirill.org/eink-meteo-station/renderable/energy_usage"
+	"fkirill.org/eink-meteo-station/renderable/entity_grid"
 	"fkirill.org/eink-meteo-station/renderable/forecast"
+	"fkirill.org/eink-meteo-station/renderable/message_board"
+	"fkirill.org/eink-meteo-station/renderable/photo_frame"
 	"fkirill.org/eink-meteo-station/renderable/pressure"
+	"fkirill.org/eink-meteo-station/renderable/radar"
 	"fkirill.org/eink-meteo-station/renderable/sunset_sunrise"
 	"fkirill.org/eink-meteo-station/renderable/temperature"
+	"fkirill.org/eink-meteo-station/renderable/tide_times"
 	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/renderable/world_clock"
+	"fkirill.org/eink-meteo-station/units"
 	"github.com/google/wire"
 	"github.com/rotisserie/eris"
 	"image"
+	"maps"
+	"slices"
 )
 
 type ScreenLayout struct {
-	ScreenRect            image.Rectangle
-	PressureWidgetRect    image.Rectangle
-	CalendarWidgetRect    image.Rectangle
-	ForecastWidgetRect    image.Rectangle
-	ClockWidgetRect       image.Rectangle
-	DaylightWidgetRect    image.Rectangle
-	TemperatureWidgetRect image.Rectangle
+	ScreenRect             image.Rectangle
+	PressureWidgetRect     image.Rectangle
+	CalendarWidgetRect     image.Rectangle
+	ForecastWidgetRect     image.Rectangle
+	ClockWidgetRect        image.Rectangle
+	DaylightWidgetRect     image.Rectangle
+	TemperatureWidgetRect  image.Rectangle
+	AgendaWidgetRect       image.Rectangle
+	WorldClockWidgetRect   image.Rectangle
+	AirQualityWidgetRect   image.Rectangle
+	RadarWidgetRect        image.Rectangle
+	PhotoFrameWidgetRect   image.Rectangle
+	EntityGridWidgetRect   image.Rectangle
+	EnergyWidgetRect       image.Rectangle
+	DeparturesWidgetRect   image.Rectangle
+	MessageBoardWidgetRect image.Rectangle
+	TidesWidgetRect        image.Rectangle
+	Modes                  map[string]string // widget name to the configured mode, missing for the default one
+	Dithering              map[string]string // widget name to the configured dithering, missing for the default one
 }
 
 // it doesn't belong here
-func provideScreenLayout(eink eink.EInkScreen) (*ScreenLayout, error) {
+func provideScreenLayout(eink eink.EInkScreen, cfg config.ConfigApi) (*ScreenLayout, error) {
 	w, h := eink.GetScreenDimensions()
 	if w != 1872 || h != 1404 {
 		return nil, eris.Errorf("Unexpected screen dimentions: (%v, %v), expected (1872, 1404)", w, h)
 	}
-	return &ScreenLayout{
+	layout := &ScreenLayout{
 		ScreenRect:            image.Rectangle{Min: image.Point{X: 0, Y: 0}, Max: image.Point{X: int(w), Y: int(h)}},
 		PressureWidgetRect:    image.Rectangle{Min: image.Point{X: 1000, Y: 500}, Max: image.Point{X: 1450, Y: 900}},
 		CalendarWidgetRect:    image.Rectangle{Min: image.Point{X: 0, Y: 280}, Max: image.Point{X: 962, Y: 1400}},
@@ -43,7 +79,88 @@ func provideScreenLayout(eink eink.EInkScreen) (*ScreenLayout, error) {
 		ClockWidgetRect:       image.Rectangle{Min: image.Point{X: 0, Y: 0}, Max: image.Point{X: 963, Y: 237}},
 		DaylightWidgetRect:    image.Rectangle{Min: image.Point{X: 1450, Y: 500}, Max: image.Point{X: 1870, Y: 900}},
 		TemperatureWidgetRect: image.Rectangle{Min: image.Point{X: 1000, Y: 0}, Max: image.Point{X: 1850, Y: 481}},
-	}, nil
+		// there is no room for the agenda, the world clock, the air quality, the radar, the photos, the entity grid,
+		// the energy, the departures, the messages and the tides in the default layout, they are only shown if placed in the config
+		AgendaWidgetRect:       image.Rectangle{},
+		WorldClockWidgetRect:   image.Rectangle{},
+		AirQualityWidgetRect:   image.Rectangle{},
+		RadarWidgetRect:        image.Rectangle{},
+		PhotoFrameWidgetRect:   image.Rectangle{},
+		EntityGridWidgetRect:   image.Rectangle{},
+		EnergyWidgetRect:       image.Rectangle{},
+		DeparturesWidgetRect:   image.Rectangle{},
+		MessageBoardWidgetRect: image.Rectangle{},
+		TidesWidgetRect:        image.Rectangle{},
+		Modes:                  make(map[string]string),
+		Dithering:              make(map[string]string),
+	}
+	err := applyLayout(layout, cfg.GetWidgetRect, layout.Dithering)
+	if err != nil {
+		return nil, err
+	}
+	calibration, err := utils.NewGrayCalibration(cfg.GetPanelGamma(), cfg.GetPanelLevels())
+	if err != nil {
+		return nil, err
+	}
+	// all the widgets are converted to the panel levels the same way
+	utils.SetGrayCalibration(calibration)
+	puppettier.SetWidgetDithering(layout.Dithering)
+	return layout, nil
+}
+
+// applyLayout moves the widgets of the layout to the configured positions and collects their modes and dithering,
+// the dithering is only taken from the day layout, it is nil for the night one
+func applyLayout(layout *ScreenLayout, configuredRect func(widget string) *config.WidgetRect, dithering map[string]string) error {
+	widgetRects := map[string]*image.Rectangle{
+		"pressure":      &layout.PressureWidgetRect,
+		"calendar":      &layout.CalendarWidgetRect,
+		"forecast":      &layout.ForecastWidgetRect,
+		"clock":         &layout.ClockWidgetRect,
+		"daylight":      &layout.DaylightWidgetRect,
+		"temperature":   &layout.TemperatureWidgetRect,
+		"agenda":        &layout.AgendaWidgetRect,
+		"world_clock":   &layout.WorldClockWidgetRect,
+		"air_quality":   &layout.AirQualityWidgetRect,
+		"radar":         &layout.RadarWidgetRect,
+		"photo_frame":   &layout.PhotoFrameWidgetRect,
+		"entity_grid":   &layout.EntityGridWidgetRect,
+		"energy":        &layout.EnergyWidgetRect,
+		"departures":    &layout.DeparturesWidgetRect,
+		"message_board": &layout.MessageBoardWidgetRect,
+		"tides":         &layout.TidesWidgetRect,
+	}
+	for widget, rect := range widgetRects {
+		configured := configuredRect(widget)
+		if configured == nil {
+			continue
+		}
+		if configured.Mode != "" {
+			layout.Modes[widget] = configured.Mode
+		}
+		if configured.Dither != "" {
+			if dithering == nil {
+				return eris.Errorf("%s widget: the dithering is set in the day layout only", widget)
+			}
+			err := utils.ValidateDither(configured.Dither)
+			if err != nil {
+				return eris.Wrapf(err, "%s widget", widget)
+			}
+			dithering[widget] = configured.Dither
+		}
+		if configured.Disabled {
+			*rect = image.Rectangle{}
+			continue
+		}
+		// a widget can be given only a mode to keep its default position
+		if configured.Width <= 0 || configured.Height <= 0 {
+			continue
+		}
+		*rect = image.Rect(configured.X, configured.Y, configured.X+configured.Width, configured.Y+configured.Height)
+		if !rect.In(layout.ScreenRect) {
+			return eris.Errorf("%s widget %v doesn't fit the screen %v", widget, *rect, layout.ScreenRect)
+		}
+	}
+	return nil
 }
 
 func providePressureRenderable(
@@ -51,48 +168,387 @@ func providePressureRenderable(
 	timeProvider utils.TimeProvider,
 	cfg config.ConfigApi,
 	envData environment.EnvironmentDataProvider,
+	translator i18n.Translator,
 ) pressure.PressureRenderable {
-	return pressure.NewHAPressureView(layout.PressureWidgetRect, timeProvider, cfg, envData)
+	return pressure.NewHAPressureView(layout.PressureWidgetRect, timeProvider, cfg, envData, translator)
 }
 
 func provideCalendarRenderable(
 	layout *ScreenLayout,
 	timeProvider utils.TimeProvider,
-	cfg config.ConfigApi,
-) calendar.CalendarRenderable {
-	return calendar.NewCalendarRenderable(layout.CalendarWidgetRect, timeProvider, cfg)
+	specialDaysProvider specialdays.SpecialDaysProvider,
+	translator i18n.Translator,
+) (calendar.CalendarRenderable, error) {
+	return calendar.NewCalendarRenderable(layout.CalendarWidgetRect, timeProvider, specialDaysProvider, translator, layout.Modes["calendar"])
 }
 
-func provideMultiRenderable(
+func provideAgendaRenderable(
 	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	specialDaysProvider specialdays.SpecialDaysProvider,
+	translator i18n.Translator,
+) agenda.AgendaRenderable {
+	return agenda.NewAgendaRenderable(layout.AgendaWidgetRect, timeProvider, cfg, specialDaysProvider, translator)
+}
+
+// Widgets maps the layout names to the widgets, disabled widgets are left out
+type Widgets map[string]renderable.Renderable
+
+func provideWidgets(
 	pressureRenderable pressure.PressureRenderable,
 	calendarWidget calendar.CalendarRenderable,
 	forecastWidget forecast.ForecastRenderable,
 	daylightWidget sunset_sunrise.DaylightRenderable,
 	temperatureWidget temperature.TemperatureHumidityRenderable,
 	clockWidget clock.ClockRenderable,
-) (utils.MultiRenderable, error) {
-	widgets := []renderable.Renderable{pressureRenderable, calendarWidget, forecastWidget, daylightWidget, temperatureWidget, clockWidget}
-	res, err := utils.NewMultiRenderable(layout.ScreenRect, widgets, false)
+	agendaWidget agenda.AgendaRenderable,
+	worldClockWidget world_clock.WorldClockRenderable,
+	airQualityWidget air_quality.AirQualityRenderable,
+	radarWidget radar.RadarRenderable,
+	photoFrameWidget photo_frame.PhotoFrameRenderable,
+	entityGridWidget entity_grid.EntityGridRenderable,
+	energyWidget energy_usage.EnergyRenderable,
+	departuresWidget departures.DeparturesRenderable,
+	messageBoardWidget message_board.MessageBoardRenderable,
+	tidesWidget tide_times.TideTimesRenderable,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	alertsProvider alerts.AlertsProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (Widgets, error) {
+	widgets := Widgets{
+		"pressure":      pressureRenderable,
+		"calendar":      calendarWidget,
+		"forecast":      forecastWidget,
+		"daylight":      daylightWidget,
+		"temperature":   temperatureWidget,
+		"clock":         clockWidget,
+		"agenda":        agendaWidget,
+		"world_clock":   worldClockWidget,
+		"air_quality":   airQualityWidget,
+		"radar":         radarWidget,
+		"photo_frame":   photoFrameWidget,
+		"entity_grid":   entityGridWidget,
+		"energy":        energyWidget,
+		"departures":    departuresWidget,
+		"message_board": messageBoardWidget,
+		"tides":         tidesWidget,
+	}
+	for name, widget := range widgets {
+		// widgets with an empty rectangle are disabled
+		if widget.BoundingBox().Empty() {
+			delete(widgets, name)
+		}
+	}
+	// the alert banner takes the place of the slot widget while an alert is active
+	if len(cfg.GetAlertFeeds()) > 0 {
+		slot := cfg.GetAlertSlot()
+		slotWidget, exists := widgets[slot]
+		if !exists {
+			return nil, eris.Errorf("alert banner slot '%s' is unknown or disabled", slot)
+		}
+		widgets[slot] = alert_banner.NewAlertBannerRenderable(slotWidget, timeProvider, alertsProvider, units, translator)
+	}
+	return widgets, nil
+}
+
+// widgetOrder is the order the widgets are rendered in when they are due at the same time
+var widgetOrder = []string{"pressure", "calendar", "forecast", "daylight", "temperature", "clock", "agenda", "world_clock", "air_quality", "radar", "photo_frame", "entity_grid", "energy", "departures", "message_board", "tides"}
+
+func newMultiRenderable(layout *ScreenLayout, widgets Widgets, names []string) (utils.MultiRenderable, error) {
+	selected := make([]renderable.Renderable, 0)
+	for _, name := range widgetOrder {
+		if widget, exists := widgets[name]; exists && slices.Contains(names, name) {
+			selected = append(selected, widget)
+		}
+	}
+	return utils.NewMultiRenderable(layout.ScreenRect, selected, false)
+}
+
+func provideWorldClockRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	daylightProvider daylight.SunriseSunsetProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (world_clock.WorldClockRenderable, error) {
+	return world_clock.NewWorldClockRenderable(layout.WorldClockWidgetRect, timeProvider, cfg, daylightProvider, units, translator)
+}
+
+func provideAirQualityRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	airQualityProvider airquality.AirQualityDataProvider,
+	translator i18n.Translator,
+) air_quality.AirQualityRenderable {
+	return air_quality.NewAirQualityRenderable(layout.AirQualityWidgetRect, timeProvider, cfg, airQualityProvider, translator)
+}
+
+func provideRadarRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+) (radar.RadarRenderable, error) {
+	return radar.NewRadarRenderable(layout.RadarWidgetRect, timeProvider, cfg, layout.Dithering["radar"])
+}
+
+func providePhotoFrameRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+) (photo_frame.PhotoFrameRenderable, error) {
+	return photo_frame.NewPhotoFrameRenderable(layout.PhotoFrameWidgetRect, timeProvider, cfg, layout.Dithering["photo_frame"])
+}
+
+func provideEntityGridRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	haApi ha.HomeAssistantApi,
+	translator i18n.Translator,
+) (entity_grid.EntityGridRenderable, error) {
+	return entity_grid.NewEntityGridRenderable(layout.EntityGridWidgetRect, timeProvider, cfg, haApi, translator)
+}
+
+func provideEnergyRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	energyProvider energy.EnergyDataProvider,
+	translator i18n.Translator,
+) (energy_usage.EnergyRenderable, error) {
+	return energy_usage.NewEnergyRenderable(layout.EnergyWidgetRect, timeProvider, cfg, energyProvider, translator)
+}
+
+func provideDeparturesRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	transitProvider transit.TransitDataProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (departures.DeparturesRenderable, error) {
+	return departures.NewDeparturesRenderable(layout.DeparturesWidgetRect, timeProvider, cfg, transitProvider, units, translator)
+}
+
+func provideMessageBoardRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	messageStore messages.MessageStore,
+	translator i18n.Translator,
+) message_board.MessageBoardRenderable {
+	return message_board.NewMessageBoardRenderable(layout.MessageBoardWidgetRect, timeProvider, messageStore, translator)
+}
+
+func provideTidesRenderable(
+	layout *ScreenLayout,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	tideProvider tides.TideProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (tide_times.TideTimesRenderable, error) {
+	return tide_times.NewTideTimesRenderable(layout.TidesWidgetRect, timeProvider, cfg, tideProvider, units, translator)
+}
+
+// WidgetFactory creates another instance of the widget at its position in the layout, e.g. in the night layout
+type WidgetFactory func(name string, layout *ScreenLayout) (renderable.Renderable, error)
+
+func provideWidgetFactory(
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	envData environment.EnvironmentDataProvider,
+	specialDaysProvider specialdays.SpecialDaysProvider,
+	weather weather.ForecastDataProvider,
+	daylightProvider daylight.SunriseSunsetProvider,
+	airQualityProvider airquality.AirQualityDataProvider,
+	haApi ha.HomeAssistantApi,
+	energyProvider energy.EnergyDataProvider,
+	transitProvider transit.TransitDataProvider,
+	messageStore messages.MessageStore,
+	tideProvider tides.TideProvider,
+	alertsProvider alerts.AlertsProvider,
+	units units.Units,
+	translator i18n.Translator,
+) WidgetFactory {
+	return func(name string, layout *ScreenLayout) (renderable.Renderable, error) {
+		var widget renderable.Renderable
+		var err error
+		switch name {
+		case "pressure":
+			widget = providePressureRenderable(layout, timeProvider, cfg, envData, translator)
+		case "calendar":
+			widget, err = provideCalendarRenderable(layout, timeProvider, specialDaysProvider, translator)
+		case "forecast":
+			widget, err = provideForecastRenderable(layout, timeProvider, weather, units, translator)
+		case "daylight":
+			widget, err = provideDaylightRenderable(layout, timeProvider, cfg, daylightProvider, units, translator)
+		case "temperature":
+			widget, err = provideTemperatureHumidityRenderable(layout, timeProvider, envData)
+		case "clock":
+			widget, err = provideClockRenderable(layout, timeProvider, units, cfg, translator)
+		case "agenda":
+			widget = provideAgendaRenderable(layout, timeProvider, cfg, specialDaysProvider, translator)
+		case "world_clock":
+			widget, err = provideWorldClockRenderable(layout, timeProvider, cfg, daylightProvider, units, translator)
+		case "air_quality":
+			widget = provideAirQualityRenderable(layout, timeProvider, cfg, airQualityProvider, translator)
+		case "radar":
+			widget, err = provideRadarRenderable(layout, timeProvider, cfg)
+		case "photo_frame":
+			widget, err = providePhotoFrameRenderable(layout, timeProvider, cfg)
+		case "entity_grid":
+			widget, err = provideEntityGridRenderable(layout, timeProvider, cfg, haApi, translator)
+		case "energy":
+			widget, err = provideEnergyRenderable(layout, timeProvider, cfg, energyProvider, translator)
+		case "departures":
+			widget, err = provideDeparturesRenderable(layout, timeProvider, cfg, transitProvider, units, translator)
+		case "message_board":
+			widget = provideMessageBoardRenderable(layout, timeProvider, messageStore, translator)
+		case "tides":
+			widget, err = provideTidesRenderable(layout, timeProvider, cfg, tideProvider, units, translator)
+		default:
+			return nil, eris.Errorf("unknown widget '%s'", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.GetAlertFeeds()) > 0 && name == cfg.GetAlertSlot() {
+			widget = alert_banner.NewAlertBannerRenderable(widget, timeProvider, alertsProvider, units, translator)
+		}
+		return widget, nil
+	}
+}
+
+func provideMultiRenderable(layout *ScreenLayout, widgets Widgets) (utils.MultiRenderable, error) {
+	res, err := newMultiRenderable(layout, widgets, widgetOrder)
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
+func provideDisplayPolicy(
+	layout *ScreenLayout,
+	widgets Widgets,
+	dayWidgets utils.MultiRenderable,
+	factory WidgetFactory,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	daylightProvider daylight.SunriseSunsetProvider,
+) (utils.DisplayPolicy, error) {
+	schedule, err := utils.NewNightSchedule(cfg, daylightProvider)
+	if err != nil {
+		return nil, err
+	}
+	nightWidgets, err := newNightWidgets(layout, widgets, dayWidgets, factory, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var photoWidgets utils.MultiRenderable
+	var photoSchedule utils.NightSchedule
+	// the photos take the whole screen during the photo hours
+	if from, to := cfg.GetPhotoFullScreenHours(); from != "" || to != "" {
+		photoSchedule, err = utils.NewFixedSchedule(from, to)
+		if err != nil {
+			return nil, eris.Wrap(err, "photo frame full screen hours")
+		}
+		photoFrame, err := photo_frame.NewPhotoFrameRenderable(layout.ScreenRect, timeProvider, cfg, layout.Dithering["photo_frame"])
+		if err != nil {
+			return nil, err
+		}
+		photoWidgets, err = utils.NewMultiRenderable(layout.ScreenRect, []renderable.Renderable{photoFrame}, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return utils.NewDisplayPolicy(dayWidgets, nightWidgets, schedule, photoWidgets, photoSchedule, timeProvider, cfg), nil
+}
+
+// newNightWidgets selects the night widgets, the ones placed in the night layout are separate instances
+// at their night positions, the others are shared with the day layout. The day widgets are kept if nothing is configured.
+func newNightWidgets(
+	layout *ScreenLayout,
+	widgets Widgets,
+	dayWidgets utils.MultiRenderable,
+	factory WidgetFactory,
+	cfg config.ConfigApi,
+) (utils.MultiRenderable, error) {
+	nightLayout := *layout
+	nightLayout.Modes = maps.Clone(layout.Modes)
+	err := applyLayout(&nightLayout, cfg.GetNightWidgetRect, nil)
+	if err != nil {
+		return nil, eris.Wrap(err, "night mode layout")
+	}
+	names := cfg.GetNightWidgets()
+	if len(names) == 0 {
+		for name := range widgets {
+			names = append(names, name)
+		}
+		for _, name := range widgetOrder {
+			if rect := cfg.GetNightWidgetRect(name); rect != nil && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	nightWidgets := make(Widgets)
+	moved := false
+	for _, name := range names {
+		rect := cfg.GetNightWidgetRect(name)
+		if rect == nil {
+			widget, exists := widgets[name]
+			if !exists {
+				return nil, eris.Errorf("night mode widget '%s' is unknown or disabled", name)
+			}
+			nightWidgets[name] = widget
+			continue
+		}
+		moved = true
+		if rect.Disabled {
+			continue
+		}
+		widget, err := factory(name, &nightLayout)
+		if err != nil {
+			return nil, eris.Wrapf(err, "night mode widget '%s'", name)
+		}
+		if widget.BoundingBox().Empty() {
+			return nil, eris.Errorf("night mode widget '%s' has no position", name)
+		}
+		nightWidgets[name] = widget
+	}
+	for _, name := range widgetOrder {
+		if cfg.GetNightWidgetRect(name) != nil && !slices.Contains(names, name) {
+			return nil, eris.Errorf("night mode layout places '%s' which is not shown at night", name)
+		}
+	}
+	if !moved && len(cfg.GetNightWidgets()) == 0 {
+		return dayWidgets, nil
+	}
+	return newMultiRenderable(layout, nightWidgets, names)
+}
+
 func provideForecastRenderable(
 	layout *ScreenLayout,
 	timeProvider utils.TimeProvider,
 	weather weather.ForecastDataProvider,
+	units units.Units,
+	translator i18n.Translator,
 ) (forecast.ForecastRenderable, error) {
-	return forecast.NewForecastRenderable(layout.ForecastWidgetRect, timeProvider, weather)
+	return forecast.NewForecastRenderable(layout.ForecastWidgetRect, timeProvider, weather, units, translator)
 }
 
 func provideClockRenderable(
 	layout *ScreenLayout,
 	timeProvider utils.TimeProvider,
+	units units.Units,
+	cfg config.ConfigApi,
+	translator i18n.Translator,
 ) (clock.ClockRenderable, error) {
-	res, err := clock.NewClockRenderable(layout.ClockWidgetRect, timeProvider)
+	res, err := clock.NewClockRenderable(layout.ClockWidgetRect, timeProvider, units, cfg, translator, layout.Modes["clock"])
 	if err != nil {
 		return nil, err
 	}
@@ -104,8 +560,10 @@ func provideDaylightRenderable(
 	timeProvider utils.TimeProvider,
 	cfg config.ConfigApi,
 	daylightProvider daylight.SunriseSunsetProvider,
+	units units.Units,
+	translator i18n.Translator,
 ) (sunset_sunrise.DaylightRenderable, error) {
-	res, err := sunset_sunrise.NewSunriseSunsetRenderable(layout.DaylightWidgetRect, timeProvider, cfg, daylightProvider)
+	res, err := sunset_sunrise.NewSunriseSunsetRenderable(layout.DaylightWidgetRect, timeProvider, cfg, daylightProvider, units, translator)
 	if err != nil {
 		return nil, err
 	}
@@ -127,10 +585,23 @@ func provideTemperatureHumidityRenderable(
 var renderableModule = wire.NewSet(
 	providePressureRenderable,
 	provideCalendarRenderable,
+	provideWidgets,
 	provideMultiRenderable,
+	provideDisplayPolicy,
+	provideWidgetFactory,
 	provideForecastRenderable,
 	provideClockRenderable,
 	provideDaylightRenderable,
 	provideTemperatureHumidityRenderable,
+	provideAgendaRenderable,
+	provideWorldClockRenderable,
+	provideAirQualityRenderable,
+	provideRadarRenderable,
+	providePhotoFrameRenderable,
+	provideEntityGridRenderable,
+	provideEnergyRenderable,
+	provideDeparturesRenderable,
+	provideMessageBoardRenderable,
+	provideTidesRenderable,
 	provideScreenLayout,
 )
@@ -11,11 +11,11 @@ import (
 func provideRenderLoop(
 	timeProvider utils.TimeProvider,
 	einkScreen eink.EInkScreen,
-	multiRenderable utils.MultiRenderable,
+	displayPolicy utils.DisplayPolicy,
 	cfg config.ConfigApi,
 	diffRenderer utils.DiffRenderer,
 ) utils.RenderLoop {
-	return utils.NewRenderLoop(timeProvider, einkScreen, multiRenderable, cfg, diffRenderer)
+	return utils.NewRenderLoop(timeProvider, einkScreen, displayPolicy, cfg, diffRenderer)
 }
 
 func provideTimeProvider() utils.TimeProvider {
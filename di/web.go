@@ -2,12 +2,13 @@ package di
 
 import (
 	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/messages"
 	"fkirill.org/eink-meteo-station/webui"
 	"github.com/google/wire"
 )
 
-func provideWebServer(cfg config.ConfigApi) webui.WebServer {
-	return webui.NewWebServer(cfg)
+func provideWebServer(cfg config.ConfigApi, messageStore messages.MessageStore) webui.WebServer {
+	return webui.NewWebServer(cfg, messageStore)
 }
 
 var webModule = wire.NewSet(
@@ -7,5 +7,7 @@ require (
 	github.com/jessevdk/go-flags v1.6.1 // indirect
 	github.com/rotisserie/eris v0.5.4 // indirect
 	github.com/tidwall/go-node v0.1.0 // indirect
+	golang.org/x/image v0.18.0 // indirect
 	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
 )
@@ -0,0 +1,338 @@
+package i18n
+
+const defaultLanguage = "en"
+
+type catalogue struct {
+	messages      map[string]string
+	months        [12]string // January first
+	shortMonths   [12]string
+	weekdays      [7]string // Sunday first, same as time.Weekday
+	shortWeekdays [7]string
+	pluralForm    func(n int) string // one, few or other
+}
+
+func englishPluralForm(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// russianPluralForm follows https://www.unicode.org/cldr/charts/latest/supplemental/language_plural_rules.html#ru
+func russianPluralForm(n int) string {
+	if n%10 == 1 && n%100 != 11 {
+		return "one"
+	}
+	if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+		return "few"
+	}
+	return "other"
+}
+
+var catalogues = map[string]*catalogue{
+	"en": {
+		messages: map[string]string{
+			"pressure":             "Pressure",
+			"above_norm":           "above norm",
+			"below_norm":           "below norm",
+			"inside":               "Inside",
+			"outside":              "Outside",
+			"forecast":             "Forecast",
+			"temp_max":             "t&nbsp;max",
+			"temp_min":             "t&nbsp;min",
+			"rain":                 "rain",
+			"snow":                 "snow",
+			"wind":                 "wind",
+			"daylight":             "Daylight",
+			"week":                 "wk",
+			"observed":             "(observed)",
+			"agenda":               "Upcoming",
+			"today":                "today",
+			"tomorrow":             "tomorrow",
+			"until":                "until",
+			"in_days.one":          "in %d day",
+			"in_days.other":        "in %d days",
+			"more.other":           "and %d more",
+			"civil":                "civil",
+			"nautical":             "nautical",
+			"astronomical":         "astron.",
+			"solar_noon":           "noon",
+			"day_length":           "day",
+			"polar_day":            "polar day",
+			"polar_night":          "polar night",
+			"moon_new":             "New moon",
+			"moon_waxing_crescent": "Waxing crescent",
+			"moon_first_quarter":   "First quarter",
+			"moon_waxing_gibbous":  "Waxing gibbous",
+			"moon_full":            "Full moon",
+			"moon_waning_gibbous":  "Waning gibbous",
+			"moon_last_quarter":    "Last quarter",
+			"moon_waning_crescent": "Waning crescent",
+			"yesterday":            "yesterday",
+
+			// pressure tendency and the local forecast
+			"tendency_0":            "rising, then falling",
+			"tendency_1":            "rising, then steady",
+			"tendency_2":            "rising",
+			"tendency_3":            "steady, then rising",
+			"tendency_4":            "steady",
+			"tendency_5":            "falling, then rising",
+			"tendency_6":            "falling, then steady",
+			"tendency_7":            "falling",
+			"tendency_8":            "steady, then falling",
+			"zambretti_exceptional": "Exceptional weather",
+			"zambretti_a":           "Settled fine",
+			"zambretti_b":           "Fine weather",
+			"zambretti_c":           "Becoming fine",
+			"zambretti_d":           "Fine, becoming less settled",
+			"zambretti_e":           "Fine, possible showers",
+			"zambretti_f":           "Fairly fine, improving",
+			"zambretti_g":           "Fairly fine, possible showers early",
+			"zambretti_h":           "Fairly fine, showery later",
+			"zambretti_i":           "Showery early, improving",
+			"zambretti_j":           "Changeable, mending",
+			"zambretti_k":           "Fairly fine, showers likely",
+			"zambretti_l":           "Rather unsettled, clearing later",
+			"zambretti_m":           "Unsettled, probably improving",
+			"zambretti_n":           "Showery, bright intervals",
+			"zambretti_o":           "Showery, becoming less settled",
+			"zambretti_p":           "Changeable, some rain",
+			"zambretti_q":           "Unsettled, short fine intervals",
+			"zambretti_r":           "Unsettled, rain later",
+			"zambretti_s":           "Unsettled, some rain",
+			"zambretti_t":           "Mostly very unsettled",
+			"zambretti_u":           "Occasional rain, worsening",
+			"zambretti_v":           "Rain at times, very unsettled",
+			"zambretti_w":           "Rain at frequent intervals",
+			"zambretti_x":           "Rain, very unsettled",
+			"zambretti_y":           "Stormy, may improve",
+			"zambretti_z":           "Stormy, much rain",
+
+			// comfort metrics and the ventilation advice
+			"dew_point":          "dew point",
+			"feels_like":         "feels like",
+			"advice_open_window": "Open the window",
+			"advice_mould_risk":  "Mould risk",
+			"advice_keep_closed": "Keep windows closed",
+
+			// air quality bands
+			"air_quality": "Air quality",
+			"aqi_0":       "Good",
+			"aqi_1":       "Moderate",
+			"aqi_2":       "Unhealthy for sensitive groups",
+			"aqi_3":       "Unhealthy",
+			"aqi_4":       "Very unhealthy",
+			"aqi_5":       "Hazardous",
+			"co2_0":       "Fresh",
+			"co2_1":       "Moderate",
+			"co2_2":       "Stuffy",
+			"co2_3":       "Very stuffy",
+
+			// severe weather alerts
+			"from":       "from",
+			"severity_1": "minor",
+			"severity_2": "moderate",
+			"severity_3": "severe",
+			"severity_4": "extreme",
+
+			// Home Assistant entity states
+			"state_on":          "on",
+			"state_off":         "off",
+			"state_open":        "open",
+			"state_closed":      "closed",
+			"state_opening":     "opening",
+			"state_closing":     "closing",
+			"state_locked":      "locked",
+			"state_unlocked":    "unlocked",
+			"state_home":        "home",
+			"state_not_home":    "away",
+			"state_detected":    "detected",
+			"state_clear":       "clear",
+			"state_unavailable": "unavailable",
+			"state_unknown":     "unknown",
+
+			// energy
+			"energy":      "Energy",
+			"solar":       "Solar",
+			"consumption": "Used",
+			"grid_import": "From grid",
+			"grid_export": "To grid",
+			"battery":     "Battery",
+
+			// public transport departures
+			"departures":    "Departures",
+			"now":           "now",
+			"minutes.other": "%d min",
+			"no_departures": "No departures",
+
+			// message board
+			"messages":    "Messages",
+			"no_messages": "No messages",
+
+			// tides
+			"tides":        "Tides",
+			"tide_high":    "High",
+			"tide_low":     "Low",
+			"tide_rising":  "Rising",
+			"tide_falling": "Falling",
+		},
+		months:        [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortWeekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		pluralForm:    englishPluralForm,
+	},
+	"ru": {
+		messages: map[string]string{
+			"pressure":             "Давление",
+			"above_norm":           "выше нормы",
+			"below_norm":           "ниже нормы",
+			"inside":               "Дома",
+			"outside":              "На улице",
+			"forecast":             "Прогноз",
+			"temp_max":             "t&nbsp;макс",
+			"temp_min":             "t&nbsp;мин",
+			"rain":                 "дождь",
+			"snow":                 "снег",
+			"wind":                 "ветер",
+			"daylight":             "Световой день",
+			"week":                 "нед",
+			"observed":             "(перенос)",
+			"agenda":               "Ближайшие события",
+			"today":                "сегодня",
+			"tomorrow":             "завтра",
+			"until":                "до",
+			"in_days.one":          "через %d день",
+			"in_days.few":          "через %d дня",
+			"in_days.other":        "через %d дней",
+			"more.other":           "и ещё %d",
+			"civil":                "гражд.",
+			"nautical":             "навиг.",
+			"astronomical":         "астрон.",
+			"solar_noon":           "полдень",
+			"day_length":           "день",
+			"polar_day":            "полярный день",
+			"polar_night":          "полярная ночь",
+			"moon_new":             "Новолуние",
+			"moon_waxing_crescent": "Молодая луна",
+			"moon_first_quarter":   "Первая четверть",
+			"moon_waxing_gibbous":  "Растущая луна",
+			"moon_full":            "Полнолуние",
+			"moon_waning_gibbous":  "Убывающая луна",
+			"moon_last_quarter":    "Последняя четверть",
+			"moon_waning_crescent": "Старая луна",
+			"yesterday":            "вчера",
+
+			// pressure tendency and the local forecast
+			"tendency_0":            "рост, затем падение",
+			"tendency_1":            "рост, затем без изменений",
+			"tendency_2":            "рост",
+			"tendency_3":            "без изменений, затем рост",
+			"tendency_4":            "без изменений",
+			"tendency_5":            "падение, затем рост",
+			"tendency_6":            "падение, затем без изменений",
+			"tendency_7":            "падение",
+			"tendency_8":            "без изменений, затем падение",
+			"zambretti_exceptional": "Необычная погода",
+			"zambretti_a":           "Устойчивая ясная погода",
+			"zambretti_b":           "Ясная погода",
+			"zambretti_c":           "Прояснение",
+			"zambretti_d":           "Ясно, становится неустойчиво",
+			"zambretti_e":           "Ясно, возможны ливни",
+			"zambretti_f":           "Преимущественно ясно, улучшение",
+			"zambretti_g":           "Преимущественно ясно, сначала возможны ливни",
+			"zambretti_h":           "Преимущественно ясно, позже ливни",
+			"zambretti_i":           "Сначала ливни, затем улучшение",
+			"zambretti_j":           "Переменчиво, улучшение",
+			"zambretti_k":           "Преимущественно ясно, вероятны ливни",
+			"zambretti_l":           "Довольно неустойчиво, позже прояснение",
+			"zambretti_m":           "Неустойчиво, вероятно улучшение",
+			"zambretti_n":           "Ливни с прояснениями",
+			"zambretti_o":           "Ливни, становится неустойчиво",
+			"zambretti_p":           "Переменчиво, местами дождь",
+			"zambretti_q":           "Неустойчиво, короткие прояснения",
+			"zambretti_r":           "Неустойчиво, позже дождь",
+			"zambretti_s":           "Неустойчиво, временами дождь",
+			"zambretti_t":           "Преимущественно очень неустойчиво",
+			"zambretti_u":           "Временами дождь, ухудшение",
+			"zambretti_v":           "Временами дождь, очень неустойчиво",
+			"zambretti_w":           "Частые дожди",
+			"zambretti_x":           "Дождь, очень неустойчиво",
+			"zambretti_y":           "Шторм, возможно улучшение",
+			"zambretti_z":           "Шторм, сильный дождь",
+
+			// comfort metrics and the ventilation advice
+			"dew_point":          "точка росы",
+			"feels_like":         "ощущается",
+			"advice_open_window": "Проветрите",
+			"advice_mould_risk":  "Риск плесени",
+			"advice_keep_closed": "Не открывайте окна",
+
+			// air quality bands
+			"air_quality": "Качество воздуха",
+			"aqi_0":       "Хорошее",
+			"aqi_1":       "Умеренное",
+			"aqi_2":       "Вредно для чувствительных",
+			"aqi_3":       "Вредное",
+			"aqi_4":       "Очень вредное",
+			"aqi_5":       "Опасное",
+			"co2_0":       "Свежо",
+			"co2_1":       "Умеренно",
+			"co2_2":       "Душно",
+			"co2_3":       "Очень душно",
+
+			// severe weather alerts
+			"from":       "с",
+			"severity_1": "слабая",
+			"severity_2": "умеренная",
+			"severity_3": "сильная",
+			"severity_4": "экстремальная",
+
+			// Home Assistant entity states
+			"state_on":          "вкл",
+			"state_off":         "выкл",
+			"state_open":        "открыто",
+			"state_closed":      "закрыто",
+			"state_opening":     "открывается",
+			"state_closing":     "закрывается",
+			"state_locked":      "заперто",
+			"state_unlocked":    "не заперто",
+			"state_home":        "дома",
+			"state_not_home":    "нет дома",
+			"state_detected":    "движение",
+			"state_clear":       "нет движения",
+			"state_unavailable": "недоступно",
+			"state_unknown":     "неизвестно",
+
+			// energy
+			"energy":      "Энергия",
+			"solar":       "Солнце",
+			"consumption": "Расход",
+			"grid_import": "Из сети",
+			"grid_export": "В сеть",
+			"battery":     "Батарея",
+
+			// public transport departures
+			"departures":    "Отправления",
+			"now":           "сейчас",
+			"minutes.other": "%d мин",
+			"no_departures": "Нет отправлений",
+
+			// message board
+			"messages":    "Сообщения",
+			"no_messages": "Нет сообщений",
+
+			// tides
+			"tides":        "Приливы",
+			"tide_high":    "Полная вода",
+			"tide_low":     "Малая вода",
+			"tide_rising":  "Прилив",
+			"tide_falling": "Отлив",
+		},
+		months:        [12]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"},
+		shortMonths:   [12]string{"Янв", "Фев", "Мар", "Апр", "Май", "Июн", "Июл", "Авг", "Сен", "Окт", "Ноя", "Дек"},
+		weekdays:      [7]string{"Воскресенье", "Понедельник", "Вторник", "Среда", "Четверг", "Пятница", "Суббота"},
+		shortWeekdays: [7]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		pluralForm:    russianPluralForm,
+	},
+}
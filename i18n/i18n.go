@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fmt"
+	"github.com/rotisserie/eris"
+	"strings"
+	"time"
+)
+
+// Translator provides widget text, month and weekday names in the language selected in the config.
+// Messages missing from the selected catalogue fall back to English and then to the key itself.
+type Translator interface {
+	Text(key string) string
+	Count(key string, n int) string
+	MonthName(month time.Month) string
+	ShortMonthName(month time.Month) string
+	WeekdayName(weekday time.Weekday) string
+	ShortWeekdayName(weekday time.Weekday) string
+	FirstDayOfWeek() time.Weekday
+	Language() string
+}
+
+type translator struct {
+	language       string
+	catalogue      *catalogue
+	firstDayOfWeek time.Weekday
+}
+
+func (t *translator) Text(key string) string {
+	if text, exists := t.catalogue.messages[key]; exists {
+		return text
+	}
+	if text, exists := catalogues[defaultLanguage].messages[key]; exists {
+		return text
+	}
+	return key
+}
+
+// Count formats a message with a number, picking the plural form of the language, e.g. "in 3 days".
+// Forms are stored under key.one, key.few and key.other, key.other is used when a form is missing.
+func (t *translator) Count(key string, n int) string {
+	form := t.catalogue.pluralForm(n)
+	text := t.Text(key + "." + form)
+	if text == key+"."+form {
+		text = t.Text(key + ".other")
+	}
+	return fmt.Sprintf(text, n)
+}
+
+func (t *translator) MonthName(month time.Month) string {
+	return t.catalogue.months[month-time.January]
+}
+
+func (t *translator) ShortMonthName(month time.Month) string {
+	return t.catalogue.shortMonths[month-time.January]
+}
+
+func (t *translator) WeekdayName(weekday time.Weekday) string {
+	return t.catalogue.weekdays[weekday]
+}
+
+func (t *translator) ShortWeekdayName(weekday time.Weekday) string {
+	return t.catalogue.shortWeekdays[weekday]
+}
+
+func (t *translator) FirstDayOfWeek() time.Weekday {
+	return t.firstDayOfWeek
+}
+
+func (t *translator) Language() string {
+	return t.language
+}
+
+// FuncMap returns template functions exposing the translator to widget templates, e.g. {{t "pressure"}}
+func FuncMap(t Translator) map[string]any {
+	return map[string]any{
+		"t": t.Text,
+	}
+}
+
+func NewTranslator(cfg config.ConfigApi) (Translator, error) {
+	language := strings.ToLower(cfg.GetLanguage())
+	catalogue, exists := catalogues[language]
+	if !exists {
+		return nil, eris.Errorf("no message catalogue for language '%s'", language)
+	}
+	var firstDayOfWeek time.Weekday
+	switch strings.ToLower(cfg.GetFirstDayOfWeek()) {
+	case "monday":
+		firstDayOfWeek = time.Monday
+	case "sunday":
+		firstDayOfWeek = time.Sunday
+	default:
+		return nil, eris.Errorf("unsupported first day of week '%s', expected 'monday' or 'sunday'", cfg.GetFirstDayOfWeek())
+	}
+	return &translator{
+		language:       language,
+		catalogue:      catalogue,
+		firstDayOfWeek: firstDayOfWeek,
+	}, nil
+}
@@ -127,7 +127,20 @@ func getHTMLRenderer() HTMLRenderer {
 
 const writePngFiles = false
 
+var widgetDithering = map[string]string{}
+
+// SetWidgetDithering sets the dithering of the widgets by their layout names, the others are not dithered
+func SetWidgetDithering(dithering map[string]string) {
+	widgetDithering = dithering
+}
+
+// RenderInPuppeteer renders the page without dithering
 func RenderInPuppeteer(html, filePrefix string, size image.Point) ([]byte, error) {
+	return RenderWidgetInPuppeteer("", html, filePrefix, size)
+}
+
+// RenderWidgetInPuppeteer renders the page with the dithering configured for the widget
+func RenderWidgetInPuppeteer(widget, html, filePrefix string, size image.Point) ([]byte, error) {
 	//pwd := utils.GetRootDir()
 	//htmlFileName := path.Join(pwd, filePrefix+".html")
 	//outputFileName := path.Join(pwd, filePrefix+".png")
@@ -171,11 +184,11 @@ func RenderInPuppeteer(html, filePrefix string, size image.Point) ([]byte, error
 	//if err != nil {
 	//	return nil, err
 	//}
-	raster, err := utils.ConvertToGrayScale(img)
-	if err != nil {
-		return nil, err
+	dither, exists := widgetDithering[widget]
+	if !exists {
+		dither = utils.DitherNone
 	}
-	return raster, nil
+	return utils.ConvertToGrayScale(img, dither), nil
 }
 
 func callPuppeteer(htmlFileName string, pngFileName string, size image.Point, pwd string) error {
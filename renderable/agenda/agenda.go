@@ -0,0 +1,125 @@
+package agenda
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// AgendaRenderable lists the upcoming special days, public holidays and calendar feed events with countdowns
+type AgendaRenderable interface {
+	renderable.Renderable
+}
+
+func NewAgendaRenderable(
+	rect image.Rectangle,
+	provider utils.TimeProvider,
+	cfg config.ConfigApi,
+	specialDaysProvider specialdays.SpecialDaysProvider,
+	translator i18n.Translator,
+) AgendaRenderable {
+	agendaHtmlTemplate, err := template.New("agendaHtml").Funcs(i18n.FuncMap(translator)).Parse(agendaHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(err, true))
+	}
+	return &agendaRenderable{
+		offset:              rect.Min,
+		size:                rect.Size(),
+		nextRedrawTime:      provider.UtcNow().AddDate(0, 0, -1),
+		timeProvider:        provider,
+		agendaHtmlTemplate:  agendaHtmlTemplate,
+		cfg:                 cfg,
+		specialDaysProvider: specialDaysProvider,
+		translator:          translator,
+	}
+}
+
+type agendaRenderable struct {
+	cfg                 config.ConfigApi
+	offset              image.Point
+	size                image.Point
+	nextRedrawTime      time.Time
+	cachedRaster        []byte
+	timeProvider        utils.TimeProvider
+	agendaHtmlTemplate  *template.Template
+	specialDaysProvider specialdays.SpecialDaysProvider
+	specialDaysVersion  uint64 // of the special days last drawn or scheduled to be drawn
+	translator          i18n.Translator
+}
+
+func (r *agendaRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+}
+
+func (_ *agendaRenderable) String() string {
+	return "agenda"
+}
+
+func (r *agendaRenderable) DisplayMode() uint8 {
+	return clib.GC16_Mode
+}
+
+func (r *agendaRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *agendaRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *agendaRenderable) Size() image.Point {
+	return r.size
+}
+
+// NextRedrawDateTimeUtc brings the redraw forward when the calendar feeds change in the background
+func (r *agendaRenderable) NextRedrawDateTimeUtc() time.Time {
+	if version := r.specialDaysProvider.Version(); version != r.specialDaysVersion {
+		r.specialDaysVersion = version
+		r.RedrawNow()
+	}
+	return r.nextRedrawTime
+}
+
+// RedrawFinished schedules the next redraw for the local midnight when all countdowns change
+func (r *agendaRenderable) RedrawFinished() {
+	now := r.timeProvider.LocalNow()
+	r.nextRedrawTime = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).UTC()
+}
+
+func (r *agendaRenderable) Raster() []byte {
+	return r.cachedRaster
+}
+
+func (r *agendaRenderable) Render() error {
+	now := r.timeProvider.LocalNow()
+	maxRows := (r.size.Y - headerHeight) / rowHeight
+	data := createAgendaData(
+		now,
+		r.specialDaysProvider.GetSpecialDays(),
+		r.cfg.GetAgendaDaysAhead(),
+		r.cfg.GetAgendaMaxItems(),
+		maxRows,
+		r.size.X,
+		r.translator,
+	)
+	html, err := renderAgenda(data, r.agendaHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	filePrefix := "agenda_" + strconv.FormatInt(now.UnixNano(), 16)
+	raster, err := puppettier.RenderWidgetInPuppeteer("agenda", html, filePrefix, r.size)
+	if err != nil {
+		return err
+	}
+	r.cachedRaster = raster
+	return nil
+}
@@ -0,0 +1,167 @@
+package agenda
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// sizes of the agenda elements in pixels, used to work out how many rows fit into the widget
+const (
+	headerHeight = 90
+	rowHeight    = 70
+)
+
+type agendaRow struct {
+	Date      string
+	Text      string
+	Countdown string
+	Today     bool
+}
+
+type agendaData struct {
+	Rows     []*agendaRow
+	More     string // "and N more" if not all events fit, empty otherwise
+	Width    int
+	RootPath string
+}
+
+// createAgendaData lists occurrences of the special days from today up to daysAhead days ahead in chronological order,
+// at most maxRows rows are returned with the last one replaced by the "and N more" line if there are more events
+func createAgendaData(
+	today time.Time,
+	specialDays []*config.SpecialDayOrInterval,
+	daysAhead int,
+	maxItems int,
+	maxRows int,
+	width int,
+	translator i18n.Translator,
+) *agendaData {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	occurrences := specialdays.Resolve(specialDays, today, today.AddDate(0, 0, daysAhead))
+	specialdays.SortByStart(occurrences)
+	if len(occurrences) > maxItems {
+		occurrences = occurrences[:maxItems]
+	}
+	res := &agendaData{Rows: make([]*agendaRow, 0, len(occurrences)), Width: width, RootPath: utils.GetRootDir()}
+	shown := len(occurrences)
+	if shown > maxRows {
+		shown = max(maxRows-1, 0)
+		res.More = translator.Count("more", len(occurrences)-shown)
+	}
+	for _, o := range occurrences[:shown] {
+		res.Rows = append(res.Rows, &agendaRow{
+			Date:      formatDate(o.Start, translator),
+			Text:      o.SpecialDay.DisplayText,
+			Countdown: countdown(o, today, translator),
+			Today:     o.Covers(today),
+		})
+	}
+	return res
+}
+
+func formatDate(day time.Time, translator i18n.Translator) string {
+	return translator.ShortWeekdayName(day.Weekday()) + " " + strconv.Itoa(day.Day()) + " " + translator.ShortMonthName(day.Month())
+}
+
+func countdown(o *specialdays.Occurrence, today time.Time, translator i18n.Translator) string {
+	days := int(o.Start.Sub(today).Hours() / 24)
+	switch {
+	case days <= 0 && o.IsMultiDay() && o.End.After(today):
+		return translator.Text("until") + " " + strconv.Itoa(o.End.Day()) + " " + translator.ShortMonthName(o.End.Month())
+	case days <= 0:
+		return translator.Text("today")
+	case days == 1:
+		return translator.Text("tomorrow")
+	}
+	return translator.Count("in_days", days)
+}
+
+var agendaHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.agendaHeader {
+    height: ` + strconv.Itoa(headerHeight) + `px;
+    line-height: ` + strconv.Itoa(headerHeight) + `px;
+    font-size: 64px;
+    font-family: "envy-code-r", serif;
+    font-weight: bold;
+    background: #555;
+    color: #eee;
+    padding-left: 20px;
+}
+
+.agendaTable {
+    width: {{ .Width }}px;
+    table-layout: fixed;
+    border: 0;
+    border-spacing: 0;
+}
+
+.agendaRow td {
+    height: ` + strconv.Itoa(rowHeight) + `px;
+    font-size: 44px;
+    font-family: "bront-ubuntu", serif;
+    white-space: nowrap;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    border-bottom: 1px solid #aaa;
+}
+
+.agendaDate {
+    width: 230px;
+    padding-left: 20px;
+    font-family: "cartograph", serif;
+}
+
+.agendaCountdown {
+    width: 300px;
+    text-align: right;
+    padding-right: 20px;
+}
+
+.today {
+    color: #fff;
+    background: #222;
+    font-weight: bold;
+}
+
+.agendaMore {
+    height: ` + strconv.Itoa(rowHeight) + `px;
+    font-size: 44px;
+    font-family: "bront-ubuntu", serif;
+    padding-left: 20px;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div class="agendaHeader">{{t "agenda"}}</div>
+  <table class="agendaTable">
+{{range .Rows}}    <tr class="agendaRow{{if .Today}} today{{end}}">
+      <td class="agendaDate">{{.Date}}</td>
+      <td class="agendaText">{{.Text}}</td>
+      <td class="agendaCountdown">{{.Countdown}}</td>
+    </tr>
+{{end}}
+  </table>
+{{if .More}}  <div class="agendaMore">{{.More}}</div>
+{{end}}
+</body>
+</html>
+`
+
+func renderAgenda(data *agendaData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,132 @@
+package air_quality
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/airquality"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"math/rand"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// AirQualityRenderable shows CO2, particulate matter and the AQI with their bands and trends
+type AirQualityRenderable interface {
+	renderable.Renderable
+}
+
+func NewAirQualityRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	airQualityProvider airquality.AirQualityDataProvider,
+	translator i18n.Translator,
+) AirQualityRenderable {
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	airQualityHtmlTemplate, err := template.New("airQualityHtml").Parse(airQualityHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing air quality template"), true))
+	}
+	return &airQualityRenderable{
+		offset:                 rect.Min,
+		size:                   rect.Size(),
+		nextRedrawTime:         timeProvider.UtcNow(),
+		raster:                 raster,
+		timeProvider:           timeProvider,
+		config:                 cfg,
+		airQualityProvider:     airQualityProvider,
+		translator:             translator,
+		airQualityHtmlTemplate: airQualityHtmlTemplate,
+	}
+}
+
+type airQualityRenderable struct {
+	offset                 image.Point
+	size                   image.Point
+	nextRedrawTime         time.Time
+	raster                 []byte
+	timeProvider           utils.TimeProvider
+	config                 config.ConfigApi
+	airQualityProvider     airquality.AirQualityDataProvider
+	translator             i18n.Translator
+	airQualityHtmlTemplate *template.Template
+	cached                 *airquality.AirQualityData // nil until the first successful load
+	failed                 bool
+}
+
+func (r *airQualityRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+}
+
+func (_ *airQualityRenderable) String() string {
+	return "air_quality"
+}
+
+func (r *airQualityRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *airQualityRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *airQualityRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *airQualityRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *airQualityRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+func (r *airQualityRenderable) RedrawFinished() {
+	// refresh at random intervals 200 to 400 seconds (approximately every 5 minutes)
+	r.nextRedrawTime = r.timeProvider.UtcNow().Add(time.Second * time.Duration(rand.Intn(200)+200))
+}
+
+func (r *airQualityRenderable) Raster() []byte {
+	return r.raster
+}
+
+func (r *airQualityRenderable) Render() error {
+	data, err := r.airQualityProvider.GetAirQuality()
+	if err != nil {
+		println(eris.ToString(eris.Wrap(err, "Error loading air quality"), true))
+		if r.failed {
+			return nil
+		}
+		r.failed = true
+		if r.cached == nil {
+			r.cached = &airquality.AirQualityData{}
+		}
+	} else {
+		if !r.failed && r.cached != nil && *data == *r.cached {
+			return nil
+		}
+		r.failed = false
+		r.cached = data
+	}
+	warningAqi, warningCo2 := r.config.GetAirQualityWarnings()
+	html, err := renderAirQuality(createAirQualityData(r.cached, r.failed, warningAqi, warningCo2, r.translator), r.airQualityHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("air_quality", html, "air_quality_"+strconv.FormatInt(time.Now().Unix(), 10), r.size)
+	if err != nil {
+		return err
+	}
+	r.raster = raster
+	return nil
+}
@@ -0,0 +1,152 @@
+package air_quality
+
+import (
+	"fkirill.org/eink-meteo-station/data/airquality"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/images"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type airQualityRow struct {
+	Label string
+	Value string
+	Unit  string
+	Band  string // empty if the value has no bands
+	Trend string // image of the trend arrow
+}
+
+type airQualityData struct {
+	Title      string
+	Warning    bool // the data couldn't be loaded or a threshold is crossed
+	Rows       []*airQualityRow
+	WarningPng string
+	RootPath   string
+}
+
+func trendPng(trend int) string {
+	switch trend {
+	case airquality.TrendRising:
+		return images.Rising_png_src
+	case airquality.TrendFalling:
+		return images.Falling_png_src
+	}
+	return images.Steady_png_src
+}
+
+// createAirQualityData lists CO2 (if there is a sensor), PM2.5, PM10 (if known) and AQI, the last data is shown
+// with the warning sign if the current one couldn't be loaded
+func createAirQualityData(
+	data *airquality.AirQualityData,
+	failed bool,
+	warningAqi, warningCo2 int,
+	translator i18n.Translator,
+) *airQualityData {
+	res := &airQualityData{
+		Title:      translator.Text("air_quality"),
+		Warning:    failed || data.Aqi > warningAqi || (data.HasCo2 && data.Co2 > float64(warningCo2)),
+		Rows:       make([]*airQualityRow, 0, 4),
+		WarningPng: images.Warning_png_src,
+		RootPath:   utils.GetRootDir(),
+	}
+	if data.HasCo2 {
+		res.Rows = append(res.Rows, &airQualityRow{
+			Label: "CO₂",
+			Value: strconv.Itoa(int(data.Co2)),
+			Unit:  "ppm",
+			Band:  translator.Text("co2_" + strconv.Itoa(data.Co2Band)),
+			Trend: trendPng(data.Co2Trend),
+		})
+	}
+	res.Rows = append(res.Rows, &airQualityRow{
+		Label: "PM2.5",
+		Value: strconv.FormatFloat(data.Pm25, 'f', 1, 64),
+		Unit:  "µg/m³",
+		Trend: trendPng(data.Pm25Trend),
+	})
+	if data.HasPm10 {
+		res.Rows = append(res.Rows, &airQualityRow{
+			Label: "PM10",
+			Value: strconv.Itoa(int(data.Pm10)),
+			Unit:  "µg/m³",
+		})
+	}
+	res.Rows = append(res.Rows, &airQualityRow{
+		Label: "AQI",
+		Value: strconv.Itoa(data.Aqi),
+		Band:  translator.Text("aqi_" + strconv.Itoa(data.AqiBand)),
+		Trend: trendPng(data.AqiTrend),
+	})
+	return res
+}
+
+var airQualityHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.airQualityTable {
+    border: 0;
+    border-spacing: 0 10px;
+}
+
+.airQualityTable td {
+    white-space: nowrap;
+    vertical-align: baseline;
+}
+
+.airLabel {
+    font-size: 32px;
+    font-family: "verily", serif;
+    font-weight: bold;
+    padding-right: 20px;
+}
+
+.airValue {
+    font-size: 72px;
+    font-family: "cartograph", serif;
+    text-align: right;
+}
+
+.airUnit {
+    font-size: 28px;
+    font-family: "cartograph", serif;
+    padding-left: 8px;
+}
+
+.airBand {
+    display: block;
+    font-size: 24px;
+    font-family: "bront-ubuntu", serif;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div style="padding: 20px 40px">
+    <div>
+      <span style="border-radius: 40px; border: 4px solid; font-size: 48px; padding: 8px 13px; font-family: verily; font-weight: bold">{{.Title}}</span>
+      {{if .Warning}}<img src="{{ .WarningPng }}" width="67" height="67"/>{{end}}
+    </div>
+    <table class="airQualityTable">
+{{range .Rows}}      <tr>
+        <td class="airLabel">{{.Label}}</td>
+        <td class="airValue">{{.Value}}</td>
+        <td class="airUnit">{{.Unit}}{{if .Band}}<span class="airBand">{{.Band}}</span>{{end}}</td>
+        <td>{{if .Trend}}<img src="{{ .Trend }}" width="30" height="30"/>{{end}}</td>
+      </tr>
+{{end}}    </table>
+  </div>
+</body>
+</html>
+`
+
+func renderAirQuality(data *airQualityData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,163 @@
+package alert_banner
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/data/alerts"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// the alerts are checked this often, the feeds themselves are refreshed at their own intervals
+const alertCheckInterval = 5 * time.Minute
+
+// AlertBannerRenderable takes over the place of another widget while a severe weather alert is active
+// and gives it back once the alerts are over
+type AlertBannerRenderable interface {
+	renderable.Renderable
+}
+
+func NewAlertBannerRenderable(
+	slot renderable.Renderable,
+	timeProvider utils.TimeProvider,
+	alertsProvider alerts.AlertsProvider,
+	units units.Units,
+	translator i18n.Translator,
+) AlertBannerRenderable {
+	alertBannerHtmlTemplate, err := template.New("alertBannerHtml").Parse(alertBannerHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing alert banner template"), true))
+	}
+	return &alertBannerRenderable{
+		slot:                    slot,
+		nextCheckTime:           timeProvider.UtcNow(),
+		timeProvider:            timeProvider,
+		alertsProvider:          alertsProvider,
+		units:                   units,
+		translator:              translator,
+		alertBannerHtmlTemplate: alertBannerHtmlTemplate,
+	}
+}
+
+type alertBannerRenderable struct {
+	slot                    renderable.Renderable
+	nextCheckTime           time.Time
+	timeProvider            utils.TimeProvider
+	alertsProvider          alerts.AlertsProvider
+	units                   units.Units
+	translator              i18n.Translator
+	alertBannerHtmlTemplate *template.Template
+	bannerRaster            []byte
+	shown                   *alertBannerData // nil while the slot widget is shown
+	slotRendered            bool             // the slot widget was rendered in this redraw
+	bannerChanged           bool             // the banner was drawn anew in this redraw
+}
+
+func (r *alertBannerRenderable) RedrawNow() {
+	r.nextCheckTime = r.timeProvider.UtcNow()
+	r.slot.RedrawNow()
+}
+
+// String keeps the name of the slot widget, the banner stands in for it in the layout
+func (r *alertBannerRenderable) String() string {
+	return r.slot.String()
+}
+
+// DisplayMode uses the full refresh for a new banner to wipe what was there before
+func (r *alertBannerRenderable) DisplayMode() uint8 {
+	if r.bannerChanged {
+		return clib.GC16_Mode
+	}
+	if r.shown != nil {
+		return clib.A2_Mode
+	}
+	return r.slot.DisplayMode()
+}
+
+func (r *alertBannerRenderable) Offset() image.Point {
+	return r.slot.Offset()
+}
+
+func (r *alertBannerRenderable) BoundingBox() image.Rectangle {
+	return r.slot.BoundingBox()
+}
+
+func (r *alertBannerRenderable) Size() image.Point {
+	return r.slot.Size()
+}
+
+func (r *alertBannerRenderable) NextRedrawDateTimeUtc() time.Time {
+	if r.shown != nil {
+		return r.nextCheckTime
+	}
+	slotTime := r.slot.NextRedrawDateTimeUtc()
+	if slotTime.Before(r.nextCheckTime) {
+		return slotTime
+	}
+	return r.nextCheckTime
+}
+
+func (r *alertBannerRenderable) RedrawFinished() {
+	now := r.timeProvider.UtcNow()
+	if !now.Before(r.nextCheckTime) {
+		r.nextCheckTime = now.Add(alertCheckInterval)
+	}
+	if r.slotRendered {
+		r.slot.RedrawFinished()
+		r.slotRendered = false
+	}
+	r.bannerChanged = false
+}
+
+func (r *alertBannerRenderable) Raster() []byte {
+	if r.shown != nil {
+		return r.bannerRaster
+	}
+	return r.slot.Raster()
+}
+
+func (r *alertBannerRenderable) Render() error {
+	now := r.timeProvider.UtcNow()
+	var active []*alerts.Alert
+	if !now.Before(r.nextCheckTime) {
+		active = r.alertsProvider.GetActiveAlerts()
+	} else if r.shown != nil {
+		return nil
+	}
+	if len(active) > 0 {
+		data := createAlertBannerData(active, r.timeProvider.LocalNow(), r.Size(), r.units, r.translator)
+		if r.shown != nil && *data == *r.shown {
+			return nil
+		}
+		html, err := renderAlertBanner(data, r.alertBannerHtmlTemplate)
+		if err != nil {
+			return err
+		}
+		raster, err := puppettier.RenderWidgetInPuppeteer(r.String(), html, "alert_banner_"+strconv.FormatInt(now.Unix(), 10), r.Size())
+		if err != nil {
+			return err
+		}
+		r.bannerRaster = raster
+		r.shown = data
+		r.bannerChanged = true
+		return nil
+	}
+	if r.shown != nil {
+		// the alerts are over, the widget underneath is drawn again from scratch
+		r.shown = nil
+		r.bannerRaster = nil
+		r.slot.RedrawNow()
+	}
+	if now.Before(r.slot.NextRedrawDateTimeUtc()) {
+		return nil
+	}
+	r.slotRendered = true
+	return r.slot.Render()
+}
@@ -0,0 +1,141 @@
+package alert_banner
+
+import (
+	"fkirill.org/eink-meteo-station/data/alerts"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/images"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"image"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// alertBannerData shows the most important alert, comparable to skip redrawing the same banner
+type alertBannerData struct {
+	Severity   string
+	Extreme    bool // drawn white on black
+	Event      string
+	Headline   string
+	Area       string
+	Period     string // from and until, empty if neither is known
+	More       string // number of the other alerts, empty if there are none
+	Width      int
+	Height     int
+	WarningPng string
+	RootPath   string
+}
+
+// formatMoment formats the time with the weekday unless it is today
+func formatMoment(t time.Time, now time.Time, units units.Units, translator i18n.Translator) string {
+	t = t.In(now.Location())
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return units.FormatTime(t)
+	}
+	return translator.ShortWeekdayName(t.Weekday()) + " " + units.FormatTime(t)
+}
+
+func createAlertBannerData(
+	active []*alerts.Alert,
+	now time.Time,
+	size image.Point,
+	units units.Units,
+	translator i18n.Translator,
+) *alertBannerData {
+	alert := active[0]
+	res := &alertBannerData{
+		Extreme:    alert.Severity == alerts.SeverityExtreme,
+		Event:      alert.Event,
+		Headline:   alert.Headline,
+		Area:       alert.Area,
+		Width:      size.X,
+		Height:     size.Y,
+		WarningPng: images.Warning_png_src,
+		RootPath:   utils.GetRootDir(),
+	}
+	if alert.Severity != alerts.SeverityUnknown {
+		res.Severity = translator.Text("severity_" + strconv.Itoa(alert.Severity))
+	}
+	if res.Headline == res.Event {
+		res.Headline = ""
+	}
+	period := make([]string, 0, 2)
+	if alert.Onset.After(now) {
+		period = append(period, translator.Text("from")+" "+formatMoment(alert.Onset, now, units, translator))
+	}
+	if !alert.Expires.IsZero() {
+		period = append(period, translator.Text("until")+" "+formatMoment(alert.Expires, now, units, translator))
+	}
+	res.Period = strings.Join(period, " ")
+	if len(active) > 1 {
+		res.More = translator.Count("more", len(active)-1)
+	}
+	return res
+}
+
+var alertBannerHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.alertBanner {
+    box-sizing: border-box;
+    width: {{ .Width }}px;
+    height: {{ .Height }}px;
+    padding: 30px 40px;
+    border: 8px solid #000;
+    overflow: hidden;
+{{if .Extreme}}    background: #000;
+    color: #fff;
+{{end}}}
+
+.alertEvent {
+    font-size: 64px;
+    font-family: "verily", serif;
+    font-weight: bold;
+    text-transform: uppercase;
+}
+
+.alertSeverity {
+    font-size: 32px;
+    font-family: "bront-ubuntu", serif;
+}
+
+.alertHeadline {
+    margin-top: 20px;
+    font-size: 40px;
+    font-family: "bront-ubuntu", serif;
+}
+
+.alertDetails {
+    margin-top: 20px;
+    font-size: 32px;
+    font-family: "cartograph", serif;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div class="alertBanner">
+    <div>
+      {{if not .Extreme}}<img src="{{ .WarningPng }}" width="67" height="67"/>{{end}}
+      <span class="alertEvent">{{html .Event}}</span>
+      {{if .Severity}}<span class="alertSeverity">{{.Severity}}</span>{{end}}
+    </div>
+    {{if .Headline}}<div class="alertHeadline">{{html .Headline}}</div>{{end}}
+    <div class="alertDetails">{{html .Area}}{{if and .Area .Period}} &middot; {{end}}{{.Period}}</div>
+    {{if .More}}<div class="alertDetails">{{.More}}</div>{{end}}
+  </div>
+</body>
+</html>
+`
+
+func renderAlertBanner(data *alertBannerData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
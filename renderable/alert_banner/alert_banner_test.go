@@ -0,0 +1,139 @@
+package alert_banner
+
+import (
+	"bytes"
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/data/alerts"
+	"image"
+	"testing"
+	"time"
+)
+
+type fixedTime struct {
+	now time.Time
+}
+
+func (f *fixedTime) LocalNow() time.Time {
+	return f.now
+}
+
+func (f *fixedTime) UtcNow() time.Time {
+	return f.now
+}
+
+// expiringAlerts returns the alerts which haven't expired by the time of the clock
+type expiringAlerts struct {
+	clock  *fixedTime
+	alerts []*alerts.Alert
+}
+
+func (p *expiringAlerts) GetActiveAlerts() []*alerts.Alert {
+	res := make([]*alerts.Alert, 0)
+	for _, alert := range p.alerts {
+		if p.clock.now.Before(alert.Expires) {
+			res = append(res, alert)
+		}
+	}
+	return res
+}
+
+// slotWidget redraws every minute
+type slotWidget struct {
+	clock      *fixedTime
+	nextRedraw time.Time
+	raster     []byte
+	renders    int
+	redrawNows int
+}
+
+func (s *slotWidget) BoundingBox() image.Rectangle {
+	return image.Rectangle{Max: s.Size()}
+}
+
+func (s *slotWidget) Offset() image.Point {
+	return image.Point{}
+}
+
+func (s *slotWidget) Size() image.Point {
+	return image.Point{X: 4, Y: 2}
+}
+
+func (s *slotWidget) Raster() []byte {
+	return s.raster
+}
+
+func (s *slotWidget) NextRedrawDateTimeUtc() time.Time {
+	return s.nextRedraw
+}
+
+func (s *slotWidget) RedrawFinished() {
+	s.nextRedraw = s.clock.now.Add(time.Minute)
+}
+
+func (s *slotWidget) Render() error {
+	s.renders++
+	return nil
+}
+
+func (s *slotWidget) DisplayMode() uint8 {
+	return clib.GL16_Mode
+}
+
+func (s *slotWidget) String() string {
+	return "forecast"
+}
+
+func (s *slotWidget) RedrawNow() {
+	s.redrawNows++
+	s.nextRedraw = s.clock.now
+}
+
+func TestBannerHandsBackOnExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	clock := &fixedTime{now: start}
+	slot := &slotWidget{clock: clock, nextRedraw: start.Add(time.Minute), raster: bytes.Repeat([]byte{0xff}, 8)}
+	provider := &expiringAlerts{clock: clock, alerts: []*alerts.Alert{{
+		Id:       "wind",
+		Event:    "Wind Advisory",
+		Severity: alerts.SeveritySevere,
+		Expires:  start.Add(3 * time.Minute),
+	}}}
+	r := NewAlertBannerRenderable(slot, clock, provider, nil, nil).(*alertBannerRenderable)
+	// the banner as it was drawn at the start, rendering it needs the browser
+	banner := bytes.Repeat([]byte{0}, 8)
+	r.shown = &alertBannerData{Event: "Wind Advisory"}
+	r.bannerRaster = banner
+	r.nextCheckTime = start.Add(alertCheckInterval)
+
+	// the slot widget is not rendered under the banner
+	if !r.NextRedrawDateTimeUtc().Equal(start.Add(alertCheckInterval)) {
+		t.Errorf("got the next redraw at %v with the banner, expected the next check", r.NextRedrawDateTimeUtc())
+	}
+	clock.now = start.Add(time.Minute)
+	if err := r.Render(); err != nil {
+		t.Fatal(err)
+	}
+	r.RedrawFinished()
+	if slot.renders != 0 || !bytes.Equal(r.Raster(), banner) || r.DisplayMode() != clib.A2_Mode {
+		t.Fatalf("got %d slot renders and display mode %d under the banner", slot.renders, r.DisplayMode())
+	}
+
+	// the alert expires between the checks, the banner goes at the next one
+	clock.now = start.Add(alertCheckInterval)
+	if err := r.Render(); err != nil {
+		t.Fatal(err)
+	}
+	if r.shown != nil || r.bannerRaster != nil {
+		t.Fatal("the banner is still shown after the alert expired")
+	}
+	if slot.redrawNows != 1 || slot.renders != 1 {
+		t.Errorf("got %d redraw requests and %d renders of the slot widget, expected one of each", slot.redrawNows, slot.renders)
+	}
+	if !bytes.Equal(r.Raster(), slot.raster) || r.DisplayMode() != clib.GL16_Mode {
+		t.Errorf("got display mode %d after the alert, expected the one of the slot widget", r.DisplayMode())
+	}
+	r.RedrawFinished()
+	if !r.NextRedrawDateTimeUtc().Equal(clock.now.Add(time.Minute)) {
+		t.Errorf("got the next redraw at %v, expected the one of the slot widget", r.NextRedrawDateTimeUtc())
+	}
+}
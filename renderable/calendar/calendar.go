@@ -2,7 +2,8 @@ package calendar
 
 import (
 	"fkirill.org/eink-meteo-station/clib"
-	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"fkirill.org/eink-meteo-station/i18n"
 	"fkirill.org/eink-meteo-station/puppettier"
 	"fkirill.org/eink-meteo-station/renderable"
 	"fkirill.org/eink-meteo-station/renderable/utils"
@@ -21,31 +22,45 @@ type CalendarRenderable interface {
 func NewCalendarRenderable(
 	rect image.Rectangle,
 	provider utils.TimeProvider,
-	cfg config.ConfigApi,
-) CalendarRenderable {
-	currentMonthHtmlTemplate, err := template.New("currentMonthHtml").Parse(currentMonthHtmlTemplateText)
+	specialDaysProvider specialdays.SpecialDaysProvider,
+	translator i18n.Translator,
+	mode string,
+) (CalendarRenderable, error) {
+	if mode == "" {
+		mode = monthMode
+	}
+	err := validateMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	currentMonthHtmlTemplate, err := template.New("currentMonthHtml").Funcs(i18n.FuncMap(translator)).Parse(modeTemplateText(mode))
 	if err != nil {
 		panic(eris.ToString(err, true))
 	}
 	return &calendarRenderable{
+		mode:                     mode,
 		offset:                   rect.Min,
 		size:                     rect.Size(),
 		nextRedrawTime:           provider.UtcNow().AddDate(0, 0, -1),
 		cachedRaster:             nil,
 		timeProvider:             provider,
 		currentMonthHtmlTemplate: currentMonthHtmlTemplate,
-		cfg:                      cfg,
-	}
+		specialDaysProvider:      specialDaysProvider,
+		translator:               translator,
+	}, nil
 }
 
 type calendarRenderable struct {
-	cfg                      config.ConfigApi
+	mode                     string
+	specialDaysProvider      specialdays.SpecialDaysProvider
+	specialDaysVersion       uint64 // of the special days last drawn or scheduled to be drawn
 	offset                   image.Point
 	size                     image.Point
 	nextRedrawTime           time.Time
 	cachedRaster             []byte
 	timeProvider             utils.TimeProvider
 	currentMonthHtmlTemplate *template.Template
+	translator               i18n.Translator
 }
 
 func (c *calendarRenderable) RedrawNow() {
@@ -72,7 +87,12 @@ func (r *calendarRenderable) Size() image.Point {
 	return r.size
 }
 
+// NextRedrawDateTimeUtc brings the redraw forward when the calendar feeds change in the background
 func (r *calendarRenderable) NextRedrawDateTimeUtc() time.Time {
+	if version := r.specialDaysProvider.Version(); version != r.specialDaysVersion {
+		r.specialDaysVersion = version
+		r.RedrawNow()
+	}
 	return r.nextRedrawTime
 }
 
@@ -81,8 +101,10 @@ func (r *calendarRenderable) Area() int {
 	return size.X * size.Y
 }
 
+// RedrawFinished schedules the next redraw for the local midnight when the current day moves
 func (r *calendarRenderable) RedrawFinished() {
-	r.nextRedrawTime = r.timeProvider.LocalNow().Truncate(24*time.Hour).AddDate(0, 0, 1).UTC()
+	now := r.timeProvider.LocalNow()
+	r.nextRedrawTime = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).UTC()
 }
 
 func (r *calendarRenderable) Raster() []byte {
@@ -91,12 +113,18 @@ func (r *calendarRenderable) Raster() []byte {
 
 func (r *calendarRenderable) Render() error {
 	now := r.timeProvider.LocalNow()
-	html, err := r.renderCurrentMonthHtml(now.Year(), now.Month(), now.Day())
+	var html string
+	var err error
+	if r.mode == monthMode {
+		html, err = r.renderCurrentMonthHtml(now.Year(), now.Month(), now.Day())
+	} else {
+		html, err = renderMode(r.mode, now, r.specialDaysProvider.GetSpecialDays(), r.translator, r.currentMonthHtmlTemplate)
+	}
 	if err != nil {
 		return err
 	}
 	filePrefix := "calendar_" + strconv.FormatInt(now.UnixNano(), 16)
-	raster, err2 := puppettier.RenderInPuppeteer(html, filePrefix, r.size)
+	raster, err2 := puppettier.RenderWidgetInPuppeteer("calendar", html, filePrefix, r.size)
 	if err2 != nil {
 		return err2
 	}
@@ -105,5 +133,5 @@ func (r *calendarRenderable) Render() error {
 }
 
 func (r *calendarRenderable) renderCurrentMonthHtml(year int, month time.Month, currentDay int) (string, error) {
-	return renderCurrentMonth(year, month, currentDay, r.cfg.GetSpecialDays(), r.currentMonthHtmlTemplate)
+	return renderCurrentMonth(year, month, currentDay, r.specialDaysProvider.GetSpecialDays(), r.translator, r.currentMonthHtmlTemplate)
 }
@@ -0,0 +1,345 @@
+package calendar
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// calendar modes, selected with the mode of the calendar widget in the layout config
+const (
+	monthMode     = "month"      // current month with a large current day, the default
+	twoMonthsMode = "two_months" // current and next month side by side
+	rollingMode   = "rolling"    // 5 weeks starting with the current one, crossing month boundaries
+	yearMode      = "year"       // compact grid of all months of the current year
+)
+
+const rollingWeeks = 5
+
+type multiMonthData struct {
+	Title      string
+	DayHeaders []dayHeader
+	Months     []*calendarData
+	Legend     string
+	RootPath   string
+}
+
+type rollingData struct {
+	Title      string
+	DayHeaders []dayHeader
+	Rows       []calendarDataRow
+	Legend     string
+	RootPath   string
+}
+
+func validateMode(mode string) error {
+	switch mode {
+	case monthMode, twoMonthsMode, rollingMode, yearMode:
+		return nil
+	}
+	return eris.Errorf("unknown calendar mode '%s', expected one of %s, %s, %s or %s", mode, monthMode, twoMonthsMode, rollingMode, yearMode)
+}
+
+func todayTitle(today time.Time, translator i18n.Translator) string {
+	return translator.WeekdayName(today.Weekday()) + " " + strconv.Itoa(today.Day()) + " " + translator.MonthName(today.Month())
+}
+
+func createTwoMonthsData(today time.Time, specialDays []*config.SpecialDayOrInterval, translator i18n.Translator) (*multiMonthData, error) {
+	current, err := createCalendarData(today.Year(), today.Month(), today.Day(), specialDays, translator)
+	if err != nil {
+		return nil, err
+	}
+	nextMonthStart := time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	next, err := createCalendarData(nextMonthStart.Year(), nextMonthStart.Month(), 0, specialDays, translator)
+	if err != nil {
+		return nil, err
+	}
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return &multiMonthData{
+		Title:      todayTitle(today, translator),
+		DayHeaders: dayHeaders(translator),
+		Months:     []*calendarData{current, next},
+		Legend:     calendarLegend(monthStart, nextMonthStart.AddDate(0, 1, -1), specialDays, translator),
+		RootPath:   utils.GetRootDir(),
+	}, nil
+}
+
+func createYearData(today time.Time, specialDays []*config.SpecialDayOrInterval, translator i18n.Translator) (*multiMonthData, error) {
+	res := &multiMonthData{
+		Title:      strconv.Itoa(today.Year()),
+		DayHeaders: dayHeaders(translator),
+		RootPath:   utils.GetRootDir(),
+	}
+	for month := time.January; month <= time.December; month++ {
+		currentDay := 0
+		if month == today.Month() {
+			currentDay = today.Day()
+		}
+		data, err := createCalendarData(today.Year(), month, currentDay, specialDays, translator)
+		if err != nil {
+			return nil, err
+		}
+		res.Months = append(res.Months, data)
+	}
+	return res, nil
+}
+
+func createRollingData(today time.Time, specialDays []*config.SpecialDayOrInterval, translator i18n.Translator) *rollingData {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	firstDayOfWeek := translator.FirstDayOfWeek()
+	from := today.AddDate(0, 0, -weekdayColumn(today.Weekday(), firstDayOfWeek))
+	to := from.AddDate(0, 0, 7*rollingWeeks-1)
+	// cells are built per month, the view covers at most 2 of them
+	months := make(map[time.Month][]calendarDataDay)
+	rows := make([]calendarDataRow, 0, rollingWeeks)
+	for rowStart := from; !rowStart.After(to); rowStart = rowStart.AddDate(0, 0, 7) {
+		row := calendarDataRow{WeekNum: weekNumber(rowStart, firstDayOfWeek), Days: make([]calendarDataDay, 7)}
+		for i := range row.Days {
+			date := rowStart.AddDate(0, 0, i)
+			days, exists := months[date.Month()]
+			if !exists {
+				currentDay := 0
+				if date.Month() == today.Month() {
+					currentDay = today.Day()
+				}
+				days = monthDays(date.Year(), date.Month(), currentDay, specialDays)
+				months[date.Month()] = days
+			}
+			row.Days[i] = days[date.Day()]
+			if date.Day() == 1 {
+				row.Days[i].Month = translator.ShortMonthName(date.Month())
+			}
+		}
+		rows = append(rows, row)
+	}
+	return &rollingData{
+		Title:      todayTitle(today, translator),
+		DayHeaders: dayHeaders(translator),
+		Rows:       rows,
+		Legend:     calendarLegend(from, to, specialDays, translator),
+		RootPath:   utils.GetRootDir(),
+	}
+}
+
+func renderMode(mode string, today time.Time, specialDays []*config.SpecialDayOrInterval, translator i18n.Translator, template *template.Template) (string, error) {
+	var data any
+	var err error
+	switch mode {
+	case twoMonthsMode:
+		data, err = createTwoMonthsData(today, specialDays, translator)
+	case yearMode:
+		data, err = createYearData(today, specialDays, translator)
+	case rollingMode:
+		data = createRollingData(today, specialDays, translator)
+	default:
+		return "", eris.Errorf("unknown calendar mode '%s'", mode)
+	}
+	if err != nil {
+		return "", err
+	}
+	sb := strings.Builder{}
+	err = template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func modeTemplateText(mode string) string {
+	switch mode {
+	case twoMonthsMode:
+		return twoMonthsHtmlTemplateText
+	case yearMode:
+		return yearHtmlTemplateText
+	case rollingMode:
+		return rollingHtmlTemplateText
+	}
+	return currentMonthHtmlTemplateText
+}
+
+// styles shared by the multi-month modes, day cell classes are the same as in the month mode
+var modeStyleText = `
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.title {
+    font-size: 72px;
+    text-align: center;
+    background: #555;
+    color: #eee;
+    font-family: "fira", serif;
+    font-weight: bold;
+}
+
+.monthName {
+    text-align: center;
+    font-family: "envy-code-r", serif;
+    font-weight: bold;
+}
+
+.calendarTable {
+    width: 100%;
+    border: 0;
+    border-spacing: 0;
+    font-family: "bront-ubuntu", serif;
+}
+
+.calendarTable td {
+    text-align: center;
+}
+
+.weekDayHeader {
+    font-family: "bedstead", serif;
+    font-weight: bold;
+}
+
+.weekNum {
+    background: #ddd;
+    font-family: "cartograph", serif;
+}
+
+.weekDayHeaderWeekend, .weekend, .publicHoliday {
+    background: #ccc;
+}
+
+.schoolHoliday {
+    background: #aaa;
+}
+
+.important {
+    color: #fff;
+    background: #666;
+}
+
+.currentDay {
+    color: #fff;
+    background: #222 !important;
+    font-weight: bold;
+}
+
+.calendarLegend {
+    font-size: 40px;
+    font-family: "bront-ubuntu", serif;
+    overflow-wrap: break-word;
+}
+  </style>
+`
+
+var monthTableTemplateText = `{{define "monthTable"}}
+  <table class="calendarTable">
+    <tr>
+      <td class="weekNum">{{t "week"}}</td>
+{{range .DayHeaders}}      <td class="weekDayHeader{{if .Weekend}} weekDayHeaderWeekend{{end}}">{{.Text}}</td>
+{{end}}
+    </tr>
+{{range .Rows}}    <tr>
+      <td class="weekNum">{{.WeekNum}}</td>
+{{range .Days}}      <td class="{{if .CurrentDay}} currentDay{{end}}{{if .Weekend}} weekend{{end}}{{if .PublicHoliday}} publicHoliday{{end}}{{if .SchoolHoliday}} schoolHoliday{{end}}{{if .Important}} important{{end}}">{{if .Visible}}{{.Day}}{{else}}&nbsp;{{end}}</td>
+{{end}}
+    </tr>
+{{end}}
+  </table>
+{{end}}`
+
+var twoMonthsHtmlTemplateText = monthTableTemplateText + `
+<html>
+<head>` + modeStyleText + `
+  <style>
+.month {
+    display: inline-block;
+    vertical-align: top;
+    width: 48%;
+    margin: 0 1%;
+}
+
+.monthName {
+    font-size: 64px;
+}
+
+.calendarTable {
+    font-size: 36px;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div class="title">{{.Title}}</div>
+{{range .Months}}  <div class="month">
+    <div class="monthName">{{.Month}}</div>
+    {{template "monthTable" .}}
+  </div>{{end}}
+  <div class="calendarLegend">{{.Legend}}</div>
+</body>
+</html>
+`
+
+var yearHtmlTemplateText = monthTableTemplateText + `
+<html>
+<head>` + modeStyleText + `
+  <style>
+.month {
+    display: inline-block;
+    vertical-align: top;
+    width: 31%;
+    margin: 4px 1%;
+}
+
+.monthName {
+    font-size: 32px;
+}
+
+.calendarTable {
+    font-size: 20px;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div class="title">{{.Title}}</div>
+{{range .Months}}  <div class="month">
+    <div class="monthName">{{.Month}}</div>
+    {{template "monthTable" .}}
+  </div>{{end}}
+</body>
+</html>
+`
+
+var rollingHtmlTemplateText = monthTableTemplateText + `
+<html>
+<head>` + modeStyleText + `
+  <style>
+.calendarTable {
+    font-size: 56px;
+}
+
+.calendarTable td {
+    height: 120px;
+}
+
+.monthStart {
+    display: block;
+    font-size: 28px;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div class="title">{{.Title}}</div>
+  <table class="calendarTable">
+    <tr>
+      <td class="weekNum">{{t "week"}}</td>
+{{range .DayHeaders}}      <td class="weekDayHeader{{if .Weekend}} weekDayHeaderWeekend{{end}}">{{.Text}}</td>
+{{end}}
+    </tr>
+{{range .Rows}}    <tr>
+      <td class="weekNum">{{.WeekNum}}</td>
+{{range .Days}}      <td class="{{if .CurrentDay}} currentDay{{end}}{{if .Weekend}} weekend{{end}}{{if .PublicHoliday}} publicHoliday{{end}}{{if .SchoolHoliday}} schoolHoliday{{end}}{{if .Important}} important{{end}}">{{if .Month}}<span class="monthStart">{{.Month}}</span>{{end}}{{.Day}}</td>
+{{end}}
+    </tr>
+{{end}}
+  </table>
+  <div class="calendarLegend">{{.Legend}}</div>
+</body>
+</html>
+`
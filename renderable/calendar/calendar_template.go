@@ -3,8 +3,11 @@ package calendar
 import (
 	"errors"
 	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/specialdays"
+	"fkirill.org/eink-meteo-station/i18n"
 	"fkirill.org/eink-meteo-station/renderable/utils"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
@@ -20,6 +23,7 @@ type calendarDataDay struct {
 	Weekend       bool
 	Important     bool
 	DayLegend     string
+	Month         string // short month name on the first day of a month, rolling mode only
 }
 
 type calendarDataRow struct {
@@ -42,62 +46,57 @@ type calendarData struct {
 	RootPath   string
 }
 
-var weekdayNames = []dayHeader{
-	{"Mon", false},
-	{"Tue", false},
-	{"Wed", false},
-	{"Thu", false},
-	{"Fri", false},
-	{"Sat", true},
-	{"Sun", true},
+func dayHeaders(translator i18n.Translator) []dayHeader {
+	res := make([]dayHeader, 7)
+	for i := range res {
+		weekday := (translator.FirstDayOfWeek() + time.Weekday(i)) % 7
+		res[i] = dayHeader{
+			Text:    translator.ShortWeekdayName(weekday),
+			Weekend: weekday == time.Saturday || weekday == time.Sunday,
+		}
+	}
+	return res
 }
 
-func createCalendarData(year int, month time.Month, currentDay int, specialDays []*config.SpecialDayOrInterval) (*calendarData, error) {
+func createCalendarData(
+	year int,
+	month time.Month,
+	currentDay int,
+	specialDays []*config.SpecialDayOrInterval,
+	translator i18n.Translator,
+) (*calendarData, error) {
 	if year < 1900 || year > 2100 {
 		return nil, errors.New("wrong Year")
 	}
 	if month < time.January || month > time.December {
 		return nil, errors.New("wrong Month")
 	}
-	if currentDay < 1 || currentDay > 31 {
+	// 0 is used for months other than the current one
+	if currentDay < 0 || currentDay > 31 {
 		return nil, errors.New("wrong CurrentDay")
 	}
 	// first day of Month
 	date := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-	dailySpecialDays := dailySpecialDays(year, month, specialDays)
-	importantDays := importantDays(dailySpecialDays)
-	schoolHolidays := schoolHolidays(dailySpecialDays)
-	publicHolidays := publicHolidays(dailySpecialDays)
+	days := monthDays(year, month, currentDay, specialDays)
+	firstDayOfWeek := translator.FirstDayOfWeek()
 	first := true
 	calendarRow := 0
-	_, weekNumber := date.ISOWeek()
 	currentRow := calendarDataRow{
-		WeekNum: weekNumber,
+		WeekNum: weekNumber(date, firstDayOfWeek),
 		Days:    make([]calendarDataDay, 7),
 	}
 	rows := []calendarDataRow{currentRow}
 	for {
-		if date.Weekday() == time.Monday && !first {
+		if date.Weekday() == firstDayOfWeek && !first {
 			calendarRow++
-			_, weekNumber := date.ISOWeek()
 			currentRow = calendarDataRow{
-				WeekNum: weekNumber,
+				WeekNum: weekNumber(date, firstDayOfWeek),
 				Days:    make([]calendarDataDay, 7),
 			}
 			rows = append(rows, currentRow)
 		}
 		first = false
-		day := date.Day()
-		weekDay := date.Weekday()
-		currentRow.Days[startsFromMonday(weekDay)] = calendarDataDay{
-			Day:           day,
-			Visible:       true,
-			CurrentDay:    day == currentDay,
-			PublicHoliday: publicHolidays[day],
-			SchoolHoliday: schoolHolidays[day],
-			Important:     importantDays[day],
-			Weekend:       weekDay == time.Saturday || weekDay == time.Sunday,
-		}
+		currentRow.Days[weekdayColumn(date.Weekday(), firstDayOfWeek)] = days[date.Day()]
 		date = date.AddDate(0, 0, 1)
 		if date.Month() != month {
 			break
@@ -110,36 +109,54 @@ func createCalendarData(year int, month time.Month, currentDay int, specialDays
 	}
 	currentDayStr += strconv.Itoa(currentDay)
 
-	legend := calendarLegend(year, month, specialDays)
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	legend := calendarLegend(monthStart, monthStart.AddDate(0, 1, -1), specialDays, translator)
 	return &calendarData{
-		Month:      month.String(),
+		Month:      translator.MonthName(month),
 		Year:       year,
 		Rows:       rows,
 		CurrentDay: currentDayStr,
-		DayHeaders: weekdayNames,
+		DayHeaders: dayHeaders(translator),
 		Legend:     legend,
 		RootPath:   utils.GetRootDir(),
 	}, nil
 }
 
-func startsFromMonday(weekday time.Weekday) int {
-	switch weekday {
-	case time.Monday:
-		return 0
-	case time.Tuesday:
-		return 1
-	case time.Wednesday:
-		return 2
-	case time.Thursday:
-		return 3
-	case time.Friday:
-		return 4
-	case time.Saturday:
-		return 5
-	case time.Sunday:
-		return 6
+// monthDays returns cells for all days of the month indexed by the day number, the 0-th element is not used
+func monthDays(year int, month time.Month, currentDay int, specialDays []*config.SpecialDayOrInterval) []calendarDataDay {
+	dailySpecialDays := dailySpecialDays(year, month, specialDays)
+	importantDays := importantDays(dailySpecialDays)
+	schoolHolidays := schoolHolidays(dailySpecialDays)
+	publicHolidays := publicHolidays(dailySpecialDays)
+	res := make([]calendarDataDay, 32)
+	for date := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC); date.Month() == month; date = date.AddDate(0, 0, 1) {
+		day := date.Day()
+		weekDay := date.Weekday()
+		res[day] = calendarDataDay{
+			Day:           day,
+			Visible:       true,
+			CurrentDay:    day == currentDay,
+			PublicHoliday: publicHolidays[day],
+			SchoolHoliday: schoolHolidays[day],
+			Important:     importantDays[day],
+			Weekend:       weekDay == time.Saturday || weekDay == time.Sunday,
+		}
 	}
-	panic("wrong day of week")
+	return res
+}
+
+// weekdayColumn returns the column of the day in a calendar row starting at firstDayOfWeek
+func weekdayColumn(weekday time.Weekday, firstDayOfWeek time.Weekday) int {
+	return int((weekday - firstDayOfWeek + 7) % 7)
+}
+
+// weekNumber returns the ISO week number for a calendar row, Sunday-first rows are numbered after their Monday
+func weekNumber(rowStart time.Time, firstDayOfWeek time.Weekday) int {
+	if firstDayOfWeek == time.Sunday && rowStart.Weekday() == time.Sunday {
+		rowStart = rowStart.AddDate(0, 0, 1)
+	}
+	_, res := rowStart.ISOWeek()
+	return res
 }
 
 var currentMonthContentTemplateText = `
@@ -149,7 +166,7 @@ var currentMonthContentTemplateText = `
   <table class="calendarTable">
     <thead>
       <tr>
-        <td class="weekNumHeader">wk</td>
+        <td class="weekNumHeader">{{t "week"}}</td>
 {{range .DayHeaders}}<td class="weekDayHeader{{if .Weekend}} weekDayHeaderWeekend{{end}}">{{.Text}}</td>
 {{end}}
       </tr>
@@ -270,8 +287,15 @@ var currentMonthHtmlTemplateText = `
 </html>
 `
 
-func renderCurrentMonth(year int, month time.Month, currentDay int, specialDays []*config.SpecialDayOrInterval, template *template.Template) (string, error) {
-	data, err := createCalendarData(year, month, currentDay, specialDays)
+func renderCurrentMonth(
+	year int,
+	month time.Month,
+	currentDay int,
+	specialDays []*config.SpecialDayOrInterval,
+	translator i18n.Translator,
+	template *template.Template,
+) (string, error) {
+	data, err := createCalendarData(year, month, currentDay, specialDays, translator)
 	if err != nil {
 		return "", err
 	}
@@ -287,27 +311,11 @@ func dailySpecialDays(year int, month time.Month, specialDays []*config.SpecialD
 	// covers days for all months (31 day max), we don't use 0-th element, so all normal month day numbers apply
 	res := make([]*config.SpecialDayOrInterval, 32, 32)
 	day := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	occurrences := specialdays.Resolve(specialDays, day, day.AddDate(0, 1, -1))
 	for {
-		var sdp *config.SpecialDayOrInterval = nil
-		for _, sd := range specialDays {
-			if sd.Type == "once_off" && sd.StartDateYear == year && sd.StartDateMonth == int(month) && sd.StartDateDay == day.Day() {
-				sdp = sd
-				break
-			}
-			if sd.Type == "annual" && sd.StartDateMonth == int(month) && sd.StartDateDay == day.Day() {
-				sdp = sd
-				break
-			}
-			if sd.Type == "interval" {
-				startDate := time.Date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay, 0, 0, 0, 0, time.UTC)
-				endDate := time.Date(sd.EndDateYear, time.Month(sd.EndDateMonth), sd.EndDateDay, 0, 0, 0, 0, time.UTC)
-				if startDate == day || endDate == day || (startDate.Before(day) && endDate.After(day)) {
-					sdp = sd
-					break
-				}
-			}
+		if occurrence := specialdays.FirstCovering(occurrences, day); occurrence != nil {
+			res[day.Day()] = occurrence.SpecialDay
 		}
-		res[day.Day()] = sdp
 		day = day.AddDate(0, 0, 1)
 		if day.Month() != month {
 			break
@@ -340,65 +348,34 @@ func publicHolidays(dailySpecialDays []*config.SpecialDayOrInterval) []bool {
 	return res
 }
 
-func calendarLegend(year int, month time.Month, specialDays []*config.SpecialDayOrInterval) string {
+// calendarLegend describes special days of the [from, to] date range, month names are only added to dates
+// outside the month of the range start or if the range spans several months
+func calendarLegend(from, to time.Time, specialDays []*config.SpecialDayOrInterval, translator i18n.Translator) string {
 	res := ""
-	day := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-	processedIntervals := []int{}
-	for {
-		var sdp *config.SpecialDayOrInterval = nil
-		for _, sd := range specialDays {
-			if sd.Type == "once_off" && sd.StartDateYear == year && sd.StartDateMonth == int(month) && sd.StartDateDay == day.Day() {
-				sdp = sd
-				break
-			}
-			if sd.Type == "annual" && sd.StartDateMonth == int(month) && sd.StartDateDay == day.Day() {
-				sdp = sd
-				break
-			}
-			if sd.Type == "interval" {
-				startDate := time.Date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay, 0, 0, 0, 0, time.UTC)
-				endDate := time.Date(sd.StartDateYear, time.Month(sd.StartDateMonth), sd.StartDateDay, 0, 0, 0, 0, time.UTC)
-				if startDate == day || endDate == day || (startDate.Before(day) && endDate.After(day)) {
-					alreadyProcessed := false
-					for _, intervalIndex := range processedIntervals {
-						if sd.Index == intervalIndex {
-							alreadyProcessed = true
-							break
-						}
-					}
-					if !alreadyProcessed {
-						sdp = sd
-						processedIntervals = append(processedIntervals, sd.Index)
-					}
-					break
-				}
-			}
+	month := from.Month()
+	withMonth := from.Year() != to.Year() || from.Month() != to.Month()
+	formatDate := func(date time.Time) string {
+		if withMonth || date.Month() != month {
+			return fmt.Sprintf("%d&nbsp;%s", date.Day(), translator.ShortMonthName(date.Month()))
 		}
-		if sdp != nil {
-			if res != "" {
-				res += "; "
-			}
-			if sdp.Type != "interval" {
-				res += fmt.Sprintf("%d&nbsp;%s", day.Day(), sdp.DisplayText)
-			} else {
-				startDateStr := ""
-				if sdp.StartDateMonth != int(month) {
-					startDateStr = fmt.Sprintf("%d&nbsp;%s", sdp.StartDateDay, time.Month(sdp.StartDateMonth).String()[0:2])
-				} else {
-					startDateStr = strconv.Itoa(sdp.StartDateDay)
-				}
-				endDateStr := ""
-				if sdp.EndDateMonth != int(month) {
-					endDateStr = fmt.Sprintf("%d&nbsp;%s", sdp.EndDateDay, time.Month(sdp.EndDateMonth).String()[0:2])
-				} else {
-					endDateStr = strconv.Itoa(sdp.EndDateDay)
-				}
-				res += fmt.Sprintf("%s&nbsp;-&nbsp;%s&nbsp;%s", startDateStr, endDateStr, sdp.DisplayText)
-			}
+		return strconv.Itoa(date.Day())
+	}
+	occurrences := specialdays.Resolve(specialDays, from, to)
+	// multi-day occurrences are only mentioned once, on the first day they cover in the range
+	var processed []*specialdays.Occurrence
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		occurrence := specialdays.FirstCovering(occurrences, day)
+		if occurrence == nil || slices.Contains(processed, occurrence) {
+			continue
 		}
-		day = day.AddDate(0, 0, 1)
-		if day.Month() != month {
-			break
+		processed = append(processed, occurrence)
+		if res != "" {
+			res += "; "
+		}
+		if !occurrence.IsMultiDay() {
+			res += fmt.Sprintf("%s&nbsp;%s", formatDate(day), occurrence.SpecialDay.DisplayText)
+		} else {
+			res += fmt.Sprintf("%s&nbsp;-&nbsp;%s&nbsp;%s", formatDate(occurrence.Start), formatDate(occurrence.End), occurrence.SpecialDay.DisplayText)
 		}
 	}
 	return res
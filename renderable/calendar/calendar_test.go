@@ -0,0 +1,55 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedTime struct {
+	now time.Time
+}
+
+func (f *fixedTime) LocalNow() time.Time {
+	return f.now
+}
+
+func (f *fixedTime) UtcNow() time.Time {
+	return f.now.UTC()
+}
+
+func TestRedrawAtLocalMidnight(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "morning in Sydney, the UTC date is still the day before",
+			now:      time.Date(2024, 6, 10, 8, 0, 0, 0, sydney),
+			expected: time.Date(2024, 6, 11, 0, 0, 0, 0, sydney),
+		},
+		{
+			name:     "evening in Sydney",
+			now:      time.Date(2024, 6, 10, 22, 0, 0, 0, sydney),
+			expected: time.Date(2024, 6, 11, 0, 0, 0, 0, sydney),
+		},
+		{
+			name:     "start of the daylight saving time",
+			now:      time.Date(2024, 10, 5, 12, 0, 0, 0, sydney),
+			expected: time.Date(2024, 10, 6, 0, 0, 0, 0, sydney),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &calendarRenderable{timeProvider: &fixedTime{now: test.now}}
+			r.RedrawFinished()
+			if !r.nextRedrawTime.Equal(test.expected) {
+				t.Errorf("got %v, expected %v", r.nextRedrawTime.In(sydney), test.expected)
+			}
+		})
+	}
+}
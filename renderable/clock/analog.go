@@ -0,0 +1,70 @@
+package clock
+
+import (
+	"image"
+	"math"
+)
+
+// segment is a line with round caps, the hands and the hour ticks of the analog clock are made of them
+type segment struct {
+	x1, y1, x2, y2 float64
+	halfWidth      float64
+}
+
+// coverage is the share of the pixel centered at (x, y) covered by the segment, 1 inside and 0 outside
+// with a linear ramp of one pixel across the edge to anti-alias it
+func (s *segment) coverage(x, y float64) float64 {
+	dx, dy := s.x2-s.x1, s.y2-s.y1
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t = math.Max(0, math.Min(1, ((x-s.x1)*dx+(y-s.y1)*dy)/lengthSquared))
+	}
+	distance := math.Hypot(x-s.x1-t*dx, y-s.y1-t*dy)
+	return edgeCoverage(distance - s.halfWidth)
+}
+
+// edgeCoverage turns the signed distance to the edge of a shape into the coverage, negative distances are inside
+func edgeCoverage(distance float64) float64 {
+	return math.Max(0, math.Min(1, 0.5-distance))
+}
+
+// drawAnalogFace draws the dial with the hour ticks and the hour and minute hands into the square centered in the area,
+// the rest of the area is cleared
+func drawAnalogFace(raster []byte, rasterSize image.Point, area image.Rectangle, hour, minute int) {
+	radius := float64(min(area.Dx(), area.Dy()))/2 - 2
+	cx := float64(area.Min.X) + float64(area.Dx())/2
+	cy := float64(area.Min.Y) + float64(area.Dy())/2
+	ringHalfWidth := radius * 0.025
+	shapes := make([]*segment, 0, 14)
+	// angles are clockwise from 12 o'clock
+	radial := func(angle, from, to, halfWidth float64) *segment {
+		sin, cos := math.Sincos(angle)
+		return &segment{cx + from*sin, cy - from*cos, cx + to*sin, cy - to*cos, halfWidth}
+	}
+	for i := 0; i < 12; i++ {
+		halfWidth := radius * 0.015
+		if i%3 == 0 {
+			halfWidth = radius * 0.035
+		}
+		shapes = append(shapes, radial(float64(i)*math.Pi/6, radius*0.8, radius*0.9, halfWidth))
+	}
+	minuteAngle := float64(minute) * math.Pi / 30
+	hourAngle := (float64(hour%12) + float64(minute)/60) * math.Pi / 6
+	shapes = append(shapes, radial(hourAngle, -radius*0.1, radius*0.5, radius*0.05))
+	shapes = append(shapes, radial(minuteAngle, -radius*0.1, radius*0.78, radius*0.03))
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			covered := edgeCoverage(math.Abs(math.Hypot(px-cx, py-cy)-radius+ringHalfWidth) - ringHalfWidth)
+			for _, s := range shapes {
+				if covered == 1 {
+					break
+				}
+				covered = math.Max(covered, s.coverage(px, py))
+			}
+			// 16 shades of gray, 0x00 is black and 0xff is white
+			shade := byte(math.Round((1 - covered) * 15))
+			raster[y*rasterSize.X+x] = shade<<4 | shade
+		}
+	}
+}
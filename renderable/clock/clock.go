@@ -2,97 +2,153 @@ package clock
 
 import (
 	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
 	"fkirill.org/eink-meteo-station/renderable"
 	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
 	"github.com/rotisserie/eris"
 	"image"
-	"path"
 	"strconv"
+	"strings"
+	"text/template"
 	"time"
 )
 
+// clock modes, selected with the mode of the clock widget in the layout config
+const (
+	secondsMode = "seconds" // HH:MM:SS redrawn every second, the default
+	minutesMode = "minutes" // HH:MM redrawn once a minute
+	analogMode  = "analog"  // dial with hands drawn in Go, redrawn once a minute
+)
+
 type ClockRenderable interface {
 	renderable.Renderable
 }
 
-func NewClockRenderable(rect image.Rectangle, provider utils.TimeProvider) (ClockRenderable, error) {
-	rasterSize := rect.Dx() * rect.Dy()
+func NewClockRenderable(
+	rect image.Rectangle,
+	provider utils.TimeProvider,
+	units units.Units,
+	cfg config.ConfigApi,
+	translator i18n.Translator,
+	mode string,
+) (ClockRenderable, error) {
+	if mode == "" {
+		mode = secondsMode
+	}
+	if mode != secondsMode && mode != minutesMode && mode != analogMode {
+		return nil, eris.Errorf("unknown clock mode '%s', expected one of %s, %s or %s", mode, secondsMode, minutesMode, analogMode)
+	}
+	dateHtmlTemplate, err := template.New("clockDateHtml").Parse(dateHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(err, true))
+	}
+	size := rect.Size()
+	rasterSize := size.X * size.Y
 	raster := make([]byte, rasterSize, rasterSize)
+	for i := range raster {
+		raster[i] = 0xff
+	}
 	res := &clockRenderable{
-		offset:         rect.Min,
-		size:           rect.Size(),
-		raster:         raster,
-		nextRedrawTime: provider.UtcNow(),
+		mode:             mode,
+		font:             cfg.GetClockFont(),
+		offset:           rect.Min,
+		size:             size,
+		raster:           raster,
+		nextRedrawTime:   provider.UtcNow(),
+		timeArea:         image.Rectangle{Max: size},
+		dateHtmlTemplate: dateHtmlTemplate,
 		// unrealistic values, will be reset upon first render
 		hour:         70,
 		minute:       70,
 		second:       70,
+		meridiem:     -1,
 		timeProvider: provider,
+		units:        units,
+		translator:   translator,
 	}
-	err := res.loadNumbersAndColon()
+	// a disabled widget is never drawn
+	if rect.Empty() {
+		return res, nil
+	}
+	if cfg.GetClockShowDate() {
+		dateHeight := size.Y / 5
+		res.timeArea.Max.Y -= dateHeight
+		res.dateArea = image.Rectangle{Min: image.Point{Y: res.timeArea.Max.Y}, Max: size}
+	}
+	err = res.prepareDigits()
 	if err != nil {
-		return nil, eris.Wrapf(err, "Error loading images")
+		return nil, eris.Wrapf(err, "Error preparing clock digits")
 	}
-	res.drawColons()
 	return res, nil
 }
 
 type clockRenderable struct {
-	// clockWidgetSize: 963x237
-	digitImages [][]byte
-
-	// digitsSize: 241x237
-	digitsImageSize []image.Point
-
-	// colonSize: 120x237
-	colonSize            image.Point
-	colonImage           []byte
+	mode   string
+	font   string
+	glyphs *glyphs // nil in the analog mode
+	// x coordinates of the numbers in the digital modes and of AM or PM with the 12-hour clock
+	hourX, minuteX, secondX, meridiemX int
+	// parts of the widget, the date area is empty unless the date is shown
+	timeArea             image.Rectangle
+	dateArea             image.Rectangle
+	dateHtmlTemplate     *template.Template
+	date                 time.Time // date drawn in the date area
 	offset               image.Point
 	size                 image.Point
 	raster               []byte
 	nextRedrawTime       time.Time
 	hour, minute, second int
+	meridiem             int // 0 for AM, 1 for PM, -1 before the first render
 	timeProvider         utils.TimeProvider
+	units                units.Units
+	translator           i18n.Translator
 }
 
-func (c *clockRenderable) loadNumbersAndColon() error {
-	if c.digitImages != nil {
+// prepareDigits rasterises the glyphs sized to fill the width of the widget, 6 digits and 2 colons of half
+// the digit width with seconds or 4 digits and a colon without them, and draws the colons.
+// The 12-hour clock takes one more digit width for AM or PM after the numbers.
+func (c *clockRenderable) prepareDigits() error {
+	meridiem := c.units.Is12HourClock()
+	// the width of the widget in halves of the digit width
+	var halves int
+	switch c.mode {
+	case secondsMode:
+		halves = 14
+	case minutesMode:
+		halves = 9
+	default:
 		return nil
 	}
-	c.digitImages = make([][]byte, 60, 60)
-	c.digitsImageSize = make([]image.Point, 60, 60)
-	for i := 0; i < 60; i++ {
-		imageNamePart := strconv.Itoa(i)
-		if len(imageNamePart) == 1 {
-			imageNamePart = "0" + imageNamePart
-		}
-		img, err := utils.LoadImage(path.Join(utils.GetRootDir(), "numbers/num_"+imageNamePart+".png"))
-		if err != nil {
-			return err
-		}
-		c.digitsImageSize[i] = img.Bounds().Max
-		c.digitImages[i], err = utils.ConvertToGrayScale(img)
-		if err != nil {
-			return err
-		}
+	if meridiem {
+		halves += 2
 	}
-	colonImg, err := utils.LoadImage(path.Join(utils.GetRootDir(), "numbers/colon.png"))
+	digitWidth := c.size.X * 2 / halves
+	var err error
+	c.glyphs, err = newGlyphs(c.font, digitWidth, c.timeArea.Dy(), meridiem)
 	if err != nil {
 		return err
 	}
-	c.colonSize = colonImg.Bounds().Size()
-	c.colonImage, err = utils.ConvertToGrayScale(colonImg)
-	if err != nil {
-		return err
+	colonWidth := c.glyphs.colonSize.X
+	width := 4*digitWidth + colonWidth
+	if c.mode == secondsMode {
+		width += 2*digitWidth + colonWidth
+	}
+	if meridiem {
+		width += digitWidth
+	}
+	c.hourX = (c.size.X - width) / 2
+	c.minuteX = c.glyphs.drawColon(c.raster, c.size, image.Point{X: c.hourX + 2*digitWidth})
+	c.meridiemX = c.minuteX + 2*digitWidth
+	if c.mode == secondsMode {
+		c.secondX = c.glyphs.drawColon(c.raster, c.size, image.Point{X: c.minuteX + 2*digitWidth})
+		c.meridiemX = c.secondX + 2*digitWidth
 	}
 	return nil
 }
 
-func (c *clockRenderable) drawColons() {
-	utils.DrawImage(c.raster, c.size, image.Point{X: c.digitsImageSize[0].X}, c.colonImage, c.colonSize)
-	utils.DrawImage(c.raster, c.size, image.Point{X: c.digitsImageSize[0].X*2 + c.colonSize.X}, c.colonImage, c.colonSize)
-}
-
 func (c *clockRenderable) RedrawNow() {
 	c.nextRedrawTime = c.timeProvider.UtcNow()
 }
@@ -101,7 +157,12 @@ func (_ *clockRenderable) String() string {
 	return "clock"
 }
 
+// DisplayMode is the fast black and white update for the digits and the non-flashing grayscale one
+// for the anti-aliased dial
 func (c *clockRenderable) DisplayMode() uint8 {
+	if c.mode == analogMode {
+		return clib.GL16_Mode
+	}
 	return clib.A2_Mode
 }
 
@@ -126,30 +187,100 @@ func (c *clockRenderable) NextRedrawDateTimeUtc() time.Time {
 }
 
 func (c *clockRenderable) RedrawFinished() {
-	now := c.timeProvider.UtcNow().Truncate(time.Second)
-	c.nextRedrawTime = now.Add(time.Second)
+	now := c.timeProvider.UtcNow()
+	if c.mode == secondsMode {
+		c.nextRedrawTime = now.Truncate(time.Second).Add(time.Second)
+		return
+	}
+	c.nextRedrawTime = now.Truncate(time.Minute).Add(time.Minute)
 }
 
 func (c *clockRenderable) Render() error {
 	now := c.timeProvider.LocalNow()
-	nextHour := now.Hour()
+	if !c.dateArea.Empty() && (now.YearDay() != c.date.YearDay() || now.Year() != c.date.Year()) {
+		err := c.drawDate(now)
+		if err != nil {
+			return err
+		}
+	}
+	nextHour := c.units.Hour(now)
 	nextMinute := now.Minute()
 	nextSecond := now.Second()
-	if c.hour != nextHour {
-		c.drawNumber(0, nextHour)
-	}
-	if c.minute != nextMinute {
-		c.drawNumber(c.digitsImageSize[0].X+c.colonSize.X, nextMinute)
-	}
-	if c.second != nextSecond {
-		c.drawNumber(c.digitsImageSize[0].X*2+c.colonSize.X*2, nextSecond)
+	nextMeridiem := now.Hour() / 12
+	switch c.mode {
+	case analogMode:
+		if c.hour != nextHour || c.minute != nextMinute {
+			drawAnalogFace(c.raster, c.size, c.timeArea, nextHour, nextMinute)
+		}
+	default:
+		if c.hour != nextHour {
+			c.glyphs.drawNumber(c.raster, c.size, image.Point{X: c.hourX}, nextHour)
+		}
+		if c.minute != nextMinute {
+			c.glyphs.drawNumber(c.raster, c.size, image.Point{X: c.minuteX}, nextMinute)
+		}
+		if c.mode == secondsMode && c.second != nextSecond {
+			c.glyphs.drawNumber(c.raster, c.size, image.Point{X: c.secondX}, nextSecond)
+		}
+		if c.glyphs.meridiems != nil && c.meridiem != nextMeridiem {
+			c.glyphs.drawMeridiem(c.raster, c.size, image.Point{X: c.meridiemX}, nextMeridiem == 1)
+		}
 	}
 	c.hour = nextHour
 	c.minute = nextMinute
 	c.second = nextSecond
+	c.meridiem = nextMeridiem
+	return nil
+}
+
+type dateData struct {
+	Text     string
+	FontUrl  string
+	Width    int
+	Height   int
+	FontSize int
+}
+
+// drawDate renders the weekday and the date line with the clock font, it changes once a day
+func (c *clockRenderable) drawDate(now time.Time) error {
+	url, err := fontUrl(c.font)
+	if err != nil {
+		return err
+	}
+	size := c.dateArea.Size()
+	data := &dateData{
+		Text:     c.translator.WeekdayName(now.Weekday()) + " " + strconv.Itoa(now.Day()) + " " + c.translator.MonthName(now.Month()),
+		FontUrl:  url,
+		Width:    size.X,
+		Height:   size.Y,
+		FontSize: size.Y * 3 / 4,
+	}
+	sb := strings.Builder{}
+	err = c.dateHtmlTemplate.Execute(&sb, data)
+	if err != nil {
+		return err
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("clock", sb.String(), "clock_date_"+strconv.FormatInt(now.UnixNano(), 16), size)
+	if err != nil {
+		return err
+	}
+	utils.DrawImage(c.raster, c.size, c.dateArea.Min, raster, size)
+	c.date = now
 	return nil
 }
 
-func (c *clockRenderable) drawNumber(xOffset int, number int) {
-	utils.DrawImage(c.raster, c.Size(), image.Point{X: xOffset, Y: 0}, c.digitImages[number], c.digitsImageSize[number])
+var dateHtmlTemplateText = `
+<html>
+<head>
+  <style>
+@font-face {
+  font-family: 'clock';
+  src: url('{{ .FontUrl }}');
 }
+  </style>
+</head>
+<body style="margin: 0">
+  <div style="width: {{ .Width }}px; height: {{ .Height }}px; line-height: {{ .Height }}px; font-size: {{ .FontSize }}px; font-family: 'clock', serif; text-align: center; white-space: nowrap; overflow: hidden">{{ .Text }}</div>
+</body>
+</html>
+`
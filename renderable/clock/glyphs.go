@@ -0,0 +1,149 @@
+package clock
+
+import (
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// meridiem indicators of the 12-hour clock, in the order of the glyphs
+var meridiems = []string{"AM", "PM"}
+
+// glyphs holds the digits and the colon drawn with the clock font, they are rasterised once at startup
+// so that the clock only copies pixels every second
+type glyphs struct {
+	digits     [10][]byte
+	digitSize  image.Point
+	colon      []byte
+	colonSize  image.Point
+	digitWidth int
+	// AM and PM in a cell of the digit width, only for the 12-hour clock
+	meridiems [][]byte
+}
+
+// fontPath resolves the configured font against the root dir and makes sure it exists
+func fontPath(font string) (string, error) {
+	if !filepath.IsAbs(font) {
+		font = filepath.Join(utils.GetRootDir(), font)
+	}
+	if _, err := os.Stat(font); err != nil {
+		return "", eris.Wrapf(err, "clock font %s is not available", font)
+	}
+	return font, nil
+}
+
+// fontUrl is the font for the pages rendered in the browser
+func fontUrl(font string) (string, error) {
+	fileName, err := fontPath(font)
+	if err != nil {
+		return "", err
+	}
+	return "file://" + fileName, nil
+}
+
+// newGlyphs rasterises the digits of the given cell width and the colon of half of it, the font size is picked
+// to fill the cell height while keeping the digits of a typical monospace font within the cell width.
+// With the meridiem AM and PM are rasterised into the cells of the digit width as well, at a third of the size.
+func newGlyphs(fontFile string, digitWidth, height int, meridiem bool) (*glyphs, error) {
+	fileName, err := fontPath(fontFile)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error reading clock font %s", fileName)
+	}
+	parsed, err := opentype.Parse(content)
+	if err != nil {
+		return nil, eris.Wrapf(err, "error parsing clock font %s, TrueType or OpenType is expected", fileName)
+	}
+	fontSize := min(height, digitWidth*5/3)
+	res := &glyphs{
+		digitSize:  image.Point{X: digitWidth, Y: height},
+		colonSize:  image.Point{X: digitWidth / 2, Y: height},
+		digitWidth: digitWidth,
+	}
+	face, err := newFace(parsed, fontSize)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFace(face)
+	for i := range res.digits {
+		res.digits[i] = rasterise(face, strconv.Itoa(i), res.digitSize)
+	}
+	res.colon = rasterise(face, ":", res.colonSize)
+	if meridiem {
+		small, err := newFace(parsed, fontSize/3)
+		if err != nil {
+			return nil, err
+		}
+		defer closeFace(small)
+		for _, text := range meridiems {
+			res.meridiems = append(res.meridiems, rasterise(small, text, res.digitSize))
+		}
+	}
+	return res, nil
+}
+
+// newFace takes the size in pixels, without hinting the digits keep the shapes of the font outlines
+func newFace(parsed *opentype.Font, size int) (font.Face, error) {
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, eris.Wrapf(err, "error creating clock font face of size %d", size)
+	}
+	return face, nil
+}
+
+func closeFace(face font.Face) {
+	err := face.Close()
+	if err != nil {
+		println(eris.ToString(eris.Wrap(err, "Error closing clock font face"), true))
+	}
+}
+
+// rasterise draws the text black on white in the middle of the cell, vertically centred like a line of the cell height
+func rasterise(face font.Face, text string, size image.Point) []byte {
+	img := image.NewGray(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	metrics := face.Metrics()
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.Black,
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: (fixed.I(size.X) - font.MeasureString(face, text)) / 2,
+			Y: (fixed.I(size.Y) + metrics.Ascent - metrics.Descent) / 2,
+		},
+	}
+	drawer.DrawString(text)
+	return utils.ConvertToGrayScale(img, utils.DitherNone)
+}
+
+// drawNumber draws a two digit number and returns the x coordinate after it
+func (g *glyphs) drawNumber(raster []byte, rasterSize image.Point, at image.Point, number int) int {
+	utils.DrawImage(raster, rasterSize, at, g.digits[number/10], g.digitSize)
+	utils.DrawImage(raster, rasterSize, image.Point{X: at.X + g.digitWidth, Y: at.Y}, g.digits[number%10], g.digitSize)
+	return at.X + 2*g.digitWidth
+}
+
+// drawColon draws the colon and returns the x coordinate after it
+func (g *glyphs) drawColon(raster []byte, rasterSize image.Point, at image.Point) int {
+	utils.DrawImage(raster, rasterSize, at, g.colon, g.colonSize)
+	return at.X + g.colonSize.X
+}
+
+// drawMeridiem draws AM or PM of the 12-hour clock
+func (g *glyphs) drawMeridiem(raster []byte, rasterSize image.Point, at image.Point, pm bool) {
+	i := 0
+	if pm {
+		i = 1
+	}
+	utils.DrawImage(raster, rasterSize, at, g.meridiems[i], g.digitSize)
+}
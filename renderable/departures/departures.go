@@ -0,0 +1,137 @@
+package departures
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/transit"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// DeparturesRenderable shows the next departures from the configured stops, with the realtime delays if there is a feed
+type DeparturesRenderable interface {
+	renderable.Renderable
+}
+
+func NewDeparturesRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	transitProvider transit.TransitDataProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (DeparturesRenderable, error) {
+	if !rect.Empty() {
+		settings := cfg.GetTransit()
+		if settings.Gtfs == "" || len(settings.Stops) == 0 {
+			return nil, eris.New("departures widget needs the GTFS feed and the stops")
+		}
+	}
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	departuresHtmlTemplate, err := template.New("departuresHtml").Parse(departuresHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing departures template"), true))
+	}
+	return &departuresRenderable{
+		offset:                 rect.Min,
+		size:                   rect.Size(),
+		nextRedrawTime:         timeProvider.UtcNow(),
+		raster:                 raster,
+		timeProvider:           timeProvider,
+		transitProvider:        transitProvider,
+		units:                  units,
+		translator:             translator,
+		departuresHtmlTemplate: departuresHtmlTemplate,
+	}, nil
+}
+
+type departuresRenderable struct {
+	offset                 image.Point
+	size                   image.Point
+	nextRedrawTime         time.Time
+	raster                 []byte
+	timeProvider           utils.TimeProvider
+	transitProvider        transit.TransitDataProvider
+	units                  units.Units
+	translator             i18n.Translator
+	departuresHtmlTemplate *template.Template
+	cached                 []*transit.StopDepartures // nil until the first successful load
+	lastHtml               string
+}
+
+func (r *departuresRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+	r.lastHtml = ""
+}
+
+func (_ *departuresRenderable) String() string {
+	return "departures"
+}
+
+// DisplayMode uses the fast black and white update, the widget changes every minute
+func (r *departuresRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *departuresRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *departuresRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *departuresRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *departuresRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+// RedrawFinished schedules the next redraw at the start of the next minute
+func (r *departuresRenderable) RedrawFinished() {
+	r.nextRedrawTime = r.timeProvider.UtcNow().Truncate(time.Minute).Add(time.Minute)
+}
+
+func (r *departuresRenderable) Raster() []byte {
+	return r.raster
+}
+
+// Render keeps showing the last departures with the warning sign if the timetable can't be loaded
+func (r *departuresRenderable) Render() error {
+	stops, err := r.transitProvider.GetDepartures()
+	failed := err != nil
+	if failed {
+		println(eris.ToString(eris.Wrap(err, "Error loading departures"), true))
+	} else {
+		r.cached = stops
+	}
+	data := createDeparturesData(r.cached, failed, r.timeProvider.LocalNow(), r.units, r.translator)
+	html, err := renderDepartures(data, r.departuresHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	// the screenshot is skipped if nothing has changed
+	if html == r.lastHtml {
+		return nil
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("departures", html, "departures_"+strconv.FormatInt(time.Now().Unix(), 10), r.size)
+	if err != nil {
+		return err
+	}
+	r.raster = raster
+	r.lastHtml = html
+	return nil
+}
@@ -0,0 +1,183 @@
+package departures
+
+import (
+	"fkirill.org/eink-meteo-station/data/transit"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/images"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type departureRow struct {
+	Route    string
+	Headsign string
+	Due      string // minutes until the departure within the hour, the time later
+	Delay    string // +N or -N minutes of the realtime delay, empty if on time or unknown
+	Realtime bool
+}
+
+type stopBlock struct {
+	Label string
+	Rows  []*departureRow
+	Empty string // shown if there are no departures
+}
+
+type departuresData struct {
+	Title      string
+	Warning    bool // the departures couldn't be loaded, the last ones are shown
+	Stops      []*stopBlock
+	WarningPng string
+	RootPath   string
+}
+
+func formatDue(departure time.Time, now time.Time, units units.Units, translator i18n.Translator) string {
+	minutes := int(departure.Sub(now) / time.Minute)
+	switch {
+	case minutes < 1:
+		return translator.Text("now")
+	case minutes < 60:
+		return translator.Count("minutes", minutes)
+	}
+	return units.FormatTime(departure.In(now.Location()))
+}
+
+// createDeparturesData shows the departures of every stop, the due times are counted from now
+// to stay correct if the last departures are shown
+func createDeparturesData(
+	stops []*transit.StopDepartures,
+	failed bool,
+	now time.Time,
+	units units.Units,
+	translator i18n.Translator,
+) *departuresData {
+	res := &departuresData{
+		Title:      translator.Text("departures"),
+		Warning:    failed,
+		Stops:      make([]*stopBlock, 0, len(stops)),
+		WarningPng: images.Warning_png_src,
+		RootPath:   utils.GetRootDir(),
+	}
+	for _, stop := range stops {
+		block := &stopBlock{Label: stop.Label, Rows: make([]*departureRow, 0, len(stop.Departures))}
+		for _, departure := range stop.Departures {
+			if departure.Expected.Before(now) {
+				continue
+			}
+			row := &departureRow{
+				Route:    departure.Route,
+				Headsign: departure.Headsign,
+				Due:      formatDue(departure.Expected, now, units, translator),
+				Realtime: departure.Realtime,
+			}
+			if delay := int(departure.Expected.Sub(departure.Scheduled).Round(time.Minute) / time.Minute); delay > 0 {
+				row.Delay = "+" + strconv.Itoa(delay)
+			} else if delay < 0 {
+				row.Delay = strconv.Itoa(delay)
+			}
+			block.Rows = append(block.Rows, row)
+		}
+		if len(block.Rows) == 0 {
+			block.Empty = translator.Text("no_departures")
+		}
+		res.Stops = append(res.Stops, block)
+	}
+	return res
+}
+
+// the widget is refreshed in the black and white mode, the route names are drawn white on black
+var departuresHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.departuresTable {
+    width: 100%;
+    border: 0;
+    border-spacing: 0 6px;
+}
+
+.departuresTable td {
+    white-space: nowrap;
+    vertical-align: baseline;
+}
+
+.stopLabel {
+    padding-top: 14px;
+    font-size: 32px;
+    font-family: "verily", serif;
+    font-weight: bold;
+}
+
+.route {
+    display: inline-block;
+    min-width: 60px;
+    padding: 2px 10px;
+    border-radius: 10px;
+    background: #000;
+    color: #fff;
+    text-align: center;
+    font-size: 36px;
+    font-family: "cartograph", serif;
+}
+
+.headsign {
+    width: 100%;
+    max-width: 0;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    padding-left: 16px;
+    font-size: 36px;
+    font-family: "bront-ubuntu", serif;
+}
+
+.due {
+    padding-left: 16px;
+    text-align: right;
+    font-size: 40px;
+    font-family: "cartograph", serif;
+}
+
+.delay {
+    padding-left: 8px;
+    font-size: 26px;
+    font-family: "cartograph", serif;
+}
+
+.realtime {
+    font-weight: bold;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div style="padding: 20px 40px">
+    <div>
+      <span style="border-radius: 40px; border: 4px solid; font-size: 48px; padding: 8px 13px; font-family: verily; font-weight: bold">{{.Title}}</span>
+      {{if .Warning}}<img src="{{ .WarningPng }}" width="67" height="67"/>{{end}}
+    </div>
+    <table class="departuresTable">
+{{range .Stops}}      <tr><td class="stopLabel" colspan="4">{{html .Label}}</td></tr>
+{{if .Empty}}      <tr><td class="headsign" colspan="4">{{.Empty}}</td></tr>
+{{end}}{{range .Rows}}      <tr>
+        <td><span class="route">{{html .Route}}</span></td>
+        <td class="headsign">{{html .Headsign}}</td>
+        <td class="due{{if .Realtime}} realtime{{end}}">{{.Due}}</td>
+        <td class="delay">{{.Delay}}</td>
+      </tr>
+{{end}}{{end}}    </table>
+  </div>
+</body>
+</html>
+`
+
+func renderDepartures(data *departuresData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
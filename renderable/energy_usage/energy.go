@@ -0,0 +1,132 @@
+package energy_usage
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/energy"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"math/rand"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// EnergyRenderable shows the solar production, the grid, the consumption and the battery of today with an hourly chart
+type EnergyRenderable interface {
+	renderable.Renderable
+}
+
+func NewEnergyRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	energyProvider energy.EnergyDataProvider,
+	translator i18n.Translator,
+) (EnergyRenderable, error) {
+	if !rect.Empty() && cfg.GetEnergy().SolarSensor == "" {
+		return nil, eris.New("energy widget needs the solar sensor")
+	}
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	energyHtmlTemplate, err := template.New("energyHtml").Parse(energyHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing energy template"), true))
+	}
+	return &energyRenderable{
+		offset:             rect.Min,
+		size:               rect.Size(),
+		nextRedrawTime:     timeProvider.UtcNow(),
+		raster:             raster,
+		timeProvider:       timeProvider,
+		energyProvider:     energyProvider,
+		translator:         translator,
+		energyHtmlTemplate: energyHtmlTemplate,
+	}, nil
+}
+
+type energyRenderable struct {
+	offset             image.Point
+	size               image.Point
+	nextRedrawTime     time.Time
+	raster             []byte
+	timeProvider       utils.TimeProvider
+	energyProvider     energy.EnergyDataProvider
+	translator         i18n.Translator
+	energyHtmlTemplate *template.Template
+	cached             *energy.EnergyData // nil until the first successful load
+	failed             bool
+}
+
+func (r *energyRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+}
+
+func (_ *energyRenderable) String() string {
+	return "energy"
+}
+
+func (r *energyRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *energyRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *energyRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *energyRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *energyRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+func (r *energyRenderable) RedrawFinished() {
+	// refresh at random intervals 200 to 400 seconds (approximately every 5 minutes)
+	r.nextRedrawTime = r.timeProvider.UtcNow().Add(time.Second * time.Duration(rand.Intn(200)+200))
+}
+
+func (r *energyRenderable) Raster() []byte {
+	return r.raster
+}
+
+func (r *energyRenderable) Render() error {
+	data, err := r.energyProvider.GetEnergy()
+	if err != nil {
+		println(eris.ToString(eris.Wrap(err, "Error loading energy"), true))
+		if r.failed {
+			return nil
+		}
+		r.failed = true
+		if r.cached == nil {
+			r.cached = &energy.EnergyData{}
+		}
+	} else {
+		if !r.failed && r.cached != nil && *data == *r.cached {
+			return nil
+		}
+		r.failed = false
+		r.cached = data
+	}
+	html, err := renderEnergy(createEnergyData(r.cached, r.failed, r.translator), r.energyHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("energy", html, "energy_"+strconv.FormatInt(time.Now().Unix(), 10), r.size)
+	if err != nil {
+		return err
+	}
+	r.raster = raster
+	return nil
+}
@@ -0,0 +1,171 @@
+package energy_usage
+
+import (
+	"fkirill.org/eink-meteo-station/data/energy"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/images"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type energyRow struct {
+	Label string
+	Value string
+	Unit  string
+}
+
+type energyData struct {
+	Title      string
+	Warning    bool // the data couldn't be loaded, the last one is shown
+	Rows       []*energyRow
+	Chart      string
+	WarningPng string
+	RootPath   string
+}
+
+// sizes of the chart in pixels
+const (
+	chartWidth       = 720
+	chartHeight      = 180
+	chartLabelHeight = 28
+)
+
+func formatKwh(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// createEnergyData lists the totals of today, the grid, the consumption and the battery are left out
+// if their sensors are not configured
+func createEnergyData(data *energy.EnergyData, failed bool, translator i18n.Translator) *energyData {
+	res := &energyData{
+		Title:      translator.Text("energy"),
+		Warning:    failed,
+		Rows:       make([]*energyRow, 0, 5),
+		Chart:      chartSvg(data),
+		WarningPng: images.Warning_png_src,
+		RootPath:   utils.GetRootDir(),
+	}
+	res.Rows = append(res.Rows, &energyRow{Label: translator.Text("solar"), Value: formatKwh(data.Solar), Unit: "kWh"})
+	if data.HasConsumption {
+		res.Rows = append(res.Rows, &energyRow{Label: translator.Text("consumption"), Value: formatKwh(data.Consumption), Unit: "kWh"})
+	}
+	if data.HasGrid {
+		res.Rows = append(res.Rows, &energyRow{Label: translator.Text("grid_import"), Value: formatKwh(data.GridImport), Unit: "kWh"})
+		res.Rows = append(res.Rows, &energyRow{Label: translator.Text("grid_export"), Value: formatKwh(data.GridExport), Unit: "kWh"})
+	}
+	if data.HasBattery {
+		res.Rows = append(res.Rows, &energyRow{Label: translator.Text("battery"), Value: strconv.Itoa(int(math.Round(data.Battery))), Unit: "%"})
+	}
+	return res
+}
+
+// chartSvg draws the hourly bars of today, the solar production is filled and the consumption is outlined behind it,
+// black only to stay sharp in the black and white refresh mode
+func chartSvg(data *energy.EnergyData) string {
+	high := 0.0
+	for i := range data.SolarHours {
+		high = math.Max(high, math.Max(data.SolarHours[i], data.ConsumptionHours[i]))
+	}
+	// an empty day still shows the axis
+	high = math.Max(high, 0.1)
+	plotHeight := float64(chartHeight - chartLabelHeight)
+	slot := float64(chartWidth) / 24
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	bar := func(x, width, v float64, filled bool) string {
+		h := v / high * (plotHeight - 4)
+		fill := "none"
+		if filled {
+			fill = "#000"
+		}
+		return `<rect x="` + format(x) + `" y="` + format(plotHeight-h) + `" width="` + format(width) + `" height="` + format(h) +
+			`" fill="` + fill + `" stroke="#000" stroke-width="2"/>`
+	}
+	sb := strings.Builder{}
+	sb.WriteString(`<svg width="` + strconv.Itoa(chartWidth) + `" height="` + strconv.Itoa(chartHeight) + `">`)
+	for i := 0; i <= data.Hour; i++ {
+		x := float64(i) * slot
+		if data.ConsumptionHours[i] > 0 {
+			sb.WriteString(bar(x+2, slot-4, data.ConsumptionHours[i], false))
+		}
+		if data.SolarHours[i] > 0 {
+			sb.WriteString(bar(x+slot/4, slot/2, data.SolarHours[i], true))
+		}
+	}
+	sb.WriteString(`<line x1="0" y1="` + format(plotHeight) + `" x2="` + strconv.Itoa(chartWidth) + `" y2="` + format(plotHeight) + `" stroke="#000" stroke-width="2"/>`)
+	for hour := 0; hour < 24; hour += 6 {
+		sb.WriteString(`<text font-size="22" font-family="cartograph" x="` + format(float64(hour)*slot) + `" y="` + strconv.Itoa(chartHeight-4) + `">` +
+			strconv.Itoa(hour) + `</text>`)
+	}
+	sb.WriteString(`<text font-size="22" font-family="cartograph" text-anchor="end" x="` + strconv.Itoa(chartWidth) + `" y="20">` +
+		formatKwh(high) + ` kWh</text>`)
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+var energyHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.energyTable {
+    border: 0;
+    border-spacing: 0 6px;
+}
+
+.energyTable td {
+    white-space: nowrap;
+    vertical-align: baseline;
+}
+
+.energyLabel {
+    font-size: 32px;
+    font-family: "verily", serif;
+    font-weight: bold;
+    padding-right: 20px;
+}
+
+.energyValue {
+    font-size: 56px;
+    font-family: "cartograph", serif;
+    text-align: right;
+}
+
+.energyUnit {
+    font-size: 28px;
+    font-family: "cartograph", serif;
+    padding-left: 8px;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div style="padding: 20px 40px">
+    <div>
+      <span style="border-radius: 40px; border: 4px solid; font-size: 48px; padding: 8px 13px; font-family: verily; font-weight: bold">{{.Title}}</span>
+      {{if .Warning}}<img src="{{ .WarningPng }}" width="67" height="67"/>{{end}}
+    </div>
+    <table class="energyTable">
+{{range .Rows}}      <tr>
+        <td class="energyLabel">{{.Label}}</td>
+        <td class="energyValue">{{.Value}}</td>
+        <td class="energyUnit">{{.Unit}}</td>
+      </tr>
+{{end}}    </table>
+    <div>{{.Chart}}</div>
+  </div>
+</body>
+</html>
+`
+
+func renderEnergy(data *energyData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,141 @@
+package entity_grid
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// EntityGridRenderable shows the states of the configured Home Assistant entities as a grid of tiles
+type EntityGridRenderable interface {
+	renderable.Renderable
+}
+
+func NewEntityGridRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	haApi ha.HomeAssistantApi,
+	translator i18n.Translator,
+) (EntityGridRenderable, error) {
+	entities := cfg.GetHAEntities()
+	if !rect.Empty() {
+		if len(entities) == 0 {
+			return nil, eris.New("entity grid widget needs the entities")
+		}
+		for _, entity := range entities {
+			if _, exists := icons[entity.Icon]; entity.Icon != "" && !exists {
+				return nil, eris.Errorf("unknown icon '%s' of '%s', expected door, window, light, switch, cover, person, power or sensor", entity.Icon, entity.Id)
+			}
+		}
+	}
+	stateTemplates, err := parseStateTemplates(entities, translator)
+	if err != nil {
+		return nil, err
+	}
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	entityGridHtmlTemplate, err := template.New("entityGridHtml").Parse(entityGridHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing entity grid template"), true))
+	}
+	return &entityGridRenderable{
+		offset:                 rect.Min,
+		size:                   rect.Size(),
+		nextRedrawTime:         timeProvider.UtcNow(),
+		raster:                 raster,
+		timeProvider:           timeProvider,
+		config:                 cfg,
+		haApi:                  haApi,
+		stateTemplates:         stateTemplates,
+		entityGridHtmlTemplate: entityGridHtmlTemplate,
+	}, nil
+}
+
+type entityGridRenderable struct {
+	offset                 image.Point
+	size                   image.Point
+	nextRedrawTime         time.Time
+	raster                 []byte
+	timeProvider           utils.TimeProvider
+	config                 config.ConfigApi
+	haApi                  ha.HomeAssistantApi
+	stateTemplates         map[string]*template.Template
+	entityGridHtmlTemplate *template.Template
+	lastHtml               string
+}
+
+func (r *entityGridRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+	r.lastHtml = ""
+}
+
+func (_ *entityGridRenderable) String() string {
+	return "entity_grid"
+}
+
+// DisplayMode uses the fast black and white update, the doors and the lights are expected to change often
+func (r *entityGridRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *entityGridRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *entityGridRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *entityGridRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *entityGridRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+func (r *entityGridRenderable) RedrawFinished() {
+	refresh := time.Duration(r.config.GetEntityGridRefreshSeconds()) * time.Second
+	r.nextRedrawTime = r.timeProvider.UtcNow().Add(refresh)
+}
+
+func (r *entityGridRenderable) Raster() []byte {
+	return r.raster
+}
+
+// Render reads all the states in one call, the last states stay on the screen if HA can't be reached
+func (r *entityGridRenderable) Render() error {
+	states, err := r.haApi.DownloadStatesFromHA()
+	if err != nil {
+		println(eris.ToString(eris.Wrap(err, "Error loading HA entity states"), true))
+		return nil
+	}
+	data := createEntityGridData(r.config.GetHAEntities(), states, r.stateTemplates, r.config.GetEntityGridColumns(), r.size)
+	html, err := renderEntityGrid(data, r.entityGridHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	// the screenshot is skipped if nothing has changed
+	if html == r.lastHtml {
+		return nil
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("entity_grid", html, "entity_grid_"+strconv.FormatInt(time.Now().Unix(), 10), r.size)
+	if err != nil {
+		return err
+	}
+	r.raster = raster
+	r.lastHtml = html
+	return nil
+}
@@ -0,0 +1,184 @@
+package entity_grid
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"math"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// icons are drawn with the text colour so that they are inverted with the tile
+var icons = map[string]string{
+	"door":   `<rect x="12" y="4" width="24" height="40" rx="1"/><circle cx="30" cy="25" r="2"/>`,
+	"window": `<rect x="6" y="6" width="36" height="36"/><line x1="24" y1="6" x2="24" y2="42"/><line x1="6" y1="24" x2="42" y2="24"/>`,
+	"light":  `<path d="M24 4a13 13 0 0 0-8 23v7h16v-7a13 13 0 0 0-8-23z"/><line x1="18" y1="40" x2="30" y2="40"/><line x1="20" y1="45" x2="28" y2="45"/>`,
+	"switch": `<rect x="4" y="14" width="40" height="20" rx="10"/><circle cx="34" cy="24" r="6"/>`,
+	"cover":  `<rect x="6" y="4" width="36" height="40"/><line x1="6" y1="13" x2="42" y2="13"/><line x1="6" y1="22" x2="42" y2="22"/><line x1="6" y1="31" x2="42" y2="31"/>`,
+	"person": `<circle cx="24" cy="14" r="9"/><path d="M7 45a17 17 0 0 1 34 0"/>`,
+	"power":  `<path d="M27 3L10 27h12l-3 18 18-25H25z"/>`,
+	"sensor": `<circle cx="24" cy="26" r="18"/><line x1="24" y1="26" x2="35" y2="15"/>`,
+}
+
+type entityTile struct {
+	Label       string
+	Text        string
+	Icon        string
+	Active      bool
+	Unavailable bool
+}
+
+type entityGridData struct {
+	Tiles    []*entityTile
+	Columns  int
+	Rows     int
+	Width    int
+	Height   int
+	IconSize int
+	RootPath string
+}
+
+// gridColumns picks the number of columns making the tiles closest to squares if it is not configured
+func gridColumns(count int, configured int, size image.Point) int {
+	if configured > 0 {
+		return configured
+	}
+	columns := int(math.Ceil(math.Sqrt(float64(count) * float64(size.X) / float64(size.Y))))
+	return max(1, min(columns, count))
+}
+
+// createEntityGridData lists the entities in the configured order, the missing ones are shown as unavailable,
+// the plain state is shown if the template fails
+func createEntityGridData(
+	entities []*config.HAEntity,
+	states map[string]*ha.HomeAssistantState,
+	templates map[string]*template.Template,
+	columns int,
+	size image.Point,
+) *entityGridData {
+	res := &entityGridData{
+		Tiles:    make([]*entityTile, 0, len(entities)),
+		Columns:  gridColumns(len(entities), columns, size),
+		Width:    size.X,
+		Height:   size.Y,
+		RootPath: utils.GetRootDir(),
+	}
+	res.Rows = (len(entities) + res.Columns - 1) / res.Columns
+	res.IconSize = min(size.X/res.Columns, size.Y/max(res.Rows, 1)) / 3
+	for _, entity := range entities {
+		state, exists := states[entity.Id]
+		if !exists {
+			state = &ha.HomeAssistantState{EntityId: entity.Id, State: "unavailable"}
+		}
+		text, err := formatState(entity, state, templates)
+		if err != nil {
+			println(eris.ToString(err, true))
+			text = state.State
+		}
+		tile := &entityTile{
+			Label:       entity.Label,
+			Text:        text,
+			Icon:        icons[entity.Icon],
+			Active:      slices.Contains(activeStates, state.State),
+			Unavailable: unavailable(state),
+		}
+		if tile.Label == "" {
+			tile.Label = attribute(state, "friendly_name")
+		}
+		if tile.Label == "" {
+			tile.Label = entity.Id
+		}
+		if entity.Icon == "" {
+			tile.Icon = icons[defaultIcon(entity.Id, state)]
+		}
+		res.Tiles = append(res.Tiles, tile)
+	}
+	return res
+}
+
+// the widget is refreshed in the black and white mode, the active entities are drawn white on black
+var entityGridHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.entityGrid {
+    display: grid;
+    grid-template-columns: repeat({{ .Columns }}, 1fr);
+    grid-template-rows: repeat({{ .Rows }}, 1fr);
+    gap: 10px;
+    box-sizing: border-box;
+    width: {{ .Width }}px;
+    height: {{ .Height }}px;
+    padding: 10px;
+}
+
+.entityTile {
+    display: flex;
+    flex-direction: column;
+    align-items: center;
+    justify-content: center;
+    overflow: hidden;
+    border: 4px solid #000;
+    border-radius: 12px;
+    color: #000;
+    background: #fff;
+}
+
+.entityTile.active {
+    color: #fff;
+    background: #000;
+}
+
+.entityTile.unavailable {
+    border-style: dashed;
+}
+
+.entityTile svg {
+    fill: none;
+    stroke: currentColor;
+    stroke-width: 3;
+    stroke-linecap: round;
+    stroke-linejoin: round;
+}
+
+.entityLabel {
+    font-size: 28px;
+    font-family: "bront-ubuntu", serif;
+    white-space: nowrap;
+}
+
+.entityState {
+    font-size: 40px;
+    font-family: "verily", serif;
+    font-weight: bold;
+    white-space: nowrap;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div class="entityGrid">
+{{- range .Tiles }}
+    <div class="entityTile{{if .Active}} active{{end}}{{if .Unavailable}} unavailable{{end}}">
+      <svg width="{{ $.IconSize }}" height="{{ $.IconSize }}" viewBox="0 0 48 48">{{ .Icon }}</svg>
+      <div class="entityLabel">{{html .Label}}</div>
+      <div class="entityState">{{html .Text}}</div>
+    </div>
+{{- end }}
+  </div>
+</body>
+</html>
+`
+
+func renderEntityGrid(data *entityGridData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
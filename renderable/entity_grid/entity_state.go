@@ -0,0 +1,172 @@
+package entity_grid
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/ha"
+	"fkirill.org/eink-meteo-station/i18n"
+	"github.com/rotisserie/eris"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// entityState is what the state templates are executed with
+type entityState struct {
+	State      string
+	Unit       string
+	Attributes map[string]any
+}
+
+// default state templates, picked by the domain and the device class of the entity
+var defaultStateTemplates = map[string]string{
+	"opening":  `{{if eq .State "on"}}{{state "open"}}{{else}}{{state "closed"}}{{end}}`,
+	"presence": `{{if eq .State "on"}}{{state "detected"}}{{else}}{{state "clear"}}{{end}}`,
+	"named":    `{{state .State}}`,
+	"number":   `{{round .State 1}}{{if .Unit}} {{.Unit}}{{end}}`,
+}
+
+// the tiles of the entities in these states are drawn inverted to stand out
+var activeStates = []string{"on", "open", "opening", "closing", "unlocked", "home"}
+
+var openingClasses = []string{"door", "garage_door", "window", "opening"}
+var presenceClasses = []string{"motion", "occupancy", "presence"}
+var energyUnits = []string{"W", "kW", "Wh", "kWh"}
+
+// stateFuncs are the template functions on top of t: state translates the known states and leaves the others
+// such as zone names as they are, round rounds numeric states to the number of digits
+func stateFuncs(translator i18n.Translator) template.FuncMap {
+	res := template.FuncMap(i18n.FuncMap(translator))
+	res["state"] = func(state string) string {
+		text := translator.Text("state_" + state)
+		if text == "state_"+state {
+			return state
+		}
+		return text
+	}
+	res["round"] = func(state string, digits int) string {
+		v, err := strconv.ParseFloat(state, 64)
+		if err != nil {
+			return state
+		}
+		scale := math.Pow(10, float64(digits))
+		return strconv.FormatFloat(math.Round(v*scale)/scale, 'f', -1, 64)
+	}
+	return res
+}
+
+// parseStateTemplates parses the default templates and the configured ones, the latter are keyed by the entity id
+func parseStateTemplates(entities []*config.HAEntity, translator i18n.Translator) (map[string]*template.Template, error) {
+	funcs := stateFuncs(translator)
+	res := make(map[string]*template.Template)
+	for name, text := range defaultStateTemplates {
+		tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, eris.Wrapf(err, "Error parsing the default state template '%s'", name)
+		}
+		res[name] = tmpl
+	}
+	for _, entity := range entities {
+		if entity.Template == "" {
+			continue
+		}
+		tmpl, err := template.New(entity.Id).Funcs(funcs).Parse(entity.Template)
+		if err != nil {
+			return nil, eris.Wrapf(err, "Error parsing the state template of '%s'", entity.Id)
+		}
+		res[entity.Id] = tmpl
+	}
+	return res, nil
+}
+
+func domain(entityId string) string {
+	res, _, _ := strings.Cut(entityId, ".")
+	return res
+}
+
+func attribute(state *ha.HomeAssistantState, name string) string {
+	if value, ok := state.Attributes[name].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// defaultTemplate picks the template for an entity without the configured one
+func defaultTemplate(entityId string, state *ha.HomeAssistantState) string {
+	switch domain(entityId) {
+	case "binary_sensor":
+		deviceClass := attribute(state, "device_class")
+		if slices.Contains(openingClasses, deviceClass) {
+			return "opening"
+		}
+		if slices.Contains(presenceClasses, deviceClass) {
+			return "presence"
+		}
+		return "named"
+	case "light", "switch", "input_boolean", "fan", "cover", "lock", "person", "device_tracker":
+		return "named"
+	}
+	return "number"
+}
+
+// defaultIcon picks the icon for an entity without the configured one
+func defaultIcon(entityId string, state *ha.HomeAssistantState) string {
+	switch domain(entityId) {
+	case "binary_sensor":
+		switch deviceClass := attribute(state, "device_class"); {
+		case deviceClass == "window":
+			return "window"
+		case slices.Contains(openingClasses, deviceClass):
+			return "door"
+		case slices.Contains(presenceClasses, deviceClass):
+			return "person"
+		}
+	case "light":
+		return "light"
+	case "switch", "input_boolean", "fan":
+		return "switch"
+	case "cover":
+		return "cover"
+	case "lock":
+		return "door"
+	case "person", "device_tracker":
+		return "person"
+	case "sensor":
+		deviceClass := attribute(state, "device_class")
+		unit := attribute(state, "unit_of_measurement")
+		if deviceClass == "power" || deviceClass == "energy" || slices.Contains(energyUnits, unit) {
+			return "power"
+		}
+	}
+	return "sensor"
+}
+
+func unavailable(state *ha.HomeAssistantState) bool {
+	return state.State == "unavailable" || state.State == "unknown"
+}
+
+// formatState runs the state template of the entity, unavailable entities are reported without it
+func formatState(
+	entity *config.HAEntity,
+	state *ha.HomeAssistantState,
+	templates map[string]*template.Template,
+) (string, error) {
+	tmpl, exists := templates[entity.Id]
+	switch {
+	case unavailable(state):
+		tmpl = templates["named"]
+	case !exists:
+		tmpl = templates[defaultTemplate(entity.Id, state)]
+	}
+	sb := strings.Builder{}
+	err := tmpl.Execute(&sb, &entityState{
+		State:      state.State,
+		Unit:       attribute(state, "unit_of_measurement"),
+		Attributes: state.Attributes,
+	})
+	if err != nil {
+		return "", eris.Wrapf(err, "Error formatting the state of '%s'", entity.Id)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"fkirill.org/eink-meteo-station/clib"
 	"fkirill.org/eink-meteo-station/data/weather"
+	"fkirill.org/eink-meteo-station/i18n"
 	"fkirill.org/eink-meteo-station/puppettier"
 	"fkirill.org/eink-meteo-station/renderable"
 	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
 	"github.com/rotisserie/eris"
 	"image"
 	"strconv"
@@ -22,6 +24,8 @@ type forecastRenderable struct {
 	nextRedrawDateTime     time.Time
 	timeProvider           utils.TimeProvider
 	forecastParsedTemplate *template.Template
+	units                  units.Units
+	translator             i18n.Translator
 }
 
 func (f *forecastRenderable) RedrawNow() {
@@ -32,13 +36,19 @@ type ForecastRenderable interface {
 	renderable.Renderable
 }
 
-func NewForecastRenderable(rect image.Rectangle, timeProvider utils.TimeProvider, weather weather.ForecastDataProvider) (ForecastRenderable, error) {
+func NewForecastRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	weather weather.ForecastDataProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (ForecastRenderable, error) {
 	rasterSize := rect.Dx() * rect.Dy()
 	raster := make([]byte, rasterSize, rasterSize)
 	for i, _ := range raster {
 		raster[i] = 0xff
 	}
-	tmpl, err := template.New("forecast").Parse(forecastTemplate)
+	tmpl, err := template.New("forecast").Funcs(i18n.FuncMap(translator)).Parse(forecastTemplate)
 	if err != nil {
 		return nil, eris.Wrap(err, "Cannot parse template")
 	}
@@ -50,6 +60,8 @@ func NewForecastRenderable(rect image.Rectangle, timeProvider utils.TimeProvider
 		nextRedrawDateTime:     timeProvider.UtcNow(),
 		timeProvider:           timeProvider,
 		forecastParsedTemplate: tmpl,
+		units:                  units,
+		translator:             translator,
 	}, nil
 }
 
@@ -91,7 +103,7 @@ func (f *forecastRenderable) Render() error {
 	if err != nil {
 		return err
 	}
-	img, err := puppettier.RenderInPuppeteer(html, "pressure_"+strconv.FormatInt(time.Now().Unix(), 10), f.size)
+	img, err := puppettier.RenderWidgetInPuppeteer("forecast", html, "forecast_"+strconv.FormatInt(time.Now().Unix(), 10), f.size)
 	if err != nil {
 		return err
 	}
@@ -108,7 +120,7 @@ func (f *forecastRenderable) String() string {
 }
 
 func (f *forecastRenderable) generateForecastHtml(forecastData *weather.ForecastData) (string, error) {
-	forecastTable := convertToTemplateFormat(forecastData.Days)
+	forecastTable := convertToTemplateFormat(forecastData.Days, f.units, f.translator)
 	buffer := bytes.Buffer{}
 	err := f.forecastParsedTemplate.Execute(&buffer, forecastTable)
 	if err != nil {
@@ -117,23 +129,26 @@ func (f *forecastRenderable) generateForecastHtml(forecastData *weather.Forecast
 	return string(buffer.Bytes()), nil
 }
 
-func convertToTemplateFormat(days []weather.ForecastDataDay) *forecastTable {
+func convertToTemplateFormat(days []weather.ForecastDataDay, units units.Units, translator i18n.Translator) *forecastTable {
 	res := make([]*dailyForecast, 0)
 	for _, day := range days {
 		daily := &dailyForecast{
 			DayOfMonth:   strconv.Itoa(day.Date.Day()),
-			DayOfWeek:    day.Date.Weekday().String()[:3],
-			Month:        day.Date.Month().String()[:3],
-			MinTemp:      strconv.Itoa(int(day.MinTemp)),
-			MaxTemp:      strconv.Itoa(int(day.MaxTemp)),
-			AmountOfRain: strconv.Itoa(int(day.ExpectedRainAmountMm)),
-			AmountOfSnow: strconv.Itoa(int(day.ExpectedSnowAmountMm)),
-			MaxWind:      strconv.Itoa(int(day.MaxWindKmh)),
+			DayOfWeek:    translator.ShortWeekdayName(day.Date.Weekday()),
+			Month:        translator.ShortMonthName(day.Date.Month()),
+			MinTemp:      units.FormatTemperature(day.MinTemp),
+			MaxTemp:      units.FormatTemperature(day.MaxTemp),
+			AmountOfRain: units.FormatPrecipitation(day.ExpectedRainAmountMm),
+			AmountOfSnow: units.FormatPrecipitation(day.ExpectedSnowAmountMm),
+			MaxWind:      units.FormatWindSpeed(day.MaxWindMs),
 			WeatherType:  0,
 		}
 		res = append(res, daily)
 	}
 	return &forecastTable{
-		Days: res,
+		Days:              res,
+		TemperatureUnit:   units.TemperatureUnit(),
+		PrecipitationUnit: units.PrecipitationUnit(),
+		WindSpeedUnit:     units.WindSpeedUnit(),
 	}
 }
@@ -2,7 +2,7 @@ package forecast
 
 type dailyForecast struct {
 	DayOfMonth   string // two characters
-	DayOfWeek    string // three characters
+	DayOfWeek    string // two or three characters, depending on the language
 	Month        string // three characters
 	MinTemp      string // two characters
 	MaxTemp      string // two characters
@@ -13,7 +13,10 @@ type dailyForecast struct {
 }
 
 type forecastTable struct {
-	Days []*dailyForecast
+	Days              []*dailyForecast
+	TemperatureUnit   string
+	PrecipitationUnit string
+	WindSpeedUnit     string
 }
 
 var forecastTemplate = `<html lang="en">
@@ -24,26 +27,30 @@ var forecastTemplate = `<html lang="en">
   <table>
     <thead>
       <tr>
-        <td><span style="border-radius: 40px; border: 4px solid; font-size: 40px; padding: 13px; font-family: verily; font-weight: bold">Forecast</span></td>
-        {{range .Days}}<td><div style="text-align: center; font-size: 80px; font-family: cartograph; margin-left:20px; margin-right: 20px">{{.DayOfMonth}}</div><div style="text-align: center; font-size: 40px; font-family: cartograph">{{.DayOfWeek}}</div></td>{{end}}
+        <td><span style="border-radius: 40px; border: 4px solid; font-size: 40px; padding: 13px; font-family: verily; font-weight: bold">{{t "forecast"}}</span></td>
+        {{range .Days}}<td><div style="text-align: center; font-size: 64px; font-family: cartograph; margin-left:20px; margin-right: 20px">{{.DayOfMonth}}</div><div style="text-align: center; font-size: 40px; font-family: cartograph">{{.DayOfWeek}}</div></td>{{end}}
       </tr>
     </thead>
     <tbody>
       <tr>
-        <td style="font-size: 50px; font-family: cartograph">t&nbsp;max</td>
-        {{range .Days}}<td><div style="text-align: center; font-size: 80px; font-family: cartograph">{{.MaxTemp}}</div></td>{{end}}
+        <td style="font-size: 50px; font-family: cartograph">{{t "temp_max"}}<span style="font-size: 30px">&nbsp;{{.TemperatureUnit}}</span></td>
+        {{range .Days}}<td><div style="text-align: center; font-size: 64px; line-height: 72px; font-family: cartograph">{{.MaxTemp}}</div></td>{{end}}
       </tr>
       <tr>
-        <td style="font-size: 50px; font-family: cartograph">t&nbsp;min</td>
-        {{range .Days}}<td><div style="text-align: center; font-size: 80px; font-family: cartograph">{{.MinTemp}}</div></td>{{end}}
+        <td style="font-size: 50px; font-family: cartograph">{{t "temp_min"}}<span style="font-size: 30px">&nbsp;{{.TemperatureUnit}}</span></td>
+        {{range .Days}}<td><div style="text-align: center; font-size: 64px; line-height: 72px; font-family: cartograph">{{.MinTemp}}</div></td>{{end}}
       </tr>
       <tr>
-        <td style="font-size: 50px; font-family: cartograph">rain</td>
-        {{range .Days}}<td><div style="text-align: center; font-size: 80px; font-family: cartograph">{{.AmountOfRain}}</div></td>{{end}}
+        <td style="font-size: 50px; font-family: cartograph">{{t "rain"}}<span style="font-size: 30px">&nbsp;{{.PrecipitationUnit}}</span></td>
+        {{range .Days}}<td><div style="text-align: center; font-size: 64px; line-height: 72px; font-family: cartograph">{{.AmountOfRain}}</div></td>{{end}}
       </tr>
       <tr>
-        <td style="font-size: 50px; font-family: cartograph">snow</td>
-        {{range .Days}}<td><div style="text-align: center; font-size: 80px; font-family: cartograph">{{.AmountOfSnow}}</div></td>{{end}}
+        <td style="font-size: 50px; font-family: cartograph">{{t "snow"}}<span style="font-size: 30px">&nbsp;{{.PrecipitationUnit}}</span></td>
+        {{range .Days}}<td><div style="text-align: center; font-size: 64px; line-height: 72px; font-family: cartograph">{{.AmountOfSnow}}</div></td>{{end}}
+      </tr>
+      <tr>
+        <td style="font-size: 50px; font-family: cartograph">{{t "wind"}}<span style="font-size: 30px">&nbsp;{{.WindSpeedUnit}}</span></td>
+        {{range .Days}}<td><div style="text-align: center; font-size: 64px; line-height: 72px; font-family: cartograph">{{.MaxWind}}</div></td>{{end}}
       </tr>
     <tbody>
   </table>
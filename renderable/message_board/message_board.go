@@ -0,0 +1,118 @@
+package message_board
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/data/messages"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// MessageBoardRenderable shows the notes posted to the station, the text is sized to fill the widget
+type MessageBoardRenderable interface {
+	renderable.Renderable
+}
+
+func NewMessageBoardRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	messageStore messages.MessageStore,
+	translator i18n.Translator,
+) MessageBoardRenderable {
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	messageBoardHtmlTemplate, err := template.New("messageBoardHtml").Parse(messageBoardHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing message board template"), true))
+	}
+	return &messageBoardRenderable{
+		offset:                   rect.Min,
+		size:                     rect.Size(),
+		nextRedrawTime:           timeProvider.UtcNow(),
+		raster:                   raster,
+		timeProvider:             timeProvider,
+		messageStore:             messageStore,
+		translator:               translator,
+		messageBoardHtmlTemplate: messageBoardHtmlTemplate,
+	}
+}
+
+type messageBoardRenderable struct {
+	offset                   image.Point
+	size                     image.Point
+	nextRedrawTime           time.Time
+	raster                   []byte
+	timeProvider             utils.TimeProvider
+	messageStore             messages.MessageStore
+	translator               i18n.Translator
+	messageBoardHtmlTemplate *template.Template
+	lastHtml                 string
+}
+
+func (r *messageBoardRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+	r.lastHtml = ""
+}
+
+func (_ *messageBoardRenderable) String() string {
+	return "message_board"
+}
+
+// DisplayMode uses the fast black and white update, the messages come and go at any time
+func (r *messageBoardRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *messageBoardRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *messageBoardRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *messageBoardRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *messageBoardRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+// RedrawFinished checks the messages again at the start of the next minute, the render loop can't be woken up
+// by the web server so a new message is shown within a minute
+func (r *messageBoardRenderable) RedrawFinished() {
+	r.nextRedrawTime = r.timeProvider.UtcNow().Truncate(time.Minute).Add(time.Minute)
+}
+
+func (r *messageBoardRenderable) Raster() []byte {
+	return r.raster
+}
+
+func (r *messageBoardRenderable) Render() error {
+	active := r.messageStore.Active(r.timeProvider.LocalNow())
+	data := createMessageBoardData(active, r.size, r.translator)
+	html, err := renderMessageBoard(data, r.messageBoardHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	// the screenshot is skipped if nothing has changed
+	if html == r.lastHtml {
+		return nil
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("message_board", html, "message_board_"+strconv.FormatInt(time.Now().Unix(), 10), r.size)
+	if err != nil {
+		return err
+	}
+	r.raster = raster
+	r.lastHtml = html
+	return nil
+}
@@ -0,0 +1,188 @@
+package message_board
+
+import (
+	"fkirill.org/eink-meteo-station/data/messages"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"image"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// the font sizes the messages are fitted with, the largest one which fits is taken
+const (
+	maxFontSize  = 96
+	minFontSize  = 24
+	fontSizeStep = 4
+)
+
+// the layout the fitting is estimated with, it must match the template
+const (
+	boardPadding   = 40  // on the left and the right of the widget
+	titleHeight    = 110 // with the padding above the title
+	messagePadding = 12  // inside every message box, the urgent ones are inverted
+	messageGap     = 12  // between the messages
+	charWidth      = 0.52
+	lineHeight     = 1.2
+)
+
+type messageView struct {
+	Text      string
+	Important bool
+	Urgent    bool
+}
+
+type messageBoardData struct {
+	Title    string
+	Messages []*messageView
+	FontSize int
+	More     string // +N if some messages don't fit even with the smallest font
+	Empty    string // shown if there are no messages
+	RootPath string
+}
+
+// countLines estimates the lines the text wraps into, the words longer than a line are broken
+func countLines(text string, charsPerLine int) int {
+	res := 0
+	for _, paragraph := range strings.Split(text, "\n") {
+		lines, lineLength := 1, 0
+		for _, word := range strings.Fields(paragraph) {
+			length := utf8.RuneCountInString(word)
+			switch {
+			case lineLength == 0:
+				lineLength = length
+			case lineLength+1+length <= charsPerLine:
+				lineLength += 1 + length
+			default:
+				lines++
+				lineLength = length
+			}
+			for lineLength > charsPerLine {
+				lines++
+				lineLength -= charsPerLine
+			}
+		}
+		res += lines
+	}
+	return res
+}
+
+// messagesHeight estimates the height of the messages with the font size
+func messagesHeight(texts []string, fontSize int, width int) int {
+	charsPerLine := max(1, int(float64(width-2*boardPadding-2*messagePadding)/(charWidth*float64(fontSize))))
+	res := 0
+	for i, text := range texts {
+		if i > 0 {
+			res += messageGap
+		}
+		res += int(float64(countLines(text, charsPerLine))*lineHeight*float64(fontSize)) + 2*messagePadding
+	}
+	return res
+}
+
+// fitMessages returns the largest font size all the messages fit into the widget with, if they don't fit
+// even with the smallest one the messages at the end are left out
+func fitMessages(texts []string, size image.Point) (int, int) {
+	height := size.Y - titleHeight - boardPadding
+	for fontSize := maxFontSize; fontSize >= minFontSize; fontSize -= fontSizeStep {
+		if messagesHeight(texts, fontSize, size.X) <= height {
+			return fontSize, len(texts)
+		}
+	}
+	shown := len(texts)
+	for shown > 1 && messagesHeight(texts[:shown], minFontSize, size.X) > height {
+		shown--
+	}
+	return minFontSize, shown
+}
+
+func createMessageBoardData(active []*messages.Message, size image.Point, translator i18n.Translator) *messageBoardData {
+	res := &messageBoardData{
+		Title:    translator.Text("messages"),
+		Messages: make([]*messageView, 0, len(active)),
+		FontSize: maxFontSize,
+		RootPath: utils.GetRootDir(),
+	}
+	if len(active) == 0 {
+		res.Empty = translator.Text("no_messages")
+		res.FontSize = 40
+		return res
+	}
+	texts := make([]string, 0, len(active))
+	for _, message := range active {
+		texts = append(texts, message.Text)
+	}
+	fontSize, shown := fitMessages(texts, size)
+	res.FontSize = fontSize
+	for _, message := range active[:shown] {
+		res.Messages = append(res.Messages, &messageView{
+			Text:      message.Text,
+			Important: message.Priority == messages.PriorityImportant,
+			Urgent:    message.Priority == messages.PriorityUrgent,
+		})
+	}
+	if shown < len(active) {
+		res.More = "+" + strconv.Itoa(len(active)-shown)
+	}
+	return res
+}
+
+// the widget is refreshed in the black and white mode, the urgent messages are drawn white on black
+var messageBoardHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.message {
+    margin-top: 12px;
+    padding: 12px;
+    border-radius: 16px;
+    font-size: {{.FontSize}}px;
+    line-height: 1.2;
+    font-family: "bront-ubuntu", serif;
+    white-space: pre-line;
+    overflow-wrap: anywhere;
+}
+
+.important {
+    border: 4px solid #000;
+    padding: 8px;
+    font-weight: bold;
+}
+
+.urgent {
+    background: #000;
+    color: #fff;
+    font-weight: bold;
+}
+
+.more {
+    margin-left: 16px;
+    font-size: 40px;
+    font-family: "cartograph", serif;
+}
+  </style>
+</head>
+<body style="margin: 0; overflow: hidden">
+  <div style="padding: 20px 40px">
+    <div>
+      <span style="border-radius: 40px; border: 4px solid; font-size: 48px; padding: 8px 13px; font-family: verily; font-weight: bold">{{.Title}}</span>
+      {{if .More}}<span class="more">{{.More}}</span>{{end}}
+    </div>
+{{if .Empty}}    <div class="message">{{.Empty}}</div>
+{{end}}{{range .Messages}}    <div class="message{{if .Important}} important{{end}}{{if .Urgent}} urgent{{end}}">{{html .Text}}</div>
+{{end}}  </div>
+</body>
+</html>
+`
+
+func renderMessageBoard(data *messageBoardData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
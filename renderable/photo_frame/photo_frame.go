@@ -0,0 +1,157 @@
+package photo_frame
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PhotoFrameRenderable cycles through the photos of a local directory cropped to the widget
+type PhotoFrameRenderable interface {
+	renderable.Renderable
+}
+
+// NewPhotoFrameRenderable takes the dithering of the widget from the layout, Floyd-Steinberg if it is not set there
+func NewPhotoFrameRenderable(rect image.Rectangle, timeProvider utils.TimeProvider, cfg config.ConfigApi, dither string) (PhotoFrameRenderable, error) {
+	if !rect.Empty() && cfg.GetPhotoDirectory() == "" {
+		return nil, eris.New("photo frame widget needs the photo directory")
+	}
+	if dither == "" {
+		dither = utils.DitherFloydSteinberg
+	}
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	return &photoFrameRenderable{
+		offset:         rect.Min,
+		size:           rect.Size(),
+		nextRedrawTime: timeProvider.UtcNow(),
+		raster:         raster,
+		timeProvider:   timeProvider,
+		config:         cfg,
+		dither:         dither,
+	}, nil
+}
+
+type photoFrameRenderable struct {
+	offset         image.Point
+	size           image.Point
+	nextRedrawTime time.Time
+	raster         []byte
+	timeProvider   utils.TimeProvider
+	config         config.ConfigApi
+	dither         string
+	current        string // file name of the photo shown, empty before the first one
+}
+
+func (r *photoFrameRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+}
+
+func (_ *photoFrameRenderable) String() string {
+	return "photo_frame"
+}
+
+// DisplayMode uses the full grayscale update, the whole photo changes at once
+func (r *photoFrameRenderable) DisplayMode() uint8 {
+	return clib.GC16_Mode
+}
+
+func (r *photoFrameRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *photoFrameRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *photoFrameRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *photoFrameRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+func (r *photoFrameRenderable) RedrawFinished() {
+	interval := time.Duration(r.config.GetPhotoIntervalMinutes()) * time.Minute
+	r.nextRedrawTime = r.timeProvider.UtcNow().Add(interval)
+}
+
+func (r *photoFrameRenderable) Raster() []byte {
+	return r.raster
+}
+
+// listPhotos returns the sorted names of the images in the directory, it is read every time to pick up new photos
+func listPhotos(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, eris.Wrapf(err, "couldn't read the photo directory '%s'", dir)
+	}
+	res := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".jpg", ".jpeg", ".png", ".gif":
+			res = append(res, entry.Name())
+		}
+	}
+	slices.Sort(res)
+	return res, nil
+}
+
+// nextPhoto returns the photo after the current one in the name order, or a random other one if shuffled
+func nextPhoto(photos []string, current string, shuffle bool) string {
+	if shuffle && len(photos) > 1 {
+		others := slices.DeleteFunc(slices.Clone(photos), func(name string) bool { return name == current })
+		return others[rand.Intn(len(others))]
+	}
+	// the current photo could have been deleted, the next one follows it in the name order anyway
+	i, _ := slices.BinarySearch(photos, current)
+	if i < len(photos) && photos[i] == current {
+		i++
+	}
+	return photos[i%len(photos)]
+}
+
+// Render shows the next photo, the broken ones are skipped and the last photo stays if none can be loaded
+func (r *photoFrameRenderable) Render() error {
+	dir := r.config.GetPhotoDirectory()
+	photos, err := listPhotos(dir)
+	if err != nil {
+		println(eris.ToString(err, true))
+		return nil
+	}
+	if len(photos) == 0 {
+		println(eris.ToString(eris.Errorf("no photos in '%s'", dir), true))
+		return nil
+	}
+	name := r.current
+	for range photos {
+		name = nextPhoto(photos, name, r.config.GetPhotoShuffle())
+		img, err := utils.LoadImage(filepath.Join(dir, name))
+		if err != nil {
+			println(eris.ToString(eris.Wrapf(err, "Error loading photo '%s'", name), true))
+			continue
+		}
+		r.raster = utils.NewGrayPlane(img).Fill(r.size).Dither(r.dither)
+		r.current = name
+		return nil
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fkirill.org/eink-meteo-station/clib"
 	"fkirill.org/eink-meteo-station/config"
 	"fkirill.org/eink-meteo-station/data/environment"
+	"fkirill.org/eink-meteo-station/i18n"
 	"fkirill.org/eink-meteo-station/puppettier"
 	"fkirill.org/eink-meteo-station/renderable"
 	"fkirill.org/eink-meteo-station/renderable/utils"
@@ -25,13 +26,14 @@ func NewHAPressureView(
 	timeProvider utils.TimeProvider,
 	cfg config.ConfigApi,
 	envData environment.EnvironmentDataProvider,
+	translator i18n.Translator,
 ) PressureRenderable {
 	var pressureWidgetSize = image.Point{X: rect.Dx(), Y: rect.Dy()}
 	raster := make([]byte, pressureWidgetSize.X*pressureWidgetSize.Y, pressureWidgetSize.X*pressureWidgetSize.Y)
 	for i := range raster {
 		raster[i] = 0xff
 	}
-	tmpl, err := template.New("pressure").Parse(pressureTemplate)
+	tmpl, err := template.New("pressure").Funcs(i18n.FuncMap(translator)).Parse(pressureTemplate)
 	if err != nil {
 		panic(eris.ToString(eris.Wrap(err, "Error parsing pressure template"), true))
 	}
@@ -112,7 +114,7 @@ func (p *pressureView) Render() error {
 		if err != nil {
 			return err
 		}
-		img, err := puppettier.RenderInPuppeteer(html, "pressure_"+strconv.FormatInt(time.Now().Unix(), 10), p.size)
+		img, err := puppettier.RenderWidgetInPuppeteer("pressure", html, "pressure_"+strconv.FormatInt(time.Now().Unix(), 10), p.size)
 		if err != nil {
 			return err
 		}
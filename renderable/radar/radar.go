@@ -0,0 +1,111 @@
+package radar
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	"time"
+)
+
+// RadarRenderable shows a dithered radar or satellite image around home, refreshed every few minutes
+type RadarRenderable interface {
+	renderable.Renderable
+}
+
+// NewRadarRenderable takes the dithering of the widget from the layout, Floyd-Steinberg if it is not set there
+func NewRadarRenderable(rect image.Rectangle, timeProvider utils.TimeProvider, cfg config.ConfigApi, dither string) (RadarRenderable, error) {
+	if !rect.Empty() && cfg.GetRadar().Source == "" {
+		return nil, eris.New("radar widget needs the radar source")
+	}
+	if dither == "" {
+		dither = utils.DitherFloydSteinberg
+	}
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	return &radarRenderable{
+		offset:         rect.Min,
+		size:           rect.Size(),
+		nextRedrawTime: timeProvider.UtcNow(),
+		raster:         raster,
+		timeProvider:   timeProvider,
+		config:         cfg,
+		dither:         dither,
+	}, nil
+}
+
+type radarRenderable struct {
+	offset         image.Point
+	size           image.Point
+	nextRedrawTime time.Time
+	raster         []byte
+	timeProvider   utils.TimeProvider
+	config         config.ConfigApi
+	dither         string
+}
+
+func (r *radarRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+}
+
+func (_ *radarRenderable) String() string {
+	return "radar"
+}
+
+// DisplayMode uses the grayscale update without the flashing, the image changes every few minutes
+func (r *radarRenderable) DisplayMode() uint8 {
+	return clib.GL16_Mode
+}
+
+func (r *radarRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *radarRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *radarRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *radarRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+func (r *radarRenderable) RedrawFinished() {
+	refresh := time.Duration(r.config.GetRadar().RefreshMinutes) * time.Minute
+	r.nextRedrawTime = r.timeProvider.UtcNow().Add(refresh)
+}
+
+func (r *radarRenderable) Raster() []byte {
+	return r.raster
+}
+
+// Render keeps the last image if the new one can't be loaded
+func (r *radarRenderable) Render() error {
+	settings := r.config.GetRadar()
+	var plane *utils.GrayPlane
+	var home *image.Point
+	var err error
+	if isTileSource(settings.Source) {
+		latitude, longitude := r.config.GetDaylightCoordinates()
+		plane, home, err = loadTiles(settings, latitude, longitude, r.size)
+	} else {
+		plane, home, err = loadSingleImage(settings, r.size)
+	}
+	if err != nil {
+		println(eris.ToString(eris.Wrap(err, "Error loading radar image"), true))
+		return nil
+	}
+	raster := plane.Dither(r.dither)
+	if home != nil {
+		drawHomeMarker(raster, r.size, *home)
+	}
+	r.raster = raster
+	return nil
+}
@@ -0,0 +1,143 @@
+package radar
+
+import (
+	"bytes"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/fetch"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"github.com/rotisserie/eris"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// isTileSource tells whether the source is a tile URL template rather than a single image
+func isTileSource(source string) bool {
+	return strings.Contains(source, "{x}") && strings.Contains(source, "{y}")
+}
+
+func loadImage(source string) (image.Image, error) {
+	content, err := fetch.ReadSource(source)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, eris.Wrapf(err, "couldn't decode the image '%s'", source)
+	}
+	return img, nil
+}
+
+// loadSingleImage fits the image into the widget, the home position is mapped onto the widget if it is configured
+func loadSingleImage(settings *config.RadarSettings, size image.Point) (*utils.GrayPlane, *image.Point, error) {
+	img, err := loadImage(settings.Source)
+	if err != nil {
+		return nil, nil, err
+	}
+	plane, scale, offset := utils.NewGrayPlane(img).Fit(size)
+	if settings.HomeX == 0 && settings.HomeY == 0 {
+		return plane, nil, nil
+	}
+	home := image.Point{
+		X: offset.X + int(math.Round(float64(settings.HomeX)*scale)),
+		Y: offset.Y + int(math.Round(float64(settings.HomeY)*scale)),
+	}
+	return plane, &home, nil
+}
+
+// worldPixel returns the Web Mercator position of the coordinates in pixels of the whole map at the zoom level,
+// see https://wiki.openstreetmap.org/wiki/Slippy_map_tilenames
+func worldPixel(latitude, longitude float64, zoom, tileSize int) (float64, float64) {
+	mapSize := float64(tileSize) * math.Exp2(float64(zoom))
+	latitudeRad := latitude * math.Pi / 180
+	x := (longitude + 180) / 360 * mapSize
+	y := (1 - math.Log(math.Tan(latitudeRad)+1/math.Cos(latitudeRad))/math.Pi) / 2 * mapSize
+	return x, y
+}
+
+func tileUrl(source string, zoom, x, y int) string {
+	return strings.NewReplacer(
+		"{z}", strconv.Itoa(zoom),
+		"{x}", strconv.Itoa(x),
+		"{y}", strconv.Itoa(y),
+	).Replace(source)
+}
+
+// loadTiles stitches the tiles covering the widget with home in its centre, the parts beyond the poles are white
+func loadTiles(settings *config.RadarSettings, latitude, longitude float64, size image.Point) (*utils.GrayPlane, *image.Point, error) {
+	homeX, homeY := worldPixel(latitude, longitude, settings.Zoom, settings.TileSize)
+	left := int(math.Round(homeX)) - size.X/2
+	top := int(math.Round(homeY)) - size.Y/2
+	tilesPerSide := 1 << settings.Zoom
+	res := &utils.GrayPlane{Size: size, Pix: make([]float64, size.X*size.Y)}
+	for i := range res.Pix {
+		res.Pix[i] = 255
+	}
+	tileSize := settings.TileSize
+	firstX, lastX := floorDiv(left, tileSize), floorDiv(left+size.X-1, tileSize)
+	firstY, lastY := floorDiv(top, tileSize), floorDiv(top+size.Y-1, tileSize)
+	for tileY := max(firstY, 0); tileY <= min(lastY, tilesPerSide-1); tileY++ {
+		for tileX := firstX; tileX <= lastX; tileX++ {
+			// the map wraps around the antimeridian
+			wrappedX := (tileX%tilesPerSide + tilesPerSide) % tilesPerSide
+			img, err := loadImage(tileUrl(settings.Source, settings.Zoom, wrappedX, tileY))
+			if err != nil {
+				return nil, nil, err
+			}
+			tile := utils.NewGrayPlane(img)
+			if tile.Size.X != tileSize || tile.Size.Y != tileSize {
+				tile = tile.Resize(image.Point{X: tileSize, Y: tileSize})
+			}
+			for y := 0; y < tileSize; y++ {
+				targetY := tileY*tileSize + y - top
+				if targetY < 0 || targetY >= size.Y {
+					continue
+				}
+				for x := 0; x < tileSize; x++ {
+					targetX := tileX*tileSize + x - left
+					if targetX < 0 || targetX >= size.X {
+						continue
+					}
+					res.Pix[targetY*size.X+targetX] = tile.Pix[y*tileSize+x]
+				}
+			}
+		}
+	}
+	return res, &image.Point{X: size.X / 2, Y: size.Y / 2}, nil
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	res := a / b
+	if a%b != 0 && a < 0 {
+		res--
+	}
+	return res
+}
+
+// drawHomeMarker draws a black ring with a white outline and a dot to stay visible on any background
+func drawHomeMarker(raster []byte, size image.Point, home image.Point) {
+	const outer, ring, inner = 16.0, 11.0, 4.0
+	for y := home.Y - int(outer); y <= home.Y+int(outer); y++ {
+		for x := home.X - int(outer); x <= home.X+int(outer); x++ {
+			if x < 0 || y < 0 || x >= size.X || y >= size.Y {
+				continue
+			}
+			d := math.Hypot(float64(x-home.X), float64(y-home.Y))
+			switch {
+			case d <= inner:
+				raster[y*size.X+x] = 0x00
+			case d <= ring-3:
+				raster[y*size.X+x] = 0xff
+			case d <= ring+1:
+				raster[y*size.X+x] = 0x00
+			case d <= outer:
+				raster[y*size.X+x] = 0xff
+			}
+		}
+	}
+}
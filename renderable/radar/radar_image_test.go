@@ -0,0 +1,236 @@
+package radar
+
+import (
+	"bytes"
+	"fkirill.org/eink-meteo-station/config"
+	"image"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// encodePng returns a PNG of the size filled with the gray level
+func encodePng(t *testing.T, size image.Point, gray uint8) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rectangle{Max: size})
+	for i := range img.Pix {
+		img.Pix[i] = gray
+	}
+	buffer := bytes.Buffer{}
+	err := png.Encode(&buffer, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestLoadSingleImage(t *testing.T) {
+	content := encodePng(t, image.Point{X: 200, Y: 100}, 0x40)
+	fileName := filepath.Join(t.TempDir(), "radar.png")
+	err := os.WriteFile(fileName, content, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/radar.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(content)
+		case "/radar.html":
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, source := range []string{"file://" + fileName, server.URL + "/radar.png"} {
+		t.Run(source, func(t *testing.T) {
+			// the image is scaled by half and centred vertically with 25 pixels of white above and below
+			settings := &config.RadarSettings{Source: source, HomeX: 120, HomeY: 40}
+			plane, home, err := loadSingleImage(settings, image.Point{X: 100, Y: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if home == nil || *home != (image.Point{X: 60, Y: 45}) {
+				t.Errorf("got home at %v, expected (60,45)", home)
+			}
+			if plane.Pix[10*100+50] != 255 || plane.Pix[50*100+50] != 0x40 {
+				t.Errorf("got %f in the margin and %f in the image", plane.Pix[10*100+50], plane.Pix[50*100+50])
+			}
+			settings.HomeX, settings.HomeY = 0, 0
+			_, home, err = loadSingleImage(settings, image.Point{X: 100, Y: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if home != nil {
+				t.Errorf("got home at %v without the home position", home)
+			}
+		})
+	}
+
+	t.Run("missing image", func(t *testing.T) {
+		_, _, err := loadSingleImage(&config.RadarSettings{Source: server.URL + "/missing.png"}, image.Point{X: 100, Y: 100})
+		if err == nil {
+			t.Error("expected an error for a missing image")
+		}
+	})
+	t.Run("not an image", func(t *testing.T) {
+		_, _, err := loadSingleImage(&config.RadarSettings{Source: server.URL + "/radar.html"}, image.Point{X: 100, Y: 100})
+		if err == nil {
+			t.Error("expected an error for a page which isn't an image")
+		}
+	})
+}
+
+// tileServer serves 16 pixel tiles of the gray level 10*x+y+100 and records the requested tiles
+type tileServer struct {
+	t         *testing.T
+	lock      sync.Mutex
+	requested []string
+}
+
+func (s *tileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.requested = append(s.requested, r.URL.Path)
+	s.lock.Unlock()
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".png"), "/")
+	if len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+	x, errX := strconv.Atoi(parts[1])
+	y, errY := strconv.Atoi(parts[2])
+	if errX != nil || errY != nil || parts[0] == "9" {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(encodePng(s.t, image.Point{X: 16, Y: 16}, uint8(10*x+y+100)))
+}
+
+func TestLoadTiles(t *testing.T) {
+	tiles := &tileServer{t: t}
+	server := httptest.NewServer(tiles)
+	defer server.Close()
+	size := image.Point{X: 8, Y: 8}
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		zoom                int
+		// the gray levels of the corners, top left, top right, bottom left and bottom right
+		corners   [4]float64
+		requested []string
+	}{
+		{
+			name: "home on the corner of four tiles", latitude: 0, longitude: 0, zoom: 1,
+			corners:   [4]float64{100, 110, 101, 111},
+			requested: []string{"/1/0/0.png", "/1/1/0.png", "/1/0/1.png", "/1/1/1.png"},
+		},
+		{
+			name: "wrapped around the antimeridian", latitude: 0, longitude: 180, zoom: 1,
+			corners:   [4]float64{110, 100, 111, 101},
+			requested: []string{"/1/1/0.png", "/1/0/0.png", "/1/1/1.png", "/1/0/1.png"},
+		},
+		{
+			name: "white beyond the pole", latitude: 85.05, longitude: 0, zoom: 1,
+			corners:   [4]float64{255, 255, 100, 110},
+			requested: []string{"/1/0/0.png", "/1/1/0.png"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tiles.requested = nil
+			settings := &config.RadarSettings{Source: server.URL + "/{z}/{x}/{y}.png", Zoom: test.zoom, TileSize: 16}
+			plane, home, err := loadTiles(settings, test.latitude, test.longitude, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *home != (image.Point{X: 4, Y: 4}) {
+				t.Errorf("got home at %v, expected the centre", *home)
+			}
+			corners := [4]float64{plane.Pix[0], plane.Pix[size.X-1], plane.Pix[(size.Y-1)*size.X], plane.Pix[size.Y*size.X-1]}
+			for i := range corners {
+				if math.Abs(corners[i]-test.corners[i]) > 0.01 {
+					t.Errorf("got corners %v, expected %v", corners, test.corners)
+					break
+				}
+			}
+			if strings.Join(tiles.requested, " ") != strings.Join(test.requested, " ") {
+				t.Errorf("got tiles %v, expected %v", tiles.requested, test.requested)
+			}
+		})
+	}
+
+	t.Run("missing tile", func(t *testing.T) {
+		settings := &config.RadarSettings{Source: server.URL + "/{z}/{x}/{y}.png", Zoom: 9, TileSize: 16}
+		_, _, err := loadTiles(settings, 0, 0, size)
+		if err == nil {
+			t.Error("expected an error for a missing tile")
+		}
+	})
+}
+
+func TestWorldPixel(t *testing.T) {
+	tests := []struct {
+		latitude, longitude float64
+		zoom                int
+		x, y                float64
+	}{
+		{0, 0, 0, 128, 128},
+		{0, -180, 1, 0, 256},
+		{51.5074, -0.1278, 7, 16372.3, 10896.5},
+	}
+	for _, test := range tests {
+		x, y := worldPixel(test.latitude, test.longitude, test.zoom, 256)
+		if math.Abs(x-test.x) > 0.5 || math.Abs(y-test.y) > 0.5 {
+			t.Errorf("(%f, %f) at zoom %d: got (%f, %f), expected (%f, %f)", test.latitude, test.longitude, test.zoom, x, y, test.x, test.y)
+		}
+	}
+}
+
+func TestDrawHomeMarker(t *testing.T) {
+	const background = 0x80
+	size := image.Point{X: 64, Y: 48}
+	tests := []struct {
+		name string
+		home image.Point
+	}{
+		{"inside", image.Point{X: 20, Y: 30}},
+		{"clipped at the corner", image.Point{X: 2, Y: 1}},
+		{"clipped at the far corner", image.Point{X: 63, Y: 47}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raster := bytes.Repeat([]byte{background}, size.X*size.Y)
+			drawHomeMarker(raster, size, test.home)
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					d := math.Hypot(float64(x-test.home.X), float64(y-test.home.Y))
+					var expected byte
+					switch {
+					case d <= 4:
+						expected = 0x00 // dot
+					case d <= 8:
+						expected = 0xff
+					case d <= 12:
+						expected = 0x00 // ring
+					case d <= 16:
+						expected = 0xff // outline
+					default:
+						expected = background
+					}
+					if got := raster[y*size.X+x]; got != expected {
+						t.Fatalf("got %#x at (%d,%d), expected %#x", got, x, y, expected)
+					}
+				}
+			}
+		})
+	}
+}
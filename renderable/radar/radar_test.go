@@ -0,0 +1,98 @@
+package radar
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type radarConfig struct {
+	config.ConfigApi
+	settings *config.RadarSettings
+}
+
+func (c *radarConfig) GetRadar() *config.RadarSettings {
+	return c.settings
+}
+
+func (c *radarConfig) GetDaylightCoordinates() (float64, float64) {
+	return 0, 0
+}
+
+type fixedTime struct{}
+
+func (fixedTime) LocalNow() time.Time {
+	return time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+}
+
+func (fixedTime) UtcNow() time.Time {
+	return time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+}
+
+func TestRenderHomeMarker(t *testing.T) {
+	content := encodePng(t, image.Point{X: 200, Y: 100}, 0x40)
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	tests := []struct {
+		name     string
+		settings *config.RadarSettings
+		home     image.Point
+	}{
+		{
+			name:     "home on a single image",
+			settings: &config.RadarSettings{Source: server.URL + "/radar.png", HomeX: 120, HomeY: 40, RefreshMinutes: 10},
+			home:     image.Point{X: 60, Y: 45},
+		},
+		{
+			name:     "home in the middle of the tiles",
+			settings: &config.RadarSettings{Source: server.URL + "/{z}/{x}/{y}.png", Zoom: 3, TileSize: 256, RefreshMinutes: 10},
+			home:     image.Point{X: 50, Y: 50},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			failing.Store(false)
+			r, err := NewRadarRenderable(image.Rect(10, 20, 110, 120), fixedTime{}, &radarConfig{settings: test.settings}, utils.DitherNone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = r.Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			raster := r.Raster()
+			at := func(dx, dy int) byte {
+				return raster[(test.home.Y+dy)*r.Size().X+test.home.X+dx]
+			}
+			background := at(-30, 0)
+			if background == 0x00 || background == 0xff {
+				t.Fatalf("got %#x for the gray of the image", background)
+			}
+			if at(0, 0) != 0x00 || at(6, 0) != 0xff || at(0, -10) != 0x00 || at(-14, 0) != 0xff || at(20, 0) != background {
+				t.Errorf("the marker is not centred at %v", test.home)
+			}
+
+			// the last image stays if the next one fails to load
+			failing.Store(true)
+			err = r.Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if &r.Raster()[0] != &raster[0] {
+				t.Error("the raster changed after a failed load")
+			}
+		})
+	}
+}
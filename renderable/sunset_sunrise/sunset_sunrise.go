@@ -5,22 +5,42 @@ import (
 	"fkirill.org/eink-meteo-station/clib"
 	"fkirill.org/eink-meteo-station/config"
 	"fkirill.org/eink-meteo-station/data/daylight"
+	"fkirill.org/eink-meteo-station/i18n"
 	"fkirill.org/eink-meteo-station/images"
 	"fkirill.org/eink-meteo-station/puppettier"
 	"fkirill.org/eink-meteo-station/renderable"
 	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"fmt"
 	"github.com/rotisserie/eris"
 	"image"
-	"strconv"
+	"math"
 	"text/template"
 	"time"
 )
 
 type SunsetSunriseData struct {
-	SunriseTime string // five characters
-	SunsetTime  string //  five characters
-	SunrisePng  string
-	SunsetPng   string
+	SunriseTime      string // five characters, up to seven for the 12-hour clock, empty during polar days and nights
+	SunsetTime       string // five characters, up to seven for the 12-hour clock, empty during polar days and nights
+	Polar            string // polar day or polar night shown instead of the sunrise and the sunset
+	PolarPng         string // the sunrise icon for the polar day and the sunset one for the polar night
+	SunrisePng       string
+	SunsetPng        string
+	SolarNoon        string
+	DayLength        string // hours and minutes
+	DayLengthChange  string // signed minutes and seconds compared to yesterday
+	Twilights        []*twilightRow
+	MoonPhase        string
+	MoonIllumination int // percent
+	MoonSvg          string
+	Moonrise         string // empty if the Moon doesn't rise on the day
+	Moonset          string // empty if the Moon doesn't set on the day
+}
+
+type twilightRow struct {
+	Name string
+	Dawn string
+	Dusk string
 }
 
 type sunriseSunsetRenderable struct {
@@ -32,6 +52,8 @@ type sunriseSunsetRenderable struct {
 	nextRedrawDateTime          time.Time
 	timeProvider                utils.TimeProvider
 	sunsetSunriseParsedTemplate *template.Template
+	units                       units.Units
+	translator                  i18n.Translator
 }
 
 func (s *sunriseSunsetRenderable) RedrawNow() {
@@ -47,13 +69,15 @@ func NewSunriseSunsetRenderable(
 	timeProvider utils.TimeProvider,
 	cfg config.ConfigApi,
 	daylightProvider daylight.SunriseSunsetProvider,
+	units units.Units,
+	translator i18n.Translator,
 ) (DaylightRenderable, error) {
 	rasterSize := rect.Dx() * rect.Dy()
 	raster := make([]byte, rasterSize, rasterSize)
 	for i := range raster {
 		raster[i] = 0xff
 	}
-	tmpl, err := template.New("sunsetSunrise").Parse(sunsetSunriseTemplate)
+	tmpl, err := template.New("sunsetSunrise").Funcs(i18n.FuncMap(translator)).Parse(sunsetSunriseTemplate)
 	if err != nil {
 		return nil, eris.Wrap(err, "Cannot parse template")
 	}
@@ -66,6 +90,8 @@ func NewSunriseSunsetRenderable(
 		raster:                      raster,
 		nextRedrawDateTime:          timeProvider.UtcNow(),
 		timeProvider:                timeProvider,
+		units:                       units,
+		translator:                  translator,
 	}, nil
 }
 
@@ -99,24 +125,64 @@ func (s *sunriseSunsetRenderable) NextRedrawDateTimeUtc() time.Time {
 }
 
 func (s *sunriseSunsetRenderable) RedrawFinished() {
-	s.nextRedrawDateTime = s.timeProvider.LocalNow().Truncate(time.Hour*24).AddDate(0, 0, 1).UTC()
+	now := s.timeProvider.LocalNow()
+	s.nextRedrawDateTime = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).UTC()
 }
 
 func (s *sunriseSunsetRenderable) Render() error {
 	latitude, longitude := s.cfg.GetDaylightCoordinates()
-	sunrise, sunset := s.daylightProvider.GetSunriseSunset(latitude, longitude, s.timeProvider.LocalNow())
+	now := s.timeProvider.LocalNow()
+	details := s.daylightProvider.GetDaylightDetails(latitude, longitude, now, now.Location())
+	formatTime := s.units.FormatTime
 
 	sunriseSunsetData := SunsetSunriseData{
-		SunriseTime: formatTime(sunrise),
-		SunsetTime:  formatTime(sunset),
-		SunrisePng:  images.Sunrise_png_src,
-		SunsetPng:   images.Sunset_png_src,
+		SunrisePng:       images.Sunrise_png_src,
+		SunsetPng:        images.Sunset_png_src,
+		SolarNoon:        formatTime(details.SolarNoon),
+		DayLength:        fmt.Sprintf("%d:%02d", int(details.DayLength.Hours()), int(details.DayLength.Minutes())%60),
+		DayLengthChange:  formatChange(details.DayLengthChange),
+		MoonPhase:        s.translator.Text(daylight.MoonPhaseKey(details.MoonPhase)),
+		MoonIllumination: int(math.Round(details.MoonIllumination * 100)),
+		MoonSvg:          moonSvg(details.MoonPhase, latitude < 0),
+	}
+	for _, twilight := range []struct {
+		name     string
+		twilight daylight.Twilight
+	}{
+		{"civil", details.CivilTwilight},
+		{"nautical", details.NauticalTwilight},
+		{"astronomical", details.AstronomicalTwilight},
+	} {
+		if twilight.twilight.Valid {
+			sunriseSunsetData.Twilights = append(sunriseSunsetData.Twilights, &twilightRow{
+				Name: s.translator.Text(twilight.name),
+				Dawn: formatTime(twilight.twilight.Dawn),
+				Dusk: formatTime(twilight.twilight.Dusk),
+			})
+		}
+	}
+	switch details.Type {
+	case daylight.PolarDay:
+		sunriseSunsetData.Polar = s.translator.Text("polar_day")
+		sunriseSunsetData.PolarPng = images.Sunrise_png_src
+	case daylight.PolarNight:
+		sunriseSunsetData.Polar = s.translator.Text("polar_night")
+		sunriseSunsetData.PolarPng = images.Sunset_png_src
+	default:
+		sunriseSunsetData.SunriseTime = formatTime(details.Sunrise)
+		sunriseSunsetData.SunsetTime = formatTime(details.Sunset)
+	}
+	if details.HasMoonrise {
+		sunriseSunsetData.Moonrise = formatTime(details.Moonrise)
+	}
+	if details.HasMoonset {
+		sunriseSunsetData.Moonset = formatTime(details.Moonset)
 	}
 	html, err := s.generateSunriseHtml(&sunriseSunsetData)
 	if err != nil {
 		return err
 	}
-	raster, err := puppettier.RenderInPuppeteer(html, "sunrise_sunset", s.size)
+	raster, err := puppettier.RenderWidgetInPuppeteer("daylight", html, "sunrise_sunset", s.size)
 	if err != nil {
 		return err
 	}
@@ -124,18 +190,39 @@ func (s *sunriseSunsetRenderable) Render() error {
 	return nil
 }
 
-func formatTime(timePastMidnight time.Duration) string {
-	hours := int(timePastMidnight.Hours())
-	minutes := int(timePastMidnight.Minutes()) % 60
-	hoursStr := strconv.Itoa(hours)
-	if len(hoursStr) == 1 {
-		hoursStr = "0" + hoursStr
+// formatChange formats a day length change as signed minutes and seconds, e.g. "+2:05" or "-0:48"
+func formatChange(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// moonSvg draws the lit part of the Moon over the dark disc, the Moon is lit from the right while waxing
+// as seen from the northern hemisphere and mirrored for the southern one
+func moonSvg(phase float64, southernHemisphere bool) string {
+	const r = 30.0
+	waxing := phase < 0.5
+	cosPhase := math.Cos(2 * math.Pi * phase)
+	outerSweep := 0
+	if waxing {
+		outerSweep = 1
+	}
+	innerSweep := 0
+	if (waxing && cosPhase < 0) || (!waxing && cosPhase > 0) {
+		innerSweep = 1
 	}
-	minutesStr := strconv.Itoa(minutes)
-	if len(minutesStr) == 1 {
-		minutesStr = "0" + minutesStr
+	transform := ""
+	if southernHemisphere {
+		transform = ` transform="scale(-1, 1) translate(-64, 0)"`
 	}
-	return hoursStr + ":" + minutesStr
+	return fmt.Sprintf(`<svg width="64" height="64" viewBox="0 0 64 64"><g%s>`+
+		`<circle cx="32" cy="32" r="%.0f" fill="#444" stroke="#000" stroke-width="2"/>`+
+		`<path d="M 32 2 A %.0f %.0f 0 0 %d 32 62 A %.2f %.0f 0 0 %d 32 2 Z" fill="#fff"/>`+
+		`</g></svg>`,
+		transform, r, r, r, outerSweep, r*math.Abs(cosPhase), r, innerSweep)
 }
 
 func (s *sunriseSunsetRenderable) DisplayMode() uint8 {
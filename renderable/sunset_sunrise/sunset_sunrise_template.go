@@ -3,19 +3,44 @@ package sunset_sunrise
 var sunsetSunriseTemplate = `<html lang="en">
 <head>
     <link rel="stylesheet" href="fonts.css"/>
+    <style>
+.small {
+    font-size: 24px;
+    font-family: cartograph;
+}
+
+.small td {
+    padding-right: 12px;
+}
+    </style>
 </head>
 <body style="margin: 0">
-	<div style="padding: 67px; display: inline">
+	<div style="padding: 10px 30px; display: inline">
 		<div>
-			<span style="border-radius: 40px; border: 4px solid; font-size: 80px; padding: 13px; font-family: verily; font-weight: bold">Daylight</span>
+			<span style="border-radius: 24px; border: 4px solid; font-size: 44px; padding: 6px; font-family: verily; font-weight: bold">{{t "daylight"}}</span>
+		</div>
+{{if .Polar}}		<div style="margin-top: 8px">
+			<img src="{{ .PolarPng }}" width="44" height="44"/>
+			<span style="font-size: 44px; font-family: verily; font-weight: bold">{{.Polar}}</span>
 		</div>
-		<div style="margin-top: 27px">
-			<img src="{{ .SunrisePng}}" width="67" height="67"/>
-			<span style="font-size: 100px; font-family: cartograph">{{.SunriseTime}}</span>
+{{else}}		<div style="margin-top: 8px">
+			<img src="{{ .SunrisePng}}" width="44" height="44"/>
+			<span style="font-size: 56px; font-family: cartograph">{{.SunriseTime}}</span>
+		</div>
+		<div>
+			<img src="{{ .SunsetPng }}" width="44" height="44"/>
+			<span style="font-size: 56px; font-family: cartograph">{{.SunsetTime}}</span>
 		</div>
-		<div style="margin-top: 0px">
-			<img src="{{ .SunsetPng }}" width="67" height="67"/>
-			<span style="font-size: 100px; font-family: cartograph">{{.SunsetTime}}</span>
+{{end}}		<table class="small">
+{{range .Twilights}}			<tr><td>{{.Name}}</td><td>{{.Dawn}}</td><td>{{.Dusk}}</td></tr>
+{{end}}			<tr><td>{{t "solar_noon"}}</td><td>{{.SolarNoon}}</td><td>{{t "day_length"}}&nbsp;{{.DayLength}}&nbsp;{{.DayLengthChange}}</td></tr>
+		</table>
+		<div style="margin-top: 8px">
+			<span style="vertical-align: middle">{{.MoonSvg}}</span>
+			<span class="small" style="display: inline-block; vertical-align: middle">
+				{{.MoonPhase}} {{.MoonIllumination}}%<br/>
+				&uarr;&nbsp;{{if .Moonrise}}{{.Moonrise}}{{else}}&ndash;{{end}} &darr;&nbsp;{{if .Moonset}}{{.Moonset}}{{else}}&ndash;{{end}}
+			</span>
 		</div>
 	</div>
 </body>
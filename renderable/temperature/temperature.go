@@ -102,12 +102,19 @@ func (t *temperatureView) RedrawFinished() {
 
 func (t *temperatureView) Render() error {
 	signleTempViewSize := image.Point{t.size.X / 2, t.size.Y}
-	inside, err := t.envProvider.GetInsideTemperatureHumidity()
+	inside, insideErr := t.envProvider.GetInsideTemperatureHumidity()
 	if inside == nil {
 		inside = &environment.TemperatureHumidityData{}
 	}
+	outside, outsideErr := t.envProvider.GetOutsideTemperatureHumidity()
+	if outside == nil {
+		outside = &environment.TemperatureHumidityData{}
+	}
+	if insideErr == nil && outsideErr == nil {
+		inside.Advice = t.envProvider.GetVentilationAdvice(inside, outside)
+	}
 	insideNeedsRedraw := false
-	if err != nil {
+	if insideErr != nil {
 		if !t.cachedInside.Warning {
 			t.cachedInside.Warning = true
 			insideNeedsRedraw = true
@@ -118,12 +125,8 @@ func (t *temperatureView) Render() error {
 			insideNeedsRedraw = true
 		}
 	}
-	outside, err := t.envProvider.GetOutsideTemperatureHumidity()
-	if outside == nil {
-		outside = &environment.TemperatureHumidityData{}
-	}
 	outsideNeedsRedraw := false
-	if err != nil {
+	if outsideErr != nil {
 		if !t.cachedOutside.Warning {
 			t.cachedOutside.Warning = true
 			outsideNeedsRedraw = true
@@ -139,7 +142,7 @@ func (t *temperatureView) Render() error {
 		if err != nil {
 			return err
 		}
-		img, err := puppettier.RenderInPuppeteer(html, "temperature_"+strconv.FormatInt(time.Now().Unix(), 10), signleTempViewSize)
+		img, err := puppettier.RenderWidgetInPuppeteer("temperature", html, "temperature_"+strconv.FormatInt(time.Now().Unix(), 10), signleTempViewSize)
 		if err != nil {
 			return err
 		}
@@ -150,7 +153,7 @@ func (t *temperatureView) Render() error {
 		if err != nil {
 			return err
 		}
-		img, err := puppettier.RenderInPuppeteer(html, "temperature_"+strconv.FormatInt(time.Now().Unix()+100, 10), signleTempViewSize)
+		img, err := puppettier.RenderWidgetInPuppeteer("temperature", html, "temperature_"+strconv.FormatInt(time.Now().Unix()+100, 10), signleTempViewSize)
 		if err != nil {
 			return err
 		}
@@ -5,7 +5,7 @@ var temperatureTemplate = `<html lang="en">
     <link rel="stylesheet" href="fonts.css"/>
 </head>
 <body style="margin: 0">
-	<div style="padding: 67px; display: inline">
+	<div style="padding: {{if or .TemperatureSparkline .HumiditySparkline}}20px 67px{{else}}40px 67px{{end}}; display: inline">
 		<div>
 			<span style="border-radius: 40px; border: 4px solid; font-size: 80px; padding: 13px; font-family: verily; font-weight: bold">{{.Title}}</span>
 			{{if .Warning}}<img src="{{ .WarningPng }}" width="67" height="67"/>{{end}}
@@ -14,14 +14,19 @@ var temperatureTemplate = `<html lang="en">
 			<img src="{{ .ThermometerPng }}" width="67" height="67"/>
 			<span style="font-size: 133px; font-family: cartograph">{{.TemperatureInt}}</span>
 			<span style="font-size: 80px; font-family: cartograph">.{{.TemperatureFrac}}</span>
+			<span style="font-size: 40px; font-family: cartograph">{{.TemperatureUnit}}</span>
 			<img src="{{if .TemperatureRising}}{{ .RisingPng }}{{end}}{{if .TemperatureFalling}}{ .FallingPng }}{{end}}{{if .TemperatureSteady}}{{ .SteadyPng }}{{end}}" width="30" height="30"/>
 		</div>
+		{{if .TemperatureSparkline}}<div>{{.TemperatureSparkline}}</div>{{end}}
 		<div style="margin-top: 0px">
 			<img src="{{ .HumidityPng }}" width="67" height="67"/>
             {{if .HundredPercentHumidity}}<span style="font-size: 133px; font-family: cartograph">100</span>{{else}}<span style="font-size: 133px; font-family: cartograph">{{.HumidityInt}}</span>
 			<span style="font-size: 80px; font-family: cartograph">.{{.HumidityFrac}}</span>{{end}}
 			<img src="{{if .HumidityRising}}{{ .RisingPng }}{{end}}{{if .HumidityFalling}}{{ .FallingPng }}{{end}}{{if .HumiditySteady}}{{ .SteadyPng }}{{end}}" width="30" height="30"/>
 		</div>
+		{{if .HumiditySparkline}}<div>{{.HumiditySparkline}}</div>{{end}}
+		{{if .DewPoint}}<div style="font-size: 24px; font-family: cartograph">{{.DewPointLabel}} {{.DewPoint}} &middot; {{.FeelsLikeLabel}} {{.FeelsLike}} &middot; {{.AbsoluteHumidity}} g/m&sup3;</div>{{end}}
+		{{if .Advice}}<div style="margin-top: 6px"><span style="border: 3px solid; border-radius: 12px; padding: 2px 10px; font-size: 28px; font-family: verily; font-weight: bold">{{.Advice}}</span></div>{{end}}
 	</div>
 </body>
 </html>`
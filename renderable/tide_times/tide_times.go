@@ -0,0 +1,145 @@
+package tide_times
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/tides"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// TideTimesRenderable shows the water level, the next high and low tides and the tide curve
+type TideTimesRenderable interface {
+	renderable.Renderable
+}
+
+func NewTideTimesRenderable(
+	rect image.Rectangle,
+	timeProvider utils.TimeProvider,
+	cfg config.ConfigApi,
+	tideProvider tides.TideProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (TideTimesRenderable, error) {
+	if !rect.Empty() {
+		settings := cfg.GetTides()
+		if len(settings.Constituents) == 0 && settings.Table == "" {
+			return nil, eris.New("tides widget needs the constituents or the table")
+		}
+	}
+	raster := make([]byte, rect.Dx()*rect.Dy())
+	for i := range raster {
+		raster[i] = 0xff
+	}
+	tideTimesHtmlTemplate, err := template.New("tideTimesHtml").Parse(tideTimesHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(eris.Wrap(err, "Error parsing tides template"), true))
+	}
+	return &tideTimesRenderable{
+		offset:                rect.Min,
+		size:                  rect.Size(),
+		nextRedrawTime:        timeProvider.UtcNow(),
+		raster:                raster,
+		timeProvider:          timeProvider,
+		config:                cfg,
+		tideProvider:          tideProvider,
+		units:                 units,
+		translator:            translator,
+		tideTimesHtmlTemplate: tideTimesHtmlTemplate,
+	}, nil
+}
+
+type tideTimesRenderable struct {
+	offset                image.Point
+	size                  image.Point
+	nextRedrawTime        time.Time
+	raster                []byte
+	timeProvider          utils.TimeProvider
+	config                config.ConfigApi
+	tideProvider          tides.TideProvider
+	units                 units.Units
+	translator            i18n.Translator
+	tideTimesHtmlTemplate *template.Template
+	cached                *tides.TideData // nil until the tides are computed for the first time
+	lastHtml              string
+}
+
+func (r *tideTimesRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+	r.lastHtml = ""
+}
+
+func (_ *tideTimesRenderable) String() string {
+	return "tides"
+}
+
+func (r *tideTimesRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *tideTimesRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *tideTimesRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *tideTimesRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *tideTimesRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+// RedrawFinished moves the curve every 15 minutes, the level is shown with one decimal so it changes about as often
+func (r *tideTimesRenderable) RedrawFinished() {
+	r.nextRedrawTime = r.timeProvider.UtcNow().Truncate(15 * time.Minute).Add(15 * time.Minute)
+}
+
+func (r *tideTimesRenderable) Raster() []byte {
+	return r.raster
+}
+
+// Render keeps showing the tides still ahead with the warning sign if they can't be computed, e.g. the table has ended
+func (r *tideTimesRenderable) Render() error {
+	now := r.timeProvider.LocalNow()
+	data, err := r.tideProvider.GetTides(now)
+	failed := err != nil
+	if failed {
+		println(eris.ToString(eris.Wrap(err, "Error computing tides"), true))
+		if r.cached == nil {
+			r.cached = &tides.TideData{}
+		}
+	} else {
+		r.cached = data
+	}
+	settings := r.config.GetTides()
+	html, err := renderTideTimes(
+		createTideTimesData(r.cached, failed, settings.Label, settings.Unit, now, r.size, r.units, r.translator),
+		r.tideTimesHtmlTemplate,
+	)
+	if err != nil {
+		return err
+	}
+	// the screenshot is skipped if nothing has changed
+	if html == r.lastHtml {
+		return nil
+	}
+	raster, err := puppettier.RenderWidgetInPuppeteer("tides", html, "tides_"+strconv.FormatInt(time.Now().Unix(), 10), r.size)
+	if err != nil {
+		return err
+	}
+	r.raster = raster
+	r.lastHtml = html
+	return nil
+}
@@ -0,0 +1,238 @@
+package tide_times
+
+import (
+	"fkirill.org/eink-meteo-station/data/tides"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/images"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type tideRow struct {
+	Type   string
+	Time   string
+	Height string
+}
+
+type tideTimesData struct {
+	Title      string
+	Label      string
+	Warning    bool // the tides couldn't be computed, the last ones are shown
+	Level      string
+	Unit       string
+	Trend      string // rising or falling, empty if the level is unknown
+	Rows       []*tideRow
+	Chart      string
+	WarningPng string
+	RootPath   string
+}
+
+// sizes of the chart in pixels, it takes the width of the widget
+const (
+	chartHeight      = 200
+	chartLabelHeight = 28
+	chartPadding     = 40 // on the left and the right of the widget
+)
+
+func formatHeight(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// createTideTimesData shows the tides still ahead of now, so the last data stays correct if new one can't be computed
+func createTideTimesData(
+	data *tides.TideData,
+	failed bool,
+	label string,
+	unit string,
+	now time.Time,
+	size image.Point,
+	units units.Units,
+	translator i18n.Translator,
+) *tideTimesData {
+	res := &tideTimesData{
+		Title:      translator.Text("tides"),
+		Label:      label,
+		Warning:    failed,
+		Unit:       unit,
+		Rows:       make([]*tideRow, 0, len(data.Tides)),
+		WarningPng: images.Warning_png_src,
+		RootPath:   utils.GetRootDir(),
+	}
+	if !failed {
+		res.Level = formatHeight(data.Level)
+		res.Trend = translator.Text("tide_falling")
+		if data.Rising {
+			res.Trend = translator.Text("tide_rising")
+		}
+	}
+	for _, tide := range data.Tides {
+		if tide.Time.Before(now) {
+			continue
+		}
+		row := &tideRow{Type: translator.Text("tide_low"), Time: units.FormatTime(tide.Time.In(now.Location())), Height: formatHeight(tide.Height)}
+		if tide.High {
+			row.Type = translator.Text("tide_high")
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	curve := make([]*tides.TidePoint, 0, len(data.Curve))
+	for _, point := range data.Curve {
+		if !point.Time.Before(now) {
+			curve = append(curve, point)
+		}
+	}
+	res.Chart = chartSvg(curve, data.Tides, now, size.X-2*chartPadding, units)
+	return res
+}
+
+// chartSvg draws the water level from now on with the dots at the high and the low tides,
+// black only to stay sharp in the black and white refresh mode
+func chartSvg(curve []*tides.TidePoint, tideList []*tides.Tide, now time.Time, width int, units units.Units) string {
+	if len(curve) < 2 {
+		return ""
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, point := range curve {
+		low = math.Min(low, point.Height)
+		high = math.Max(high, point.Height)
+	}
+	// a flat curve still gets the height of the chart
+	if high-low < 0.1 {
+		high = low + 0.1
+	}
+	start, end := curve[0].Time, curve[len(curve)-1].Time
+	plotHeight := float64(chartHeight - chartLabelHeight)
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 1, 64)
+	}
+	x := func(t time.Time) float64 {
+		return float64(t.Sub(start)) / float64(end.Sub(start)) * float64(width)
+	}
+	y := func(height float64) float64 {
+		return 8 + (high-height)/(high-low)*(plotHeight-16)
+	}
+	sb := strings.Builder{}
+	sb.WriteString(`<svg width="` + strconv.Itoa(width) + `" height="` + strconv.Itoa(chartHeight) + `">`)
+	sb.WriteString(`<polyline fill="none" stroke="#000" stroke-width="4" points="`)
+	for i, point := range curve {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(format(x(point.Time)) + "," + format(y(point.Height)))
+	}
+	sb.WriteString(`"/>`)
+	for _, tide := range tideList {
+		if tide.Time.Before(start) || tide.Time.After(end) {
+			continue
+		}
+		fill := "#fff"
+		if tide.High {
+			fill = "#000"
+		}
+		sb.WriteString(`<circle cx="` + format(x(tide.Time)) + `" cy="` + format(y(tide.Height)) + `" r="9" fill="` + fill + `" stroke="#000" stroke-width="3"/>`)
+	}
+	sb.WriteString(`<line x1="0" y1="` + format(plotHeight) + `" x2="` + strconv.Itoa(width) + `" y2="` + format(plotHeight) + `" stroke="#000" stroke-width="2"/>`)
+	// the hour labels every 6 hours of the local time
+	local := start.In(now.Location())
+	tick := time.Date(local.Year(), local.Month(), local.Day(), local.Hour()-local.Hour()%6, 0, 0, 0, local.Location())
+	for ; !tick.After(end); tick = tick.Add(6 * time.Hour) {
+		if tick.Before(start) || x(tick) > float64(width)-30 {
+			continue
+		}
+		sb.WriteString(`<line x1="` + format(x(tick)) + `" y1="` + format(plotHeight) + `" x2="` + format(x(tick)) + `" y2="` + format(plotHeight-10) + `" stroke="#000" stroke-width="2"/>`)
+		sb.WriteString(`<text font-size="22" font-family="cartograph" x="` + format(x(tick)) + `" y="` + strconv.Itoa(chartHeight-4) + `">` +
+			strconv.Itoa(units.Hour(tick)) + `</text>`)
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+var tideTimesHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.tideTable {
+    border: 0;
+    border-spacing: 0 6px;
+}
+
+.tideTable td {
+    white-space: nowrap;
+    vertical-align: baseline;
+}
+
+.tideType {
+    font-size: 32px;
+    font-family: "verily", serif;
+    font-weight: bold;
+    padding-right: 20px;
+}
+
+.tideTime {
+    font-size: 48px;
+    font-family: "cartograph", serif;
+    padding-right: 30px;
+}
+
+.tideHeight {
+    font-size: 40px;
+    font-family: "cartograph", serif;
+    text-align: right;
+}
+
+.tideUnit {
+    font-size: 28px;
+    font-family: "cartograph", serif;
+    padding-left: 8px;
+}
+
+.tideLabel {
+    margin-left: 16px;
+    font-size: 32px;
+    font-family: "verily", serif;
+}
+
+.tideLevel {
+    margin-top: 10px;
+    font-size: 32px;
+    font-family: "bront-ubuntu", serif;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <div style="padding: 20px 40px">
+    <div>
+      <span style="border-radius: 40px; border: 4px solid; font-size: 48px; padding: 8px 13px; font-family: verily; font-weight: bold">{{.Title}}</span>
+      {{if .Label}}<span class="tideLabel">{{html .Label}}</span>{{end}}
+      {{if .Warning}}<img src="{{ .WarningPng }}" width="67" height="67"/>{{end}}
+    </div>
+{{if .Level}}    <div class="tideLevel">{{.Trend}} <span style="font-family: cartograph; font-size: 40px">{{.Level}}</span> {{html .Unit}}</div>
+{{end}}    <table class="tideTable">
+{{range .Rows}}      <tr>
+        <td class="tideType">{{.Type}}</td>
+        <td class="tideTime">{{.Time}}</td>
+        <td class="tideHeight">{{.Height}}</td>
+        <td class="tideUnit">{{html $.Unit}}</td>
+      </tr>
+{{end}}    </table>
+    <div>{{.Chart}}</div>
+  </div>
+</body>
+</html>
+`
+
+func renderTideTimes(data *tideTimesData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
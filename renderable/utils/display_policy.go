@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/renderable"
+	"image"
+	"time"
+)
+
+// DisplayPolicy sits between the widgets and the render loop, at night it switches to the night layout and palette,
+// slows the screen updates down and keeps the screen from flashing. During the photo hours the screen shows the photos.
+type DisplayPolicy interface {
+	renderable.Renderable
+	// Update re-evaluates the schedules, it returns true if the display has just switched to other widgets
+	Update() bool
+	Night() bool
+}
+
+// NewDisplayPolicy creates the policy showing the day widgets all the time if the schedules are nil,
+// night widgets can be the same as the day ones to keep the layout and only change the cadence and the palette.
+// The night takes precedence over the photo hours.
+func NewDisplayPolicy(
+	dayWidgets MultiRenderable,
+	nightWidgets MultiRenderable,
+	schedule NightSchedule,
+	photoWidgets MultiRenderable,
+	photoSchedule NightSchedule,
+	provider TimeProvider,
+	cfg config.ConfigApi,
+) DisplayPolicy {
+	return &displayPolicy{
+		dayWidgets:      dayWidgets,
+		nightWidgets:    nightWidgets,
+		photoWidgets:    photoWidgets,
+		current:         dayWidgets,
+		schedule:        schedule,
+		photoSchedule:   photoSchedule,
+		timeProvider:    provider,
+		invert:          cfg.GetNightInvert(),
+		refreshInterval: time.Duration(cfg.GetNightRefreshSeconds()) * time.Second,
+	}
+}
+
+type displayPolicy struct {
+	dayWidgets      MultiRenderable
+	nightWidgets    MultiRenderable
+	photoWidgets    MultiRenderable
+	current         MultiRenderable
+	schedule        NightSchedule
+	photoSchedule   NightSchedule
+	timeProvider    TimeProvider
+	night           bool
+	nextSwitch      time.Time
+	invert          bool
+	invertedRaster  []byte
+	refreshInterval time.Duration
+}
+
+func (p *displayPolicy) Update() bool {
+	if p.schedule == nil && p.photoSchedule == nil {
+		return false
+	}
+	now := p.timeProvider.LocalNow()
+	if now.Before(p.nextSwitch) {
+		return false
+	}
+	night, photos := false, false
+	p.nextSwitch = time.Time{}
+	if p.schedule != nil {
+		night, p.nextSwitch = p.schedule.IsNight(now)
+	}
+	if p.photoSchedule != nil {
+		var nextPhotoSwitch time.Time
+		photos, nextPhotoSwitch = p.photoSchedule.IsNight(now)
+		if p.nextSwitch.IsZero() || nextPhotoSwitch.Before(p.nextSwitch) {
+			p.nextSwitch = nextPhotoSwitch
+		}
+	}
+	next := p.dayWidgets
+	switch {
+	case night:
+		next = p.nightWidgets
+	case photos:
+		next = p.photoWidgets
+	}
+	// the night widgets can be the day ones, the palette changes then
+	if night == p.night && next == p.current {
+		return false
+	}
+	p.night = night
+	p.current = next
+	// at night the widgets due within one refresh interval are drawn together, the screen is updated once per interval
+	if night {
+		p.current.AlignRedraws(p.refreshInterval)
+	} else {
+		p.current.AlignRedraws(0)
+	}
+	// the widgets hidden until now have stale or no content, the raster is cleared and everything is drawn again
+	p.current.RedrawNow()
+	return true
+}
+
+func (p *displayPolicy) Night() bool {
+	return p.night
+}
+
+func (p *displayPolicy) RedrawNow() {
+	p.current.RedrawNow()
+}
+
+func (_ *displayPolicy) String() string {
+	return "display-policy"
+}
+
+// DisplayMode replaces the flashing full grayscale update with the non-flashing one at night
+func (p *displayPolicy) DisplayMode() uint8 {
+	mode := p.current.DisplayMode()
+	if p.night && mode == clib.GC16_Mode {
+		return clib.GL16_Mode
+	}
+	return mode
+}
+
+func (p *displayPolicy) Offset() image.Point {
+	return p.current.Offset()
+}
+
+func (p *displayPolicy) BoundingBox() image.Rectangle {
+	return p.current.BoundingBox()
+}
+
+func (p *displayPolicy) Size() image.Point {
+	return p.current.Size()
+}
+
+func (p *displayPolicy) Raster() []byte {
+	raster := p.current.Raster()
+	if !p.night || !p.invert {
+		return raster
+	}
+	if len(p.invertedRaster) != len(raster) {
+		p.invertedRaster = make([]byte, len(raster))
+	}
+	for i, b := range raster {
+		p.invertedRaster[i] = 0xff - b
+	}
+	return p.invertedRaster
+}
+
+// NextRedrawDateTimeUtc is brought forward if the display is due to switch to other widgets
+func (p *displayPolicy) NextRedrawDateTimeUtc() time.Time {
+	next := p.current.NextRedrawDateTimeUtc()
+	if !p.nextSwitch.IsZero() && p.nextSwitch.Before(next) {
+		return p.nextSwitch.UTC()
+	}
+	return next
+}
+
+func (p *displayPolicy) RedrawFinished() {
+	p.current.RedrawFinished()
+}
+
+func (p *displayPolicy) Render() error {
+	return p.current.Render()
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/renderable"
+	"image"
+	"testing"
+	"time"
+)
+
+type nightConfig struct {
+	config.ConfigApi
+}
+
+func (nightConfig) GetNightInvert() bool {
+	return false
+}
+
+func (nightConfig) GetNightRefreshSeconds() int {
+	return 300
+}
+
+type fixedTime struct {
+	now time.Time
+}
+
+func (f *fixedTime) LocalNow() time.Time {
+	return f.now
+}
+
+func (f *fixedTime) UtcNow() time.Time {
+	return f.now
+}
+
+// constantSchedule is night or day until the far future
+type constantSchedule bool
+
+func (s constantSchedule) IsNight(now time.Time) (bool, time.Time) {
+	return bool(s), now.AddDate(1, 0, 0)
+}
+
+// dueWidget is due at the given time and counts its renders
+type dueWidget struct {
+	rect     image.Rectangle
+	next     time.Time
+	rendered int
+	finished int
+}
+
+func (w *dueWidget) BoundingBox() image.Rectangle {
+	return w.rect
+}
+
+func (w *dueWidget) Offset() image.Point {
+	return w.rect.Min
+}
+
+func (w *dueWidget) Size() image.Point {
+	return w.rect.Size()
+}
+
+func (w *dueWidget) Raster() []byte {
+	return make([]byte, w.rect.Dx()*w.rect.Dy())
+}
+
+func (w *dueWidget) NextRedrawDateTimeUtc() time.Time {
+	return w.next
+}
+
+func (w *dueWidget) RedrawFinished() {
+	w.finished++
+}
+
+func (w *dueWidget) Render() error {
+	w.rendered++
+	return nil
+}
+
+func (*dueWidget) DisplayMode() uint8 {
+	return clib.GC16_Mode
+}
+
+func (*dueWidget) String() string {
+	return "due-widget"
+}
+
+func (*dueWidget) RedrawNow() {
+}
+
+func TestWidgetsDueWithinOneInterval(t *testing.T) {
+	now := time.Date(2024, 1, 15, 22, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		night    bool
+		next     time.Time
+		rendered [2]int
+	}{
+		{"night renders both at the aligned tick", true, now.Add(5 * time.Minute), [2]int{1, 1}},
+		{"day renders the first one on time", false, now.Add(time.Minute + 10*time.Second), [2]int{1, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first := &dueWidget{rect: image.Rect(0, 0, 10, 10), next: now.Add(time.Minute + 10*time.Second)}
+			second := &dueWidget{rect: image.Rect(10, 0, 20, 10), next: now.Add(3*time.Minute + 30*time.Second)}
+			widgets, err := NewMultiRenderable(image.Rect(0, 0, 20, 10), []renderable.Renderable{first, second}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy := NewDisplayPolicy(widgets, widgets, constantSchedule(test.night), nil, nil, &fixedTime{now: now}, nightConfig{})
+			policy.Update()
+			if next := policy.NextRedrawDateTimeUtc(); !next.Equal(test.next) {
+				t.Errorf("got the next redraw at %v, expected %v", next, test.next)
+			}
+			err = policy.Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy.RedrawFinished()
+			if first.rendered != test.rendered[0] || second.rendered != test.rendered[1] {
+				t.Errorf("got %d and %d renders, expected %v", first.rendered, second.rendered, test.rendered)
+			}
+			if first.finished != first.rendered || second.finished != second.rendered {
+				t.Errorf("got %d and %d finished redraws, expected them to follow the renders", first.finished, second.finished)
+			}
+		})
+	}
+}
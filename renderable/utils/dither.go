@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"github.com/rotisserie/eris"
+	"image"
+	"math"
+)
+
+// dithering methods of the conversion to the 16 grey levels of the panel
+const (
+	DitherNone           = "none"
+	DitherOrdered        = "ordered"
+	DitherFloydSteinberg = "floyd_steinberg"
+	DitherAtkinson       = "atkinson"
+)
+
+// the panel shows 16 levels, 0x00, 0x11, ... 0xff
+const grayLevelStep = 255.0 / 15
+
+// GrayCalibration describes how the panel shows its levels
+type GrayCalibration struct {
+	Gamma  float64     // applied to the grey values before they are reduced to the panel levels
+	Levels [16]float64 // perceived brightness of every panel level from 0 (black) to 255 (white), ascending
+}
+
+func DefaultGrayCalibration() *GrayCalibration {
+	res := &GrayCalibration{Gamma: 1}
+	for i := range res.Levels {
+		res.Levels[i] = float64(i) * grayLevelStep
+	}
+	return res
+}
+
+// NewGrayCalibration checks the gamma and the measured levels, the evenly spread levels are used if there are none
+func NewGrayCalibration(gamma float64, levels []float64) (*GrayCalibration, error) {
+	res := DefaultGrayCalibration()
+	if gamma <= 0 {
+		return nil, eris.Errorf("panel gamma %v must be positive", gamma)
+	}
+	res.Gamma = gamma
+	if len(levels) == 0 {
+		return res, nil
+	}
+	if len(levels) != len(res.Levels) {
+		return nil, eris.Errorf("panel levels must list all %d levels, got %d", len(res.Levels), len(levels))
+	}
+	for i, level := range levels {
+		if level < 0 || level > 255 || (i > 0 && level <= levels[i-1]) {
+			return nil, eris.Errorf("panel levels must ascend from 0 to 255, got %v", levels)
+		}
+		res.Levels[i] = level
+	}
+	return res, nil
+}
+
+var panelCalibration = DefaultGrayCalibration()
+
+// SetGrayCalibration sets the calibration all the images are converted with
+func SetGrayCalibration(calibration *GrayCalibration) {
+	panelCalibration = calibration
+}
+
+// GrayPlane is an image as grey values from 0 (black) to 255 (white) before they are reduced to the panel levels
+type GrayPlane struct {
+	Size image.Point
+	Pix  []float64
+}
+
+func ValidateDither(method string) error {
+	switch method {
+	case DitherNone, DitherOrdered, DitherFloydSteinberg, DitherAtkinson:
+		return nil
+	}
+	return eris.Errorf("unknown dithering '%s', expected none, ordered, floyd_steinberg or atkinson", method)
+}
+
+// NewGrayPlane converts an image of any type, transparent parts are put over white
+func NewGrayPlane(img image.Image) *GrayPlane {
+	bounds := img.Bounds()
+	res := &GrayPlane{Size: bounds.Size(), Pix: make([]float64, bounds.Dx()*bounds.Dy())}
+	// the screenshots of the widgets are large, the common types are read directly
+	switch typed := img.(type) {
+	case *image.Gray:
+		for y := 0; y < bounds.Dy(); y++ {
+			row := typed.Pix[y*typed.Stride : y*typed.Stride+bounds.Dx()]
+			for x, v := range row {
+				res.Pix[y*bounds.Dx()+x] = float64(v)
+			}
+		}
+		return res
+	case *image.NRGBA:
+		for y := 0; y < bounds.Dy(); y++ {
+			row := typed.Pix[y*typed.Stride : y*typed.Stride+bounds.Dx()*4]
+			for x := 0; x < bounds.Dx(); x++ {
+				r, g, b, a := float64(row[x*4]), float64(row[x*4+1]), float64(row[x*4+2]), float64(row[x*4+3])/255
+				res.Pix[y*bounds.Dx()+x] = (0.299*r+0.587*g+0.114*b)*a + 255*(1-a)
+			}
+		}
+		return res
+	case *image.RGBA:
+		for y := 0; y < bounds.Dy(); y++ {
+			row := typed.Pix[y*typed.Stride : y*typed.Stride+bounds.Dx()*4]
+			for x := 0; x < bounds.Dx(); x++ {
+				// premultiplied
+				r, g, b, a := float64(row[x*4]), float64(row[x*4+1]), float64(row[x*4+2]), float64(row[x*4+3])
+				res.Pix[y*bounds.Dx()+x] = 0.299*r + 0.587*g + 0.114*b + 255 - a
+			}
+		}
+		return res
+	}
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// premultiplied 16-bit components
+			r, g, b, a := img.At(x, y).RGBA()
+			white := float64(0xffff - a)
+			luma := 0.299*(float64(r)+white) + 0.587*(float64(g)+white) + 0.114*(float64(b)+white)
+			res.Pix[i] = luma / 0xffff * 255
+			i++
+		}
+	}
+	return res
+}
+
+// Resize scales the plane averaging the covered source pixels when shrinking and interpolating when enlarging
+func (p *GrayPlane) Resize(size image.Point) *GrayPlane {
+	res := &GrayPlane{Size: size, Pix: make([]float64, size.X*size.Y)}
+	scaleX := float64(p.Size.X) / float64(size.X)
+	scaleY := float64(p.Size.Y) / float64(size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			var v float64
+			if scaleX > 1 || scaleY > 1 {
+				v = p.average(float64(x)*scaleX, float64(y)*scaleY, float64(x+1)*scaleX, float64(y+1)*scaleY)
+			} else {
+				v = p.interpolate((float64(x)+0.5)*scaleX-0.5, (float64(y)+0.5)*scaleY-0.5)
+			}
+			res.Pix[y*size.X+x] = v
+		}
+	}
+	return res
+}
+
+func (p *GrayPlane) at(x, y int) float64 {
+	x = max(0, min(x, p.Size.X-1))
+	y = max(0, min(y, p.Size.Y-1))
+	return p.Pix[y*p.Size.X+x]
+}
+
+// average is the mean of the source pixels the area touches
+func (p *GrayPlane) average(x0, y0, x1, y1 float64) float64 {
+	sum, count := 0.0, 0
+	for y := int(y0); y < max(int(math.Ceil(y1)), int(y0)+1); y++ {
+		for x := int(x0); x < max(int(math.Ceil(x1)), int(x0)+1); x++ {
+			sum += p.at(x, y)
+			count++
+		}
+	}
+	return sum / float64(count)
+}
+
+// interpolate is the bilinear interpolation between the four nearest source pixels
+func (p *GrayPlane) interpolate(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	dx, dy := x-fx, y-fy
+	ix, iy := int(fx), int(fy)
+	top := p.at(ix, iy)*(1-dx) + p.at(ix+1, iy)*dx
+	bottom := p.at(ix, iy+1)*(1-dx) + p.at(ix+1, iy+1)*dx
+	return top*(1-dy) + bottom*dy
+}
+
+// Fit scales the plane to fit into the size keeping its proportions, the margins are white.
+// It returns the scale and the offset of the scaled plane to map positions on it.
+func (p *GrayPlane) Fit(size image.Point) (*GrayPlane, float64, image.Point) {
+	scale := math.Min(float64(size.X)/float64(p.Size.X), float64(size.Y)/float64(p.Size.Y))
+	scaledSize := image.Point{
+		X: max(1, int(math.Round(float64(p.Size.X)*scale))),
+		Y: max(1, int(math.Round(float64(p.Size.Y)*scale))),
+	}
+	scaled := p.Resize(scaledSize)
+	offset := size.Sub(scaledSize).Div(2)
+	res := &GrayPlane{Size: size, Pix: make([]float64, size.X*size.Y)}
+	for i := range res.Pix {
+		res.Pix[i] = 255
+	}
+	for y := 0; y < scaledSize.Y; y++ {
+		copy(res.Pix[(y+offset.Y)*size.X+offset.X:], scaled.Pix[y*scaledSize.X:(y+1)*scaledSize.X])
+	}
+	return res, scale, offset
+}
+
+// Fill scales the plane to cover the whole size keeping its proportions, the parts sticking out are cropped evenly
+func (p *GrayPlane) Fill(size image.Point) *GrayPlane {
+	scale := math.Max(float64(size.X)/float64(p.Size.X), float64(size.Y)/float64(p.Size.Y))
+	scaledSize := image.Point{
+		X: max(size.X, int(math.Round(float64(p.Size.X)*scale))),
+		Y: max(size.Y, int(math.Round(float64(p.Size.Y)*scale))),
+	}
+	scaled := p.Resize(scaledSize)
+	offset := scaledSize.Sub(size).Div(2)
+	res := &GrayPlane{Size: size, Pix: make([]float64, size.X*size.Y)}
+	for y := 0; y < size.Y; y++ {
+		start := (y+offset.Y)*scaledSize.X + offset.X
+		copy(res.Pix[y*size.X:(y+1)*size.X], scaled.Pix[start:start+size.X])
+	}
+	return res
+}
+
+// quantize returns the nearest panel level as a raster byte and the brightness the panel shows it with
+func (c *GrayCalibration) quantize(v float64) (byte, float64) {
+	level := 0
+	for level < len(c.Levels)-1 && v > (c.Levels[level]+c.Levels[level+1])/2 {
+		level++
+	}
+	return byte(level<<4 + level), c.Levels[level]
+}
+
+// correct applies the gamma to a grey value
+func (c *GrayCalibration) correct(v float64) float64 {
+	if c.Gamma == 1 {
+		return v
+	}
+	return 255 * math.Pow(math.Max(0, math.Min(v, 255))/255, c.Gamma)
+}
+
+// 8x8 Bayer threshold matrix
+var bayerMatrix = [8][8]float64{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// error diffusion kernels as the offsets of the neighbours and their shares of the error
+type diffusion struct {
+	dx, dy int
+	share  float64
+}
+
+var diffusionKernels = map[string][]diffusion{
+	DitherFloydSteinberg: {{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16}},
+	// Atkinson only spreads 3/4 of the error which keeps the contrast of the details
+	DitherAtkinson: {{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8}},
+}
+
+// Dither reduces the plane to the panel levels with the panel calibration, the result is a raster of one byte per pixel
+func (p *GrayPlane) Dither(method string) []byte {
+	c := panelCalibration
+	res := make([]byte, len(p.Pix))
+	pix := make([]float64, len(p.Pix))
+	for i, v := range p.Pix {
+		pix[i] = c.correct(v)
+	}
+	width := p.Size.X
+	if kernel, exists := diffusionKernels[method]; exists {
+		for i, v := range pix {
+			var shown float64
+			res[i], shown = c.quantize(v)
+			diff := v - shown
+			x, y := i%width, i/width
+			for _, d := range kernel {
+				if x+d.dx < 0 || x+d.dx >= width || y+d.dy >= p.Size.Y {
+					continue
+				}
+				pix[i+d.dy*width+d.dx] += diff * d.share
+			}
+		}
+		return res
+	}
+	for i, v := range pix {
+		if method == DitherOrdered {
+			x, y := i%width, i/width
+			v += ((bayerMatrix[y%8][x%8]+0.5)/64 - 0.5) * grayLevelStep
+		}
+		res[i], _ = c.quantize(v)
+	}
+	return res
+}
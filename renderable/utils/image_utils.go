@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"image"
 	"os"
-	"reflect"
 	"slices"
 )
 
@@ -13,59 +12,9 @@ func BoundingBox(offset, size image.Point) image.Rectangle {
 	return image.Rectangle{Min: offset, Max: image.Point{X: offset.X + size.X, Y: offset.Y + size.Y}}
 }
 
-func ConvertToGrayScale(img image.Image) ([]byte, error) {
-	size := img.Bounds().Size()
-	var raster []byte = nil
-	rgbaOrNrgba := false
-	var source []uint8
-	if nrgba, nrgbaSuccess := img.(*image.NRGBA); nrgbaSuccess {
-		rgbaOrNrgba = true
-		source = nrgba.Pix
-	} else if rgba, rgbaSuccess := img.(*image.RGBA); rgbaSuccess {
-		rgbaOrNrgba = true
-		source = rgba.Pix
-	}
-	if rgbaOrNrgba {
-		raster = make([]byte, size.X*size.Y)
-		srcIndex := 0
-		grayIndex := 0
-		for y := 0; y < size.Y; y++ {
-			for x := 0; x < size.X; x++ {
-				r := int(source[srcIndex])
-				srcIndex++
-				g := int(source[srcIndex])
-				srcIndex++
-				b := int(source[srcIndex])
-				srcIndex++
-				// we don't use alpha
-				// a := source[srcIndex]
-				srcIndex++
-				// https://www.tutorialspoint.com/dip/grayscale_to_rgb_conversion.htm
-				gray := (299*r + 587*g + 114*b) / 1000
-				// coarse it down to 16 colours
-				grayShade := 0
-				if gray >= 255-8 {
-					grayShade = 15
-				} else {
-					grayShade = (gray + 8) >> 4
-				}
-				if grayShade >= 16 {
-					panic("unexpected gray color")
-				}
-				// making it a byte: 0x00, 0x11, 0x22, 0x33 ... 0xff
-				grayShade = grayShade<<4 + grayShade
-				raster[grayIndex] = byte(grayShade)
-				grayIndex++
-			}
-		}
-	} else {
-		grey, success := img.(*image.Gray)
-		if !success {
-			return nil, fmt.Errorf("expected NRGBA or Gray, but was %v", reflect.TypeOf(img))
-		}
-		raster = grey.Pix
-	}
-	return raster, nil
+// ConvertToGrayScale converts an image of any type to the panel levels with the panel calibration and the dithering
+func ConvertToGrayScale(img image.Image, dither string) []byte {
+	return NewGrayPlane(img).Dither(dither)
 }
 
 func LoadImage(fileName string) (image.Image, error) {
@@ -10,6 +10,9 @@ import (
 
 type MultiRenderable interface {
 	renderable.Renderable
+	// AlignRedraws rounds the next redraw up to the interval and renders all the widgets due by then in one pass,
+	// zero renders every widget at its own time
+	AlignRedraws(interval time.Duration)
 }
 
 func NewMultiRenderable(rect image.Rectangle, renderables []renderable.Renderable, startWithBlackScreen bool) (MultiRenderable, error) {
@@ -46,6 +49,7 @@ type multiRenderable struct {
 	toRender          []renderable.Renderable
 	nextRenderTime    time.Time
 	renderCalcPending bool
+	alignment         time.Duration
 }
 
 func (m *multiRenderable) RedrawNow() {
@@ -63,6 +67,7 @@ func (_ *multiRenderable) String() string {
 
 func (m *multiRenderable) DisplayMode() uint8 {
 	m.maybeCalculateWhatToRerender()
+	res := uint8(clib.A2_Mode)
 	for _, r := range m.toRender {
 		mode := r.DisplayMode()
 		if mode == clib.GC16_Mode {
@@ -71,8 +76,12 @@ func (m *multiRenderable) DisplayMode() uint8 {
 		if mode == clib.INIT_Mode {
 			return clib.INIT_Mode
 		}
+		// grayscale widgets need at least the non-flashing grayscale update
+		if mode == clib.GL16_Mode {
+			res = clib.GL16_Mode
+		}
 	}
-	return clib.A2_Mode
+	return res
 }
 
 func (m *multiRenderable) Offset() image.Point {
@@ -102,10 +111,17 @@ func (m *multiRenderable) maybeCalculateWhatToRerender() {
 			minTime = redrawTime
 		}
 	}
+	if m.alignment > 0 {
+		aligned := minTime.Truncate(m.alignment)
+		if aligned.Before(minTime) {
+			aligned = aligned.Add(m.alignment)
+		}
+		minTime = aligned
+	}
 	m.nextRenderTime = minTime
 	toReRender := make([]renderable.Renderable, 0)
 	for _, r := range m.renderables {
-		if r.NextRedrawDateTimeUtc() == minTime {
+		if !r.NextRedrawDateTimeUtc().After(minTime) {
 			toReRender = append(toReRender, r)
 		}
 	}
@@ -113,6 +129,11 @@ func (m *multiRenderable) maybeCalculateWhatToRerender() {
 	m.renderCalcPending = false
 }
 
+func (m *multiRenderable) AlignRedraws(interval time.Duration) {
+	m.alignment = interval
+	m.renderCalcPending = true
+}
+
 func (m *multiRenderable) NextRedrawDateTimeUtc() time.Time {
 	m.maybeCalculateWhatToRerender()
 	return m.nextRenderTime
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/daylight"
+	"github.com/rotisserie/eris"
+	"time"
+)
+
+// NightSchedule tells when nobody is expected to look at the screen
+type NightSchedule interface {
+	// IsNight tells whether the moment falls into the night and when that changes next
+	IsNight(now time.Time) (bool, time.Time)
+}
+
+// NewNightSchedule creates the schedule configured in the night mode settings, nil if the night mode is off
+func NewNightSchedule(cfg config.ConfigApi, daylightProvider daylight.SunriseSunsetProvider) (NightSchedule, error) {
+	switch cfg.GetNightSchedule() {
+	case "off":
+		return nil, nil
+	case "daylight":
+		return &daylightNightSchedule{cfg: cfg, daylightProvider: daylightProvider}, nil
+	case "fixed":
+		fromText, toText := cfg.GetNightQuietHours()
+		res, err := NewFixedSchedule(fromText, toText)
+		if err != nil {
+			return nil, eris.Wrap(err, "night mode quiet hours")
+		}
+		return res, nil
+	}
+	return nil, eris.Errorf("unknown night schedule '%s', expected off, daylight or fixed", cfg.GetNightSchedule())
+}
+
+// NewFixedSchedule creates the schedule of the same hours every day given as HH:MM, they cross the midnight if from is after to
+func NewFixedSchedule(fromText, toText string) (NightSchedule, error) {
+	from, err := parseTimeOfDay(fromText)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseTimeOfDay(toText)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, eris.Errorf("hours must not start and end at the same time %s", fromText)
+	}
+	return &fixedNightSchedule{from: from, to: to}, nil
+}
+
+// parseTimeOfDay parses HH:MM into the offset from the midnight
+func parseTimeOfDay(text string) (time.Duration, error) {
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, eris.Wrapf(err, "hours must be given as HH:MM, got '%s'", text)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// fixedNightSchedule is the night between two times of the day, the night crosses the midnight if from is after to
+type fixedNightSchedule struct {
+	from time.Duration
+	to   time.Duration
+}
+
+func (s *fixedNightSchedule) IsNight(now time.Time) (bool, time.Time) {
+	today := midnight(now)
+	// time.Date is used instead of adding the duration to keep the wall clock on the days the clocks change
+	at := func(day time.Time, offset time.Duration) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), int(offset.Hours()), int(offset.Minutes())%60, 0, 0, day.Location())
+	}
+	from, to := at(today, s.from), at(today, s.to)
+	tomorrow := today.AddDate(0, 0, 1)
+	if s.from < s.to {
+		switch {
+		case now.Before(from):
+			return false, from
+		case now.Before(to):
+			return true, to
+		}
+		return false, at(tomorrow, s.from)
+	}
+	switch {
+	case now.Before(to):
+		return true, to
+	case now.Before(from):
+		return false, from
+	}
+	return true, at(tomorrow, s.to)
+}
+
+// daylightNightSchedule is the night from the sunset to the sunrise, it lasts all day during polar nights
+type daylightNightSchedule struct {
+	cfg              config.ConfigApi
+	daylightProvider daylight.SunriseSunsetProvider
+}
+
+func (s *daylightNightSchedule) IsNight(now time.Time) (bool, time.Time) {
+	lat, lon := s.cfg.GetDaylightCoordinates()
+	today := s.daylightProvider.GetSunriseSunset(lat, lon, now, now.Location())
+	nextMidnight := midnight(now).AddDate(0, 0, 1)
+	switch today.Type {
+	case daylight.PolarDay:
+		return false, nextMidnight
+	case daylight.PolarNight:
+		return true, nextMidnight
+	}
+	switch {
+	case now.Before(today.Sunrise):
+		return true, today.Sunrise
+	case now.Before(today.Sunset):
+		return false, today.Sunset
+	}
+	tomorrow := s.daylightProvider.GetSunriseSunset(lat, lon, nextMidnight, now.Location())
+	if tomorrow.HasSunrise() {
+		return true, tomorrow.Sunrise
+	}
+	// the polar night or day starts tomorrow, it is decided again at the midnight
+	return true, nextMidnight
+}
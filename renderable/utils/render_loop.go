@@ -13,12 +13,12 @@ type RenderLoop interface {
 }
 
 type renderLoop struct {
-	first           bool
-	timeProvider    TimeProvider
-	einkScreen      eink.EInkScreen
-	multiRenderable MultiRenderable
-	configApi       config.ConfigApi
-	diffRenderer    DiffRenderer
+	first         bool
+	timeProvider  TimeProvider
+	einkScreen    eink.EInkScreen
+	displayPolicy DisplayPolicy
+	configApi     config.ConfigApi
+	diffRenderer  DiffRenderer
 }
 
 func (r *renderLoop) Run() error {
@@ -28,22 +28,23 @@ func (r *renderLoop) Run() error {
 	currentDate := r.timeProvider.LocalNow().Truncate(24 * time.Hour)
 	// main loop
 	for {
-		timeToNextDraw := r.multiRenderable.NextRedrawDateTimeUtc().Sub(r.timeProvider.UtcNow())
+		timeToNextDraw := r.displayPolicy.NextRedrawDateTimeUtc().Sub(r.timeProvider.UtcNow())
 		if timeToNextDraw.Nanoseconds() > 0 {
 			time.Sleep(timeToNextDraw)
 		}
+		switched := r.displayPolicy.Update()
 		if r.configApi.GetRedrawAll() {
 			clib.EPD_IT8951_Clear_Refresh(uint16(screenSize.X), uint16(screenSize.Y), r.einkScreen.GetBufferAddress(), clib.INIT_Mode)
 			r.configApi.ResetRedrawAll()
-			r.multiRenderable.RedrawNow()
+			r.displayPolicy.RedrawNow()
 		}
-		err := r.multiRenderable.Render()
+		err := r.displayPolicy.Render()
 		if err != nil {
 			panic(err)
 		}
-		displayMode := r.multiRenderable.DisplayMode()
-		r.multiRenderable.RedrawFinished()
-		rect, err := r.diffRenderer.SingleRenderPass(r.multiRenderable.Raster())
+		displayMode := r.displayPolicy.DisplayMode()
+		r.displayPolicy.RedrawFinished()
+		rect, err := r.diffRenderer.SingleRenderPass(r.displayPolicy.Raster())
 		if err != nil {
 			println("Diff render failed")
 			panic(err)
@@ -51,13 +52,18 @@ func (r *renderLoop) Run() error {
 		if rect.Empty() {
 			continue
 		}
-		// full redraw at midnight
+		// full redraw at midnight, postponed until the morning in the night mode
 		date := r.timeProvider.LocalNow().Truncate(24 * time.Hour)
-		if date != currentDate {
+		if date != currentDate && !r.displayPolicy.Night() {
 			currentDate = date
 			displayMode = clib.GC16_Mode
 			rect = image.Rectangle{Max: screenSize}
 		}
+		// full redraw when the layout or the palette changes
+		if switched {
+			displayMode = clib.GC16_Mode
+			rect = image.Rectangle{Max: screenSize}
+		}
 		if r.first {
 			displayMode = clib.GC16_Mode
 			rect = image.Rectangle{Max: screenSize}
@@ -81,9 +87,9 @@ func (r *renderLoop) Run() error {
 		fullScreen := rect.Size() == screenSize
 		var rectBuffer []byte
 		if fullScreen {
-			rectBuffer = r.multiRenderable.Raster()
+			rectBuffer = r.displayPolicy.Raster()
 		} else {
-			rectBuffer = CutRectangle(rect, screenSize, r.multiRenderable.Raster())
+			rectBuffer = CutRectangle(rect, screenSize, r.displayPolicy.Raster())
 		}
 
 		compressed, err := CompressRasterTo4bpp(
@@ -116,16 +122,16 @@ func alignRectangles(r image.Rectangle, width int) image.Rectangle {
 func NewRenderLoop(
 	timeProvider TimeProvider,
 	einkScreen eink.EInkScreen,
-	multiRenderable MultiRenderable,
+	displayPolicy DisplayPolicy,
 	cfg config.ConfigApi,
 	diffRenderer DiffRenderer,
 ) RenderLoop {
 	return &renderLoop{
-		first:           true,
-		timeProvider:    timeProvider,
-		einkScreen:      einkScreen,
-		multiRenderable: multiRenderable,
-		configApi:       cfg,
-		diffRenderer:    diffRenderer,
+		first:         true,
+		timeProvider:  timeProvider,
+		einkScreen:    einkScreen,
+		displayPolicy: displayPolicy,
+		configApi:     cfg,
+		diffRenderer:  diffRenderer,
 	}
 }
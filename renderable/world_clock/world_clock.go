@@ -0,0 +1,129 @@
+package world_clock
+
+import (
+	"fkirill.org/eink-meteo-station/clib"
+	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/daylight"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/puppettier"
+	"fkirill.org/eink-meteo-station/renderable"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"github.com/rotisserie/eris"
+	"image"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// WorldClockRenderable shows the time in the configured time zones with day and night indicators
+type WorldClockRenderable interface {
+	renderable.Renderable
+}
+
+func NewWorldClockRenderable(
+	rect image.Rectangle,
+	provider utils.TimeProvider,
+	cfg config.ConfigApi,
+	daylightProvider daylight.SunriseSunsetProvider,
+	units units.Units,
+	translator i18n.Translator,
+) (WorldClockRenderable, error) {
+	worldClockHtmlTemplate, err := template.New("worldClockHtml").Parse(worldClockHtmlTemplateText)
+	if err != nil {
+		panic(eris.ToString(err, true))
+	}
+	zones := make([]*zone, 0, len(cfg.GetWorldClocks()))
+	for _, z := range cfg.GetWorldClocks() {
+		location, err := time.LoadLocation(z.TimeZone)
+		if err != nil {
+			return nil, eris.Wrapf(err, "unknown world clock time zone '%s'", z.TimeZone)
+		}
+		zones = append(zones, &zone{config: z, location: location})
+	}
+	if len(zones) == 0 && !rect.Empty() {
+		return nil, eris.New("world clock widget needs at least one time zone in world_clocks")
+	}
+	return &worldClockRenderable{
+		offset:                 rect.Min,
+		size:                   rect.Size(),
+		nextRedrawTime:         provider.UtcNow(),
+		timeProvider:           provider,
+		worldClockHtmlTemplate: worldClockHtmlTemplate,
+		zones:                  zones,
+		daylightProvider:       daylightProvider,
+		units:                  units,
+		translator:             translator,
+	}, nil
+}
+
+type zone struct {
+	config   *config.WorldClockZone
+	location *time.Location
+}
+
+type worldClockRenderable struct {
+	offset                 image.Point
+	size                   image.Point
+	nextRedrawTime         time.Time
+	cachedRaster           []byte
+	timeProvider           utils.TimeProvider
+	worldClockHtmlTemplate *template.Template
+	zones                  []*zone
+	daylightProvider       daylight.SunriseSunsetProvider
+	units                  units.Units
+	translator             i18n.Translator
+}
+
+func (r *worldClockRenderable) RedrawNow() {
+	r.nextRedrawTime = r.timeProvider.UtcNow()
+}
+
+func (_ *worldClockRenderable) String() string {
+	return "world_clock"
+}
+
+func (r *worldClockRenderable) DisplayMode() uint8 {
+	return clib.A2_Mode
+}
+
+func (r *worldClockRenderable) Offset() image.Point {
+	return r.offset
+}
+
+func (r *worldClockRenderable) BoundingBox() image.Rectangle {
+	return utils.BoundingBox(r.offset, r.size)
+}
+
+func (r *worldClockRenderable) Size() image.Point {
+	return r.size
+}
+
+func (r *worldClockRenderable) NextRedrawDateTimeUtc() time.Time {
+	return r.nextRedrawTime
+}
+
+// RedrawFinished schedules the next redraw for the start of the next minute
+func (r *worldClockRenderable) RedrawFinished() {
+	r.nextRedrawTime = r.timeProvider.UtcNow().Truncate(time.Minute).Add(time.Minute)
+}
+
+func (r *worldClockRenderable) Raster() []byte {
+	return r.cachedRaster
+}
+
+func (r *worldClockRenderable) Render() error {
+	now := r.timeProvider.LocalNow()
+	data := createWorldClockData(now, r.zones, r.size, r.daylightProvider, r.units, r.translator)
+	html, err := renderWorldClock(data, r.worldClockHtmlTemplate)
+	if err != nil {
+		return err
+	}
+	filePrefix := "world_clock_" + strconv.FormatInt(now.UnixNano(), 16)
+	raster, err := puppettier.RenderWidgetInPuppeteer("world_clock", html, filePrefix, r.size)
+	if err != nil {
+		return err
+	}
+	r.cachedRaster = raster
+	return nil
+}
@@ -0,0 +1,144 @@
+package world_clock
+
+import (
+	"fkirill.org/eink-meteo-station/data/daylight"
+	"fkirill.org/eink-meteo-station/i18n"
+	"fkirill.org/eink-meteo-station/renderable/utils"
+	"fkirill.org/eink-meteo-station/units"
+	"image"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type worldClockRow struct {
+	Label string
+	Time  string
+	Day   string // yesterday or tomorrow if the date in the zone differs from the local one, empty otherwise
+	Night bool
+}
+
+type worldClockData struct {
+	Rows      []*worldClockRow
+	Width     int
+	RowHeight int
+	RootPath  string
+}
+
+// createWorldClockData lists the zones in the configured order, the rows share the height of the widget
+func createWorldClockData(
+	now time.Time,
+	zones []*zone,
+	size image.Point,
+	daylightProvider daylight.SunriseSunsetProvider,
+	units units.Units,
+	translator i18n.Translator,
+) *worldClockData {
+	res := &worldClockData{
+		Rows:      make([]*worldClockRow, 0, len(zones)),
+		Width:     size.X,
+		RowHeight: size.Y / max(len(zones), 1),
+		RootPath:  utils.GetRootDir(),
+	}
+	localDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, z := range zones {
+		zoneNow := now.In(z.location)
+		zoneDate := time.Date(zoneNow.Year(), zoneNow.Month(), zoneNow.Day(), 0, 0, 0, 0, time.UTC)
+		row := &worldClockRow{
+			Label: z.config.Label,
+			Time:  units.FormatTime(zoneNow),
+			Night: isNight(zoneNow, z, daylightProvider),
+		}
+		switch {
+		case zoneDate.After(localDate):
+			row.Day = translator.Text("tomorrow")
+		case zoneDate.Before(localDate):
+			row.Day = translator.Text("yesterday")
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return res
+}
+
+// isNight tells whether the Sun is down in the zone, a fixed 19:00 to 7:00 night is used for zones without coordinates
+func isNight(zoneNow time.Time, z *zone, daylightProvider daylight.SunriseSunsetProvider) bool {
+	if z.config.Latitude == 0 && z.config.Longitude == 0 {
+		return zoneNow.Hour() < 7 || zoneNow.Hour() >= 19
+	}
+	sunriseSunset := daylightProvider.GetSunriseSunset(z.config.Latitude, z.config.Longitude, zoneNow, z.location)
+	switch sunriseSunset.Type {
+	case daylight.PolarDay:
+		return false
+	case daylight.PolarNight:
+		return true
+	}
+	return zoneNow.Before(sunriseSunset.Sunrise) || !zoneNow.Before(sunriseSunset.Sunset)
+}
+
+// the widget is refreshed in the black and white mode, the icons are drawn without shades of gray
+var worldClockHtmlTemplateText = `
+<html>
+<head>
+  <link rel="stylesheet" href="file://{{ .RootPath }}/fonts.css"/>
+  <style>
+.worldClockTable {
+    width: {{ .Width }}px;
+    table-layout: fixed;
+    border: 0;
+    border-spacing: 0;
+}
+
+.worldClockTable td {
+    height: {{ .RowHeight }}px;
+    white-space: nowrap;
+    overflow: hidden;
+    border-bottom: 2px solid #000;
+}
+
+.zoneIcon {
+    width: 70px;
+    text-align: center;
+}
+
+.zoneLabel {
+    font-size: 44px;
+    font-family: "bront-ubuntu", serif;
+    text-overflow: ellipsis;
+}
+
+.zoneDay {
+    display: block;
+    font-size: 28px;
+}
+
+.zoneTime {
+    width: 260px;
+    font-size: 72px;
+    font-family: "cartograph", serif;
+    font-weight: bold;
+    text-align: right;
+    padding-right: 20px;
+}
+  </style>
+</head>
+<body style="margin: 0">
+  <table class="worldClockTable">
+{{range .Rows}}    <tr>
+      <td class="zoneIcon">{{if .Night}}<svg width="48" height="48" viewBox="0 0 48 48"><path d="M30 4 A20 20 0 1 0 44 34 A16 16 0 0 1 30 4 Z" fill="#000"/></svg>{{else}}<svg width="48" height="48" viewBox="0 0 48 48" stroke="#000" stroke-width="4" stroke-linecap="round"><circle cx="24" cy="24" r="10" fill="#000"/><path d="M24 2 V8 M24 40 V46 M2 24 H8 M40 24 H46 M8 8 L12 12 M36 36 L40 40 M8 40 L12 36 M36 12 L40 8"/></svg>{{end}}</td>
+      <td class="zoneLabel">{{.Label}}{{if .Day}}<span class="zoneDay">{{.Day}}</span>{{end}}</td>
+      <td class="zoneTime">{{.Time}}</td>
+    </tr>
+{{end}}
+  </table>
+</body>
+</html>
+`
+
+func renderWorldClock(data *worldClockData, template *template.Template) (string, error) {
+	sb := strings.Builder{}
+	err := template.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
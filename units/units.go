@@ -0,0 +1,191 @@
+package units
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"github.com/rotisserie/eris"
+	"math"
+	"strconv"
+	"time"
+)
+
+// NormalPressureHPa is the standard atmospheric pressure at sea level (760 mmHg)
+const NormalPressureHPa = 1013.25
+
+const hPaToMmHgCoeff = 0.750062
+const hPaToInHgCoeff = 0.0295300
+const msToKmhCoeff = 3.6
+const msToMphCoeff = 2.236936
+const mmToInCoeff = 1 / 25.4
+
+// Units converts values from the metric units used by the data providers (°C, hPa, m/s, mm)
+// into the units selected in the config and formats them for display.
+type Units interface {
+	Temperature(celsius float64) float64
+	TemperatureUnit() string
+	FormatTemperature(celsius float64) string
+	Pressure(hPa float64) float64
+	PressureUnit() string
+	FormatPressure(hPa float64) string
+	WindSpeed(metersPerSecond float64) float64
+	WindSpeedUnit() string
+	FormatWindSpeed(metersPerSecond float64) string
+	Precipitation(mm float64) float64
+	PrecipitationUnit() string
+	FormatPrecipitation(mm float64) string
+	Is12HourClock() bool
+	Hour(t time.Time) int
+	FormatTime(t time.Time) string
+}
+
+type units struct {
+	temperature   string
+	pressure      string
+	windSpeed     string
+	precipitation string
+	timeFormat    string
+}
+
+// formatRounded rounds to the nearest whole number, 21.8 degrees are shown as 22 and not as 21
+func formatRounded(v float64) string {
+	return strconv.Itoa(int(math.Round(v)))
+}
+
+func (u *units) Temperature(celsius float64) float64 {
+	if u.temperature == "fahrenheit" {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
+func (u *units) TemperatureUnit() string {
+	if u.temperature == "fahrenheit" {
+		return "°F"
+	}
+	return "°C"
+}
+
+func (u *units) FormatTemperature(celsius float64) string {
+	return formatRounded(u.Temperature(celsius))
+}
+
+func (u *units) Pressure(hPa float64) float64 {
+	switch u.pressure {
+	case "mmhg":
+		return hPa * hPaToMmHgCoeff
+	case "inhg":
+		return hPa * hPaToInHgCoeff
+	}
+	return hPa
+}
+
+func (u *units) PressureUnit() string {
+	switch u.pressure {
+	case "mmhg":
+		return "mmHg"
+	case "inhg":
+		return "inHg"
+	}
+	return "hPa"
+}
+
+func (u *units) FormatPressure(hPa float64) string {
+	if u.pressure == "inhg" {
+		// whole inches are too coarse to be useful
+		return strconv.FormatFloat(u.Pressure(hPa), 'f', 2, 64)
+	}
+	return formatRounded(u.Pressure(hPa))
+}
+
+func (u *units) WindSpeed(metersPerSecond float64) float64 {
+	switch u.windSpeed {
+	case "kmh":
+		return metersPerSecond * msToKmhCoeff
+	case "mph":
+		return metersPerSecond * msToMphCoeff
+	}
+	return metersPerSecond
+}
+
+func (u *units) WindSpeedUnit() string {
+	switch u.windSpeed {
+	case "kmh":
+		return "km/h"
+	case "mph":
+		return "mph"
+	}
+	return "m/s"
+}
+
+func (u *units) FormatWindSpeed(metersPerSecond float64) string {
+	return formatRounded(u.WindSpeed(metersPerSecond))
+}
+
+func (u *units) Precipitation(mm float64) float64 {
+	if u.precipitation == "in" {
+		return mm * mmToInCoeff
+	}
+	return mm
+}
+
+func (u *units) PrecipitationUnit() string {
+	if u.precipitation == "in" {
+		return "in"
+	}
+	return "mm"
+}
+
+func (u *units) FormatPrecipitation(mm float64) string {
+	if u.precipitation == "in" {
+		return strconv.FormatFloat(u.Precipitation(mm), 'f', 1, 64)
+	}
+	return formatRounded(mm)
+}
+
+func (u *units) Is12HourClock() bool {
+	return u.timeFormat == "12h"
+}
+
+// Hour returns the hour to display on a clock: 0 to 23 for the 24-hour clock, 1 to 12 for the 12-hour one
+func (u *units) Hour(t time.Time) int {
+	if !u.Is12HourClock() {
+		return t.Hour()
+	}
+	hour := t.Hour() % 12
+	if hour == 0 {
+		return 12
+	}
+	return hour
+}
+
+func (u *units) FormatTime(t time.Time) string {
+	if u.Is12HourClock() {
+		return t.Format("3:04pm")
+	}
+	return t.Format("15:04")
+}
+
+func NewUnits(cfg config.ConfigApi) (Units, error) {
+	res := &units{
+		temperature:   cfg.GetTemperatureUnit(),
+		pressure:      cfg.GetPressureUnit(),
+		windSpeed:     cfg.GetWindSpeedUnit(),
+		precipitation: cfg.GetPrecipitationUnit(),
+		timeFormat:    cfg.GetTimeFormat(),
+	}
+	if res.temperature != "celsius" && res.temperature != "fahrenheit" {
+		return nil, eris.Errorf("unknown temperature unit '%s', expected 'celsius' or 'fahrenheit'", res.temperature)
+	}
+	if res.pressure != "hpa" && res.pressure != "mmhg" && res.pressure != "inhg" {
+		return nil, eris.Errorf("unknown pressure unit '%s', expected 'hpa', 'mmhg' or 'inhg'", res.pressure)
+	}
+	if res.windSpeed != "ms" && res.windSpeed != "kmh" && res.windSpeed != "mph" {
+		return nil, eris.Errorf("unknown wind speed unit '%s', expected 'ms', 'kmh' or 'mph'", res.windSpeed)
+	}
+	if res.precipitation != "mm" && res.precipitation != "in" {
+		return nil, eris.Errorf("unknown precipitation unit '%s', expected 'mm' or 'in'", res.precipitation)
+	}
+	if res.timeFormat != "24h" && res.timeFormat != "12h" {
+		return nil, eris.Errorf("unknown time format '%s', expected '24h' or '12h'", res.timeFormat)
+	}
+	return res, nil
+}
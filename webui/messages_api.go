@@ -0,0 +1,216 @@
+package webui
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fkirill.org/eink-meteo-station/data/messages"
+	"github.com/rotisserie/eris"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// messageRequest is the body of POST /api/messages, the same fields can be sent as a form.
+// Home Assistant can post it with a rest_command, the id lets an automation replace its own message.
+type messageRequest struct {
+	Id               string     `json:"id"`
+	Text             string     `json:"text"`
+	Priority         int        `json:"priority"`
+	Expires          *time.Time `json:"expires"`            // RFC 3339
+	ExpiresInMinutes int        `json:"expires_in_minutes"` // used if expires is not set, 0 for never
+	From             string     `json:"from"`               // HH:MM, the message is only shown between from and to if both are set
+	To               string     `json:"to"`
+	Weekdays         []int      `json:"weekdays"` // 0 (Sunday) to 6 (Saturday), every day if empty
+}
+
+func (req *messageRequest) toMessage() *messages.Message {
+	res := &messages.Message{Id: req.Id, Text: req.Text, Priority: req.Priority, Expires: req.Expires}
+	if res.Expires == nil && req.ExpiresInMinutes > 0 {
+		expires := time.Now().Add(time.Duration(req.ExpiresInMinutes) * time.Minute)
+		res.Expires = &expires
+	}
+	if req.From != "" || req.To != "" {
+		res.Schedule = &messages.Schedule{From: req.From, To: req.To, Weekdays: req.Weekdays}
+	}
+	return res
+}
+
+func messageFromForm(r *http.Request) (*messages.Message, error) {
+	req := &messageRequest{
+		Id:   r.FormValue("id"),
+		Text: r.FormValue("text"),
+		From: strings.TrimSpace(r.FormValue("from")),
+		To:   strings.TrimSpace(r.FormValue("to")),
+	}
+	var err error
+	intFields := []struct {
+		name   string
+		target *int
+	}{
+		{"priority", &req.Priority},
+		{"expires_in_minutes", &req.ExpiresInMinutes},
+	}
+	for _, field := range intFields {
+		valueStr := r.FormValue(field.name)
+		if valueStr == "" {
+			continue
+		}
+		*field.target, err = strconv.Atoi(valueStr)
+		if err != nil {
+			return nil, eris.Wrapf(err, "cannot parse %s '%s'", field.name, valueStr)
+		}
+	}
+	if expiresStr := r.FormValue("expires"); expiresStr != "" {
+		expires, err := time.Parse(time.RFC3339, expiresStr)
+		if err != nil {
+			return nil, eris.Wrapf(err, "cannot parse expires '%s'", expiresStr)
+		}
+		req.Expires = &expires
+	}
+	// the weekdays come as repeated fields or as one comma separated field
+	for _, weekdaysStr := range r.Form["weekdays"] {
+		for _, weekdayStr := range strings.Split(weekdaysStr, ",") {
+			weekday, err := strconv.Atoi(strings.TrimSpace(weekdayStr))
+			if err != nil {
+				return nil, eris.Wrapf(err, "cannot parse weekday '%s'", weekdayStr)
+			}
+			req.Weekdays = append(req.Weekdays, weekday)
+		}
+	}
+	return req.toMessage(), nil
+}
+
+// authorized checks the bearer token, without a token configured the API only answers the requests from this host
+// so that nobody else on the network can post to the screen
+func (ws *webServer) authorized(w http.ResponseWriter, r *http.Request) bool {
+	presented := ""
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		presented = strings.TrimPrefix(auth, "Bearer ")
+	}
+	switch ws.messagesAccess(r, presented) {
+	case http.StatusForbidden:
+		writeJsonError(w, http.StatusForbidden, "messages API needs a token for the requests from other hosts")
+		return false
+	case http.StatusUnauthorized:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJsonError(w, http.StatusUnauthorized, "wrong or missing bearer token")
+		return false
+	}
+	return true
+}
+
+// formAuthorized checks the token field of the message forms of the page the same way as the API checks the bearer token
+func (ws *webServer) formAuthorized(r *http.Request) bool {
+	switch ws.messagesAccess(r, r.PostFormValue("token")) {
+	case http.StatusForbidden:
+		ws.message = "Error: without the message board token the messages can only be changed from this host"
+		return false
+	case http.StatusUnauthorized:
+		ws.message = "Error: wrong or missing message board token"
+		return false
+	}
+	return true
+}
+
+// messagesAccess compares the presented token with the message board one, it returns the status of the refusal
+// or 0 if the request is allowed
+func (ws *webServer) messagesAccess(r *http.Request, presented string) int {
+	token := ws.configApi.GetMessagesToken()
+	if token == "" {
+		if isLocalRequest(r) {
+			return 0
+		}
+		return http.StatusForbidden
+	}
+	if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+		return 0
+	}
+	return http.StatusUnauthorized
+}
+
+func isLocalRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJson(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.Printf("Error writing api output %v", err)
+	}
+}
+
+func writeJsonError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, map[string]string{"error": message})
+}
+
+func (ws *webServer) listMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	if !ws.authorized(w, r) {
+		return
+	}
+	writeJson(w, http.StatusOK, ws.messageStore.List())
+}
+
+// postMessageHandler takes JSON or a form, it replies with the stored message
+func (ws *webServer) postMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if !ws.authorized(w, r) {
+		return
+	}
+	var message *messages.Message
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "application/json" {
+		req := &messageRequest{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(req)
+		if err != nil {
+			writeJsonError(w, http.StatusBadRequest, "cannot parse message: "+err.Error())
+			return
+		}
+		message = req.toMessage()
+	} else {
+		err := r.ParseForm()
+		if err == nil {
+			message, err = messageFromForm(r)
+		}
+		if err != nil {
+			writeJsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	stored, err := ws.messageStore.Put(message)
+	if err != nil {
+		// the message is checked before it is written, a stored message means the file couldn't be written
+		status := http.StatusBadRequest
+		if stored != nil {
+			status = http.StatusInternalServerError
+		}
+		writeJsonError(w, status, err.Error())
+		return
+	}
+	writeJson(w, http.StatusCreated, stored)
+}
+
+func (ws *webServer) deleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if !ws.authorized(w, r) {
+		return
+	}
+	removed, err := ws.messageStore.Remove(r.PathValue("id"))
+	if err != nil {
+		writeJsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !removed {
+		writeJsonError(w, http.StatusNotFound, "message not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package webui
+
+import (
+	"fkirill.org/eink-meteo-station/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type tokenConfig struct {
+	config.ConfigApi
+	token string
+}
+
+func (c *tokenConfig) GetMessagesToken() string {
+	return c.token
+}
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		remoteAddr    string
+		url           string
+		authorization string
+		expected      int // 0 if the request is authorized
+	}{
+		{name: "bearer token", token: "secret", remoteAddr: "192.168.1.20:5000", url: "/api/messages", authorization: "Bearer secret"},
+		{name: "wrong bearer token", token: "secret", remoteAddr: "127.0.0.1:5000", url: "/api/messages", authorization: "Bearer guess", expected: http.StatusUnauthorized},
+		{name: "token in the URL", token: "secret", remoteAddr: "192.168.1.20:5000", url: "/api/messages?token=secret", expected: http.StatusUnauthorized},
+		{name: "basic authorization", token: "secret", remoteAddr: "192.168.1.20:5000", url: "/api/messages", authorization: "Basic c2VjcmV0", expected: http.StatusUnauthorized},
+		{name: "local request without a token", remoteAddr: "127.0.0.1:5000", url: "/api/messages"},
+		{name: "local IPv6 request without a token", remoteAddr: "[::1]:5000", url: "/api/messages"},
+		{name: "remote request without a token", remoteAddr: "192.168.1.20:5000", url: "/api/messages", expected: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ws := &webServer{configApi: &tokenConfig{token: test.token}}
+			r := httptest.NewRequest(http.MethodPost, test.url, nil)
+			r.RemoteAddr = test.remoteAddr
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			w := httptest.NewRecorder()
+			authorized := ws.authorized(w, r)
+			if authorized != (test.expected == 0) {
+				t.Fatalf("got authorized %v", authorized)
+			}
+			if !authorized && w.Code != test.expected {
+				t.Errorf("got status %d, expected %d", w.Code, test.expected)
+			}
+		})
+	}
+}
+
+func TestFormAuthorized(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		remoteAddr string
+		url        string
+		form       string
+		expected   bool
+	}{
+		{name: "form token", token: "secret", remoteAddr: "192.168.1.20:5000", url: "/", form: "command=add_message&token=secret", expected: true},
+		{name: "wrong form token", token: "secret", remoteAddr: "127.0.0.1:5000", url: "/", form: "command=add_message&token=guess"},
+		{name: "missing form token", token: "secret", remoteAddr: "192.168.1.20:5000", url: "/", form: "command=remove_message"},
+		{name: "token in the URL", token: "secret", remoteAddr: "192.168.1.20:5000", url: "/?token=secret", form: "command=add_message"},
+		{name: "local request without a token", remoteAddr: "127.0.0.1:5000", url: "/", form: "command=add_message", expected: true},
+		{name: "remote request without a token", remoteAddr: "192.168.1.20:5000", url: "/", form: "command=remove_message"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ws := &webServer{configApi: &tokenConfig{token: test.token}}
+			r := httptest.NewRequest(http.MethodPost, test.url, strings.NewReader(test.form))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.RemoteAddr = test.remoteAddr
+			if authorized := ws.formAuthorized(r); authorized != test.expected {
+				t.Fatalf("got authorized %v", authorized)
+			}
+			if !test.expected && !strings.HasPrefix(ws.message, "Error: ") {
+				t.Errorf("got message '%s', expected an error", ws.message)
+			}
+		})
+	}
+}
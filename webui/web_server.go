@@ -2,11 +2,15 @@ package webui
 
 import (
 	"fkirill.org/eink-meteo-station/config"
+	"fkirill.org/eink-meteo-station/data/holidays"
+	"fkirill.org/eink-meteo-station/data/messages"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,7 +21,25 @@ type renderData struct {
 	InternalHumiditySensor    string
 	ExternalHumiditySensor    string
 	PressureSensor            string
+	WindDirectionSensor       string
+	WindSpeedSensor           string
 	SpecialDays               []*config.SpecialDayOrInterval
+	HolidayPacks              []*holidayPackView
+	Messages                  []*messages.Message
+}
+
+type holidayPackView struct {
+	Code     string
+	Name     string
+	Version  string
+	Selected bool
+	Holidays []*holidayView
+}
+
+type holidayView struct {
+	Id     string
+	Name   string
+	Hidden bool
 }
 
 var configPageTemplateText = `
@@ -56,10 +78,75 @@ var configPageTemplateText = `
       <div>
         Pressure sensor name: <input type="text" name="pressure_sensor" value="{{.PressureSensor}}"/>
       </div>
+      <div>
+        Wind direction sensor name (optional): <input type="text" name="wind_direction_sensor" value="{{.WindDirectionSensor}}"/>
+      </div>
+      <div>
+        Wind speed sensor name in m/s (optional): <input type="text" name="wind_speed_sensor" value="{{.WindSpeedSensor}}"/>
+      </div>
       <input type="hidden" name="command" value="set_sensor_names"/>
       <button type="submit">Update sensors</button>
     </form>
   </div>
+  <h1>Public holidays</h1>
+  <div>
+    <form action="/" method="post">
+{{ range .HolidayPacks }}
+      <input type="checkbox" name="holiday_packs" value="{{.Code}}"{{ if .Selected }} checked{{end}} />
+      {{.Name}} ({{.Code}}, version {{.Version}})
+      <br />
+  {{ if .Selected }}
+      <input type="hidden" name="hidden_holidays_pack" value="{{.Code}}"/>
+    {{ range .Holidays }}
+      &nbsp;&nbsp;<input type="checkbox" name="hidden_holidays" value="{{.Id}}"{{ if .Hidden }} checked{{end}} />
+      hide {{.Name}} ({{.Id}})
+      <br />
+    {{ end }}
+  {{ end }}
+{{ end }}
+      A special day with the id of a holiday replaces it.
+      <br />
+      <button type="submit">Update public holidays</button>
+      <input type="hidden" name="command" value="set_holidays"/>
+    </form>
+  </div>
+  <h1>Messages</h1>
+  <div>
+{{ range .Messages }}
+    <form action="/" method="post">
+      {{ if eq .Priority 2 }}[urgent] {{ else if eq .Priority 1 }}[important] {{ end }}{{.Text}}
+      {{ if .Expires }}(until {{ .Expires.Local.Format "2006-01-02 15:04" }}){{ end }}
+      {{ if .Schedule }}({{.Schedule.From}}-{{.Schedule.To}}){{ end }}
+      <input type="hidden" name="message_id" value="{{.Id}}"/>
+      <input type="hidden" name="command" value="remove_message"/>
+      Token: <input type="password" name="token" size="10"/>
+      <button type="submit">Remove</button>
+    </form>
+{{ end }}
+    <form action="/" method="post">
+      Text: <input type="text" name="text" size="60"/>
+      <br />
+      Priority:
+      <select name="priority">
+        <option value="0">Normal</option>
+        <option value="1">Important</option>
+        <option value="2">Urgent</option>
+      </select>
+      Expires in minutes (0 for never): <input type="number" min="0" name="expires_in_minutes" value="0"/>
+      <br />
+      Shown from (HH:MM, optional): <input type="text" name="from" size="5"/>
+      to: <input type="text" name="to" size="5"/>
+      <br />
+      Token: <input type="password" name="token" size="10"/>
+      <br />
+      The messages can be posted to /api/messages as JSON or a form, e.g. from a Home Assistant rest_command,
+      with the message board token as "Authorization: Bearer &lt;token&gt;". The forms of this page need the token as well.
+      Without a token configured only this host can change the messages.
+      <br />
+      <input type="hidden" name="command" value="add_message"/>
+      <button type="submit">Add message</button>
+    </form>
+  </div>
   <h1>Special days</h1>
   <div>
     <form action="/" method="post">
@@ -75,6 +162,7 @@ var configPageTemplateText = `
         <option value="once_off"{{ if eq .Type "once_off"}} selected{{end}}>Once off</option>
         <option value="annual"{{ if eq .Type "annual"}} selected{{end}}>Annual</option>
         <option value="interval"{{ if eq .Type "interval"}} selected{{end}}>Interval</option>
+        <option value="recurring"{{ if eq .Type "recurring"}} selected{{end}}>Recurring</option>
       </select>
       <br />
       <input type="checkbox" id="public_holiday" name="special_days.{{.Index}}.public_holiday" value="true"{{ if .IsPublicHoliday }} checked{{end}} />
@@ -119,6 +207,47 @@ var configPageTemplateText = `
       </select>
       Year: <input type="number" min="2021" max="2100" name="special_days.{{.Index}}.end_year" value="{{.EndDateYear}}" />
       <br/>
+      {{ $r := recurrenceOf . }}
+      Recurring every <input type="number" min="1" max="100" name="special_days.{{.Index}}.interval" value="{{ if $r.Interval }}{{$r.Interval}}{{else}}1{{end}}" />
+      <select name="special_days.{{.Index}}.frequency">
+        <option value="daily"{{ if eq $r.Frequency "daily"}} selected{{end}}>day(s)</option>
+        <option value="weekly"{{ if eq $r.Frequency "weekly"}} selected{{end}}>week(s)</option>
+        <option value="monthly"{{ if eq $r.Frequency "monthly"}} selected{{end}}>month(s)</option>
+        <option value="yearly"{{ if eq $r.Frequency "yearly"}} selected{{end}}>year(s)</option>
+        <option value="easter"{{ if eq $r.Frequency "easter"}} selected{{end}}>year(s) relative to Easter</option>
+      </select>
+      (start date is the first occurrence)
+      <br />
+      On:
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="1"{{ if hasWeekday $r 1 }} checked{{end}} />Mon
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="2"{{ if hasWeekday $r 2 }} checked{{end}} />Tue
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="3"{{ if hasWeekday $r 3 }} checked{{end}} />Wed
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="4"{{ if hasWeekday $r 4 }} checked{{end}} />Thu
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="5"{{ if hasWeekday $r 5 }} checked{{end}} />Fri
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="6"{{ if hasWeekday $r 6 }} checked{{end}} />Sat
+      <input type="checkbox" name="special_days.{{.Index}}.weekdays" value="0"{{ if hasWeekday $r 0 }} checked{{end}} />Sun
+      (weekly, or the weekday for monthly/yearly rules below; start date weekday if none)
+      <br />
+      Monthly/yearly on:
+      <select name="special_days.{{.Index}}.week_of_month">
+        <option value="0"{{ if eq $r.WeekOfMonth 0 }} selected{{end}}>the start date day</option>
+        <option value="1"{{ if eq $r.WeekOfMonth 1 }} selected{{end}}>the first weekday</option>
+        <option value="2"{{ if eq $r.WeekOfMonth 2 }} selected{{end}}>the second weekday</option>
+        <option value="3"{{ if eq $r.WeekOfMonth 3 }} selected{{end}}>the third weekday</option>
+        <option value="4"{{ if eq $r.WeekOfMonth 4 }} selected{{end}}>the fourth weekday</option>
+        <option value="5"{{ if eq $r.WeekOfMonth 5 }} selected{{end}}>the fifth weekday</option>
+        <option value="-1"{{ if eq $r.WeekOfMonth -1 }} selected{{end}}>the last weekday</option>
+      </select>
+      of the month
+      <br />
+      Days after Easter (negative for before): <input type="number" min="-100" max="100" name="special_days.{{.Index}}.easter_offset" value="{{$r.EasterOffsetDays}}" />
+      Lasts days: <input type="number" min="1" max="366" name="special_days.{{.Index}}.duration_days" value="{{ if $r.DurationDays }}{{$r.DurationDays}}{{else}}1{{end}}" />
+      <br />
+      Ends after occurrences (0 for never): <input type="number" min="0" name="special_days.{{.Index}}.count" value="{{$r.Count}}" />
+      or on day: <input type="number" min="0" max="31" name="special_days.{{.Index}}.until_day" value="{{$r.UntilDay}}" />
+      Month: <input type="number" min="0" max="12" name="special_days.{{.Index}}.until_month" value="{{$r.UntilMonth}}" />
+      Year (0 for never): <input type="number" min="0" max="2100" name="special_days.{{.Index}}.until_year" value="{{$r.UntilYear}}" />
+      <br/>
       -------------------------------------
       <br/>
 {{ end }}
@@ -147,20 +276,36 @@ var tmpl *template.Template
 
 func init() {
 	var err error
-	tmpl, err = template.New("config").Parse(configPageTemplateText)
+	tmpl, err = template.New("config").Funcs(template.FuncMap{
+		"recurrenceOf": recurrenceOf,
+		"hasWeekday":   hasWeekday,
+	}).Parse(configPageTemplateText)
 	if err != nil {
 		log.Fatalf("cannot parse html template: %v", err)
 	}
 }
 
+// recurrenceOf lets the template render recurrence fields for special days which are not recurring yet
+func recurrenceOf(sd *config.SpecialDayOrInterval) *config.Recurrence {
+	if sd.Recurrence == nil {
+		return &config.Recurrence{}
+	}
+	return sd.Recurrence
+}
+
+func hasWeekday(r *config.Recurrence, weekday int) bool {
+	return slices.Contains(r.Weekdays, weekday)
+}
+
 type WebServer interface {
 	Start() error
 }
 
 type webServer struct {
-	configApi   config.ConfigApi
-	specialDays []*config.SpecialDayOrInterval
-	message     string
+	configApi    config.ConfigApi
+	messageStore messages.MessageStore
+	specialDays  []*config.SpecialDayOrInterval
+	message      string
 }
 
 func (ws *webServer) mainHandler(w http.ResponseWriter, r *http.Request) {
@@ -179,6 +324,16 @@ func (ws *webServer) mainHandler(w http.ResponseWriter, r *http.Request) {
 				ws.addSpecialDay()
 			} else if command == "remove_special_day" {
 				ws.removeSpecialDay(r)
+			} else if command == "set_holidays" {
+				ws.setHolidays(r)
+			} else if command == "add_message" {
+				if ws.formAuthorized(r) {
+					ws.addMessage(r)
+				}
+			} else if command == "remove_message" {
+				if ws.formAuthorized(r) {
+					ws.removeMessage(r)
+				}
 			} else {
 				ws.message = fmt.Sprintf("Commande not recognized: %s", command)
 			}
@@ -193,7 +348,11 @@ func (ws *webServer) mainHandler(w http.ResponseWriter, r *http.Request) {
 		InternalHumiditySensor:    ws.configApi.GetInternalHumiditySensorName(),
 		ExternalHumiditySensor:    ws.configApi.GetExternalHumiditySensorName(),
 		PressureSensor:            ws.configApi.GetPressureSensorName(),
+		WindDirectionSensor:       ws.configApi.GetWindDirectionSensorName(),
+		WindSpeedSensor:           ws.configApi.GetWindSpeedSensorName(),
 		SpecialDays:               ws.specialDays,
+		HolidayPacks:              ws.holidayPacks(),
+		Messages:                  ws.messageStore.List(),
 	}
 	err := tmpl.Execute(w, data)
 	if err != nil {
@@ -214,7 +373,7 @@ func (ws *webServer) setSpecialDays(r *http.Request) {
 			ws.message += "; Warning: special day display text must not be empty"
 		}
 		typeStr := r.FormValue(fmt.Sprintf("special_days.%d.type", ws.specialDays[i].Index))
-		if typeStr != "once_off" && typeStr != "annual" && typeStr != "interval" {
+		if typeStr != "once_off" && typeStr != "annual" && typeStr != "interval" && typeStr != "recurring" {
 			ws.message += fmt.Sprintf("; Error: unrecognized special day type: '%s'", typeStr)
 			continue
 		}
@@ -293,6 +452,15 @@ func (ws *webServer) setSpecialDays(r *http.Request) {
 				ws.message += "; Warning: start date must be before end date"
 			}
 		}
+		if typeStr == "recurring" {
+			recurrence, ok := ws.parseRecurrence(r, ws.specialDays[i].Index)
+			if !ok {
+				continue
+			}
+			ws.specialDays[i].Recurrence = recurrence
+		} else {
+			ws.specialDays[i].Recurrence = nil
+		}
 		isPublicHolidayStr := r.FormValue(fmt.Sprintf("special_days.%d.public_holiday", ws.specialDays[i].Index))
 		isSchoolHolidayStr := r.FormValue(fmt.Sprintf("special_days.%d.school_holiday", ws.specialDays[i].Index))
 		ws.specialDays[i].IsPublicHoliday = isPublicHolidayStr == "true"
@@ -302,6 +470,52 @@ func (ws *webServer) setSpecialDays(r *http.Request) {
 	ws.message = "Special days set"
 }
 
+func (ws *webServer) parseRecurrence(r *http.Request, index int) (*config.Recurrence, bool) {
+	frequency := r.FormValue(fmt.Sprintf("special_days.%d.frequency", index))
+	if frequency != "daily" && frequency != "weekly" && frequency != "monthly" && frequency != "yearly" && frequency != "easter" {
+		ws.message += fmt.Sprintf("; Error: unrecognized recurrence frequency: '%s'", frequency)
+		return nil, false
+	}
+	res := &config.Recurrence{Frequency: frequency, Weekdays: []int{}}
+	for _, weekdayStr := range r.Form[fmt.Sprintf("special_days.%d.weekdays", index)] {
+		weekday, err := strconv.Atoi(weekdayStr)
+		if err != nil || weekday < 0 || weekday > 6 {
+			ws.message += fmt.Sprintf("; Error: cannot parse weekday '%s'", weekdayStr)
+			return nil, false
+		}
+		res.Weekdays = append(res.Weekdays, weekday)
+	}
+	intFields := []struct {
+		name   string
+		target *int
+	}{
+		{"interval", &res.Interval},
+		{"week_of_month", &res.WeekOfMonth},
+		{"easter_offset", &res.EasterOffsetDays},
+		{"duration_days", &res.DurationDays},
+		{"count", &res.Count},
+		{"until_day", &res.UntilDay},
+		{"until_month", &res.UntilMonth},
+		{"until_year", &res.UntilYear},
+	}
+	for _, field := range intFields {
+		valueStr := r.FormValue(fmt.Sprintf("special_days.%d.%s", index, field.name))
+		value, err := strconv.Atoi(valueStr)
+		if err != nil {
+			ws.message += fmt.Sprintf("; Error: cannot parse recurrence %s '%s'", field.name, valueStr)
+			return nil, false
+		}
+		*field.target = value
+	}
+	if res.WeekOfMonth != 0 && (frequency == "monthly" || frequency == "yearly") && len(res.Weekdays) > 1 {
+		ws.message += "; Warning: only the first selected weekday is used for monthly and yearly rules"
+	}
+	if res.UntilYear != 0 && (res.UntilDay < 1 || res.UntilDay > 31 || res.UntilMonth < 1 || res.UntilMonth > 12) {
+		ws.message += "; Warning: recurrence end date is not a valid date"
+	}
+	return res, true
+}
+
 func (ws *webServer) redrawAll() {
 	ws.configApi.RedrawAll()
 	ws.message = "Full redraw initiated"
@@ -339,12 +553,47 @@ func (ws *webServer) addSpecialDay() {
 	ws.message = "Special day added"
 }
 
+func (ws *webServer) holidayPacks() []*holidayPackView {
+	selected := ws.configApi.GetHolidayPacks()
+	hidden := ws.configApi.GetHiddenHolidays()
+	res := make([]*holidayPackView, 0, len(holidays.Packs))
+	for _, pack := range holidays.Packs {
+		view := &holidayPackView{
+			Code:     pack.Code,
+			Name:     pack.Name,
+			Version:  pack.Version,
+			Selected: slices.Contains(selected, pack.Code),
+		}
+		for _, rule := range pack.Rules {
+			id := holidays.FullId(pack, rule)
+			view.Holidays = append(view.Holidays, &holidayView{Id: id, Name: rule.Name("en"), Hidden: slices.Contains(hidden, id)})
+		}
+		res = append(res, view)
+	}
+	return res
+}
+
+// setHolidays only replaces the hidden holidays of the packs listed on the page, the ones hidden in the packs
+// which were not selected are kept for when the pack is selected again
+func (ws *webServer) setHolidays(r *http.Request) {
+	listed := r.Form["hidden_holidays_pack"]
+	hidden := slices.DeleteFunc(slices.Clone(ws.configApi.GetHiddenHolidays()), func(id string) bool {
+		code, _, _ := strings.Cut(id, "/")
+		return slices.Contains(listed, code)
+	})
+	ws.configApi.SetHolidayPacks(r.Form["holiday_packs"])
+	ws.configApi.SetHiddenHolidays(append(hidden, r.Form["hidden_holidays"]...))
+	ws.message = "Public holidays updated"
+}
+
 func (ws *webServer) setSensorNames(r *http.Request) {
 	ws.configApi.SetInternalTemperatureSensorName(r.FormValue("internal_temperature_sensor"))
 	ws.configApi.SetExternalTemperatureSensorName(r.FormValue("external_temperature_sensor"))
 	ws.configApi.SetInternalHumiditySensorName(r.FormValue("internal_humidity_sensor"))
 	ws.configApi.SetExternalHumiditySensorName(r.FormValue("external_humidity_sensor"))
 	ws.configApi.SetPressureSensorName(r.FormValue("pressure_sensor"))
+	ws.configApi.SetWindDirectionSensorName(r.FormValue("wind_direction_sensor"))
+	ws.configApi.SetWindSpeedSensorName(r.FormValue("wind_speed_sensor"))
 	ws.configApi.RedrawAll()
 	ws.message = "Sensors updated successfully, full redraw initiated"
 }
@@ -355,12 +604,38 @@ func (ws *webServer) updateSpecialDayIndices() {
 	}
 }
 
-func NewWebServer(configApi config.ConfigApi) WebServer {
-	return &webServer{configApi: configApi, message: "", specialDays: configApi.GetSpecialDays()}
+func (ws *webServer) addMessage(r *http.Request) {
+	message, err := messageFromForm(r)
+	if err == nil {
+		_, err = ws.messageStore.Put(message)
+	}
+	if err != nil {
+		ws.message = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	ws.message = "Message added"
+}
+
+func (ws *webServer) removeMessage(r *http.Request) {
+	removed, err := ws.messageStore.Remove(r.FormValue("message_id"))
+	if err != nil {
+		ws.message = fmt.Sprintf("Error: %v", err)
+	} else if !removed {
+		ws.message = "Message not found"
+	} else {
+		ws.message = "Message removed"
+	}
+}
+
+func NewWebServer(configApi config.ConfigApi, messageStore messages.MessageStore) WebServer {
+	return &webServer{configApi: configApi, messageStore: messageStore, message: "", specialDays: configApi.GetSpecialDays()}
 }
 
 func (ws *webServer) Start() error {
 	http.HandleFunc("/", ws.mainHandler)
+	http.HandleFunc("GET /api/messages", ws.listMessagesHandler)
+	http.HandleFunc("POST /api/messages", ws.postMessageHandler)
+	http.HandleFunc("DELETE /api/messages/{id}", ws.deleteMessageHandler)
 	return http.ListenAndServe(":8080", nil)
 }
 